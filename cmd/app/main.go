@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
 	"syscall"
 	"time"
 
@@ -15,13 +16,43 @@ import (
 
 	configs "myproject/internal/app/config"
 	myhttp "myproject/internal/deliveries/http"
+	"myproject/internal/pkg/email"
+	"myproject/internal/pkg/gateway"
+	"myproject/internal/pkg/health"
+	"myproject/internal/pkg/metrics"
+	"myproject/internal/pkg/money"
+	"myproject/internal/pkg/storage"
+	"myproject/internal/pkg/token"
+	"myproject/internal/pkg/tracing"
+	accounttokenrepo "myproject/internal/repositories/accounttoken"
+	auditrepo "myproject/internal/repositories/audit"
 	carrepo "myproject/internal/repositories/car"
+	idempotencyrepo "myproject/internal/repositories/idempotency"
+	ledgerrepo "myproject/internal/repositories/ledger"
+	loginthrottlerepo "myproject/internal/repositories/loginthrottle"
+	mediarepo "myproject/internal/repositories/media"
+	mfarepo "myproject/internal/repositories/mfa"
 	orderrepo "myproject/internal/repositories/order"
 	paymentrepo "myproject/internal/repositories/payment"
+	reservationrepo "myproject/internal/repositories/reservation"
+	sessionrepo "myproject/internal/repositories/session"
+	statsrepo "myproject/internal/repositories/stats"
+	testdriverepo "myproject/internal/repositories/testdrive"
+	"myproject/internal/repositories/txmanager"
 	userrepo "myproject/internal/repositories/user"
+	auditservice "myproject/internal/services/audit"
 	carservice "myproject/internal/services/car"
+	idempotencyservice "myproject/internal/services/idempotency"
+	ledgerservice "myproject/internal/services/ledger"
+	loginguardservice "myproject/internal/services/loginguard"
+	mediaservice "myproject/internal/services/media"
+	mfaservice "myproject/internal/services/mfa"
 	orderservice "myproject/internal/services/order"
 	paymentservice "myproject/internal/services/payment"
+	reservationservice "myproject/internal/services/reservation"
+	sessionservice "myproject/internal/services/session"
+	statsservice "myproject/internal/services/stats"
+	testdriveservice "myproject/internal/services/testdrive"
 	userservice "myproject/internal/services/user"
 	"myproject/pkg/logger"
 )
@@ -35,9 +66,22 @@ func main() {
 		return
 	}
 
-	appLogger := logger.New(cfg.App.LogLevel)
+	appLogger, err := logger.New(cfg.App.LogLevel, cfg.App.LogFormat)
+	if err != nil {
+		log.Fatalf("failed to configure logger: %s", err)
+	}
 	appLogger.Info("app started", "port", cfg.Server.Port, "environment", cfg.App.Environment)
 
+	if err := money.SetDefaultCurrency(cfg.App.Currency); err != nil {
+		appLogger.Fatal("invalid app.currency", "error", err)
+	}
+
+	tracer, err := newTracer(cfg)
+	if err != nil {
+		appLogger.Fatal("invalid tracing config", "error", err)
+	}
+	tracing.SetDefault(tracer)
+
 	dbPool, err := pgxpool.Connect(context.Background(), fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Database.Host,
@@ -57,20 +101,231 @@ func main() {
 	carRepo := carrepo.NewPostgresRepo(dbPool)
 	orderRepo := orderrepo.NewPostgresRepository(dbPool)
 	paymentRepo := paymentrepo.NewPaymentRepository(dbPool)
+	reservationRepo := reservationrepo.NewPostgresRepository(dbPool)
+	testDriveRepo := testdriverepo.NewPostgresRepository(dbPool)
+	ledgerRepo := ledgerrepo.NewPostgresRepository(dbPool)
+	idempotencyRepo := idempotencyrepo.NewPostgresRepository(dbPool)
+	sessionRepo := sessionrepo.NewPostgresRepository(dbPool)
+	mfaRepo := mfarepo.NewPostgresRepository(dbPool)
+	auditRepo := auditrepo.NewPostgresRepository(dbPool)
+	loginThrottleRepo := loginthrottlerepo.NewPostgresRepository(dbPool)
+	accountTokenRepo := accounttokenrepo.NewPostgresRepository(dbPool)
+	statsRepo := statsrepo.NewPostgresRepository(dbPool)
+	mediaRepo := mediarepo.NewPostgresRepository(dbPool)
+	txManager := txmanager.New(dbPool)
+
+	metricsRegistry := metrics.NewRegistry()
+	txmanager.RegisterPoolMetrics(metricsRegistry, dbPool)
+	statsservice.RegisterMetrics(metricsRegistry, statsRepo)
+
+	healthTimeout, err := time.ParseDuration(cfg.Health.Timeout)
+	if err != nil {
+		appLogger.Fatal("invalid health timeout", "error", err)
+	}
+	healthCacheFor, err := time.ParseDuration(cfg.Health.CacheFor)
+	if err != nil {
+		appLogger.Fatal("invalid health cache_for", "error", err)
+	}
+	drainDelay, err := time.ParseDuration(cfg.Server.DrainDelay)
+	if err != nil {
+		appLogger.Fatal("invalid server drain delay", "error", err)
+	}
+	healthRegistry := health.NewRegistry(healthTimeout)
+	txmanager.RegisterHealthChecks(healthRegistry, dbPool, cfg.Health.MinMigration, healthCacheFor)
+
+	blobStore, err := newBlobStore(cfg)
+	if err != nil {
+		appLogger.Fatal("failed to configure media storage", "error", err)
+	}
+	healthRegistry.Register(health.Check{Name: "storage", CacheFor: healthCacheFor, Func: blobStore.Check})
+	var mediaFiles http.Handler
+	if local, ok := blobStore.(*storage.LocalStore); ok {
+		mediaFiles = local
+	}
+
+	accessTTL, err := time.ParseDuration(cfg.JWT.TTL)
+	if err != nil {
+		appLogger.Fatal("invalid jwt ttl", "error", err)
+	}
+	refreshTTL, err := time.ParseDuration(cfg.JWT.RefreshTTL)
+	if err != nil {
+		appLogger.Fatal("invalid jwt refresh ttl", "error", err)
+	}
+	sessionSweepInterval, err := time.ParseDuration(cfg.JWT.SessionSweepInterval)
+	if err != nil {
+		appLogger.Fatal("invalid session sweep interval", "error", err)
+	}
+	tokenMaker, err := newTokenMaker(cfg)
+	if err != nil {
+		appLogger.Fatal("failed to configure token maker", "error", err)
+	}
+	sessionService := sessionservice.NewService(sessionRepo, txManager, userRepo, tokenMaker, accessTTL, refreshTTL)
+	verificationTTL, err := time.ParseDuration(cfg.Email.VerificationTTL)
+	if err != nil {
+		appLogger.Fatal("invalid email verification ttl", "error", err)
+	}
+	resetTTL, err := time.ParseDuration(cfg.Email.ResetTTL)
+	if err != nil {
+		appLogger.Fatal("invalid password reset ttl", "error", err)
+	}
+	accountLoginPolicy, err := newLoginPolicy(cfg.Login.Account)
+	if err != nil {
+		appLogger.Fatal("invalid login.account policy", "error", err)
+	}
+	ipLoginPolicy, err := newLoginPolicy(cfg.Login.IP)
+	if err != nil {
+		appLogger.Fatal("invalid login.ip policy", "error", err)
+	}
+	loginSweepInterval, err := time.ParseDuration(cfg.Login.SweepInterval)
+	if err != nil {
+		appLogger.Fatal("invalid login sweep interval", "error", err)
+	}
+	loginGuard := loginguardservice.NewService(loginThrottleRepo, txManager, accountLoginPolicy, ipLoginPolicy)
+	challengeTTL, err := time.ParseDuration(cfg.MFA.ChallengeTTL)
+	if err != nil {
+		appLogger.Fatal("invalid mfa challenge ttl", "error", err)
+	}
+	mfaSweepInterval, err := time.ParseDuration(cfg.MFA.SweepInterval)
+	if err != nil {
+		appLogger.Fatal("invalid mfa sweep interval", "error", err)
+	}
+	mfaService := mfaservice.NewService(mfaRepo, txManager, userRepo, sessionService, loginGuard, mfaservice.Options{
+		Issuer:        cfg.MFA.Issuer,
+		RequiredRoles: cfg.MFA.RequiredRoles,
+		ChallengeTTL:  challengeTTL,
+		MaxAttempts:   cfg.MFA.MaxAttempts,
+	})
+	emailSender, err := newEmailSender(cfg, appLogger)
+	if err != nil {
+		appLogger.Fatal("failed to configure email sender", "error", err)
+	}
+	mailer, err := email.NewMailer(emailSender)
+	if err != nil {
+		appLogger.Fatal("failed to load email templates", "error", err)
+	}
+	auditService := auditservice.NewService(auditRepo, txManager)
+	userService := userservice.NewUserService(userRepo, txManager, sessionService, loginGuard, mfaService, accountTokenRepo, mailer, auditService, userservice.AccountOptions{
+		AppURL:          cfg.Email.AppURL,
+		VerificationTTL: verificationTTL,
+		ResetTTL:        resetTTL,
+	})
+	mediaOptions, err := newMediaOptions(cfg)
+	if err != nil {
+		appLogger.Fatal("invalid media config", "error", err)
+	}
+	mediaService := mediaservice.NewService(mediaRepo, carRepo, txManager, blobStore, auditService, mediaOptions)
+	carService := carservice.NewService(carRepo, txManager, testDriveRepo, reservationRepo, auditService, mediaService)
+	ledgerService := ledgerservice.NewService(ledgerRepo, txManager)
+	chargeTimeout, err := time.ParseDuration(cfg.Payments.ChargeTimeout)
+	if err != nil {
+		appLogger.Fatal("invalid payment charge timeout", "error", err)
+	}
+	reconcileInterval, err := time.ParseDuration(cfg.Payments.ReconcileInterval)
+	if err != nil {
+		appLogger.Fatal("invalid payment reconcile interval", "error", err)
+	}
+	paymentGateway, err := newPaymentGateway(cfg)
+	if err != nil {
+		appLogger.Fatal("failed to configure payment gateway", "error", err)
+	}
+	paymentService := paymentservice.NewService(
+		paymentRepo,
+		txManager,
+		ledgerService,
+		auditService,
+		paymentGateway,
+		cfg.Payments.WebhookSecrets[paymentGateway.Name()],
+		chargeTimeout,
+	)
+	depositPolicy, err := newDepositPolicy(cfg)
+	if err != nil {
+		appLogger.Fatal("invalid order deposit bands", "error", err)
+	}
+	orderService := orderservice.NewService(orderRepo, txManager, carService, ledgerService, userRepo, depositPolicy, auditService)
+
+	holdDuration, err := time.ParseDuration(cfg.Reservation.HoldDuration)
+	if err != nil {
+		appLogger.Fatal("invalid reservation hold duration", "error", err)
+	}
+	sweepInterval, err := time.ParseDuration(cfg.Reservation.SweepInterval)
+	if err != nil {
+		appLogger.Fatal("invalid reservation sweep interval", "error", err)
+	}
+	reservationService := reservationservice.NewService(reservationRepo, txManager, carService, orderService, holdDuration)
 
-	userService := userservice.NewUserService(userRepo)
-	carService := carservice.NewService(carRepo)
-	paymentService := paymentservice.NewService(paymentRepo)
-	orderService := orderservice.NewService(orderRepo, carService, userService, paymentService) // Fixed: declare with :=
+	openingHours, err := testdriveservice.ParseOpeningHours(
+		cfg.Showroom.Timezone,
+		cfg.Showroom.OpensAt,
+		cfg.Showroom.ClosesAt,
+		cfg.Showroom.WorkingDays,
+	)
+	if err != nil {
+		appLogger.Fatal("invalid showroom opening hours", "error", err)
+	}
+	testDriveDuration, err := time.ParseDuration(cfg.Showroom.TestDriveDuration)
+	if err != nil {
+		appLogger.Fatal("invalid test drive duration", "error", err)
+	}
+	testDriveService := testdriveservice.NewService(testDriveRepo, carService, userService, openingHours, testDriveDuration)
+
+	idempotencyTTL, err := time.ParseDuration(cfg.Idempotency.TTL)
+	if err != nil {
+		appLogger.Fatal("invalid idempotency ttl", "error", err)
+	}
+	idempotencySweepInterval, err := time.ParseDuration(cfg.Idempotency.SweepInterval)
+	if err != nil {
+		appLogger.Fatal("invalid idempotency sweep interval", "error", err)
+	}
+	idempotencyService := idempotencyservice.NewService(idempotencyRepo, idempotencyTTL)
+	mediaSweepInterval, err := time.ParseDuration(cfg.Media.SweepInterval)
+	if err != nil {
+		appLogger.Fatal("invalid media sweep interval", "error", err)
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	workers := map[string]interface {
+		Run(ctx context.Context)
+		Check(ctx context.Context) error
+	}{
+		"reservation_sweeper":    reservationservice.NewSweeper(reservationService, sweepInterval, appLogger),
+		"payment_reconciler":     paymentservice.NewReconciler(paymentService, reconcileInterval, appLogger),
+		"session_sweeper":        sessionservice.NewSweeper(sessionService, sessionSweepInterval, appLogger),
+		"idempotency_sweeper":    idempotencyservice.NewSweeper(idempotencyService, idempotencySweepInterval, appLogger),
+		"login_throttle_sweeper": loginguardservice.NewSweeper(loginGuard, loginSweepInterval, appLogger),
+		"mfa_sweeper":            mfaservice.NewSweeper(mfaService, mfaSweepInterval, appLogger),
+		"media_sweeper":          mediaservice.NewSweeper(mediaService, mediaSweepInterval, appLogger),
+	}
+	for name, worker := range workers {
+		healthRegistry.Register(health.Check{Name: "worker." + name, Live: true, Func: worker.Check})
+		go worker.Run(workerCtx)
+	}
 
 	routerDeps := myhttp.RouterDependencies{
-		UserUC:    userService,
-		CarUC:     carService,
-		OrderUC:   orderService,
-		PaymentUC: paymentService,
-		Logger:    appLogger,
+		UserUC:         userService,
+		CarUC:          carService,
+		MediaUC:        mediaService,
+		OrderUC:        orderService,
+		PaymentUC:      paymentService,
+		ReservationUC:  reservationService,
+		TestDriveUC:    testDriveService,
+		LedgerUC:       ledgerService,
+		AuthUC:         sessionService,
+		MFAUC:          mfaService,
+		AuditUC:        auditService,
+		Idempotency:    idempotencyService,
+		Sessions:       sessionService,
+		Auth:           tokenMaker,
+		Metrics:        metricsRegistry,
+		Health:         healthRegistry,
+		MediaFiles:     mediaFiles,
+		TrustedProxies: cfg.Server.TrustedProxies,
+		Logger:         appLogger,
+	}
+	router, err := myhttp.NewRouter(routerDeps)
+	if err != nil {
+		appLogger.Fatal("failed to configure router", "error", err)
 	}
-	router := myhttp.NewRouter(routerDeps)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%s", cfg.Server.Port),
@@ -87,18 +342,231 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	appLogger.Info("draining before shutdown", "delay", drainDelay)
+	healthRegistry.SetDraining()
+	time.Sleep(drainDelay)
+
 	appLogger.Info("shutting down server...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
 		appLogger.Error("server shutdown failed", "error", err)
 	}
+	if err := tracer.Shutdown(ctx); err != nil {
+		appLogger.Error("tracer shutdown failed", "error", err)
+	}
 	appLogger.Info("server stopped")
 }
 
+func newDepositPolicy(cfg *configs.Config) (*orderservice.DepositPolicy, error) {
+	bands := make([]orderservice.DepositBand, 0, len(cfg.Orders.DepositBands))
+	for _, band := range cfg.Orders.DepositBands {
+		maxPrice := money.Zero()
+		if band.MaxPrice != "" {
+			var err error
+			if maxPrice, err = money.Parse(band.MaxPrice, money.DefaultCurrency()); err != nil {
+				return nil, fmt.Errorf("invalid deposit band price %q: %w", band.MaxPrice, err)
+			}
+		}
+		bands = append(bands, orderservice.DepositBand{MaxPrice: maxPrice, MinPercent: band.MinPercent})
+	}
+	return orderservice.NewDepositPolicy(bands)
+}
+
+func newTokenMaker(cfg *configs.Config) (token.Maker, error) {
+	switch cfg.JWT.Maker {
+	case "paseto":
+		return token.NewPasetoMaker(cfg.JWT.KeyID, cfg.JWT.PasetoKeys)
+	case "jwt":
+		return newJWTMaker(cfg)
+	default:
+		return nil, fmt.Errorf("unknown token maker %q", cfg.JWT.Maker)
+	}
+}
+
+func newJWTMaker(cfg *configs.Config) (token.Maker, error) {
+	if cfg.JWT.Algorithm == token.AlgorithmHS256 {
+		previous := make([]token.JWTKey, 0, len(cfg.JWT.VerificationKeys))
+		for id, secret := range cfg.JWT.VerificationKeys {
+			previous = append(previous, token.NewHMACKey(id, []byte(secret)))
+		}
+		return token.NewJWTMaker(token.NewHMACKey(cfg.JWT.KeyID, []byte(cfg.JWT.Secret)), previous...)
+	}
+
+	current, err := readJWTKey(cfg.JWT.KeyID, cfg.JWT.Algorithm, cfg.JWT.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	previous := make([]token.JWTKey, 0, len(cfg.JWT.VerificationKeys))
+	for id, path := range cfg.JWT.VerificationKeys {
+		key, err := readJWTKey(id, cfg.JWT.Algorithm, path)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+	return token.NewJWTMaker(current, previous...)
+}
+
+func readJWTKey(id, algorithm, path string) (token.JWTKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return token.JWTKey{}, fmt.Errorf("failed to read key %q: %w", id, err)
+	}
+	return token.ParseJWTKey(id, algorithm, data)
+}
+
+func newLoginPolicy(cfg configs.LoginPolicy) (loginguardservice.Policy, error) {
+	policy := loginguardservice.Policy{FreeAttempts: cfg.FreeAttempts, MaxFailures: cfg.MaxFailures}
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"base_delay", cfg.BaseDelay, &policy.BaseDelay},
+		{"max_delay", cfg.MaxDelay, &policy.MaxDelay},
+		{"window", cfg.Window, &policy.Window},
+		{"lockout", cfg.Lockout, &policy.Lockout},
+		{"max_lockout", cfg.MaxLockout, &policy.MaxLockout},
+	} {
+		var err error
+		if *d.dst, err = time.ParseDuration(d.value); err != nil {
+			return policy, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+	}
+	return policy, nil
+}
+
+func newEmailSender(cfg *configs.Config, logger logger.Interface) (email.Sender, error) {
+	switch cfg.Email.Provider {
+	case "smtp":
+		timeout, err := time.ParseDuration(cfg.Email.SMTP.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid smtp timeout: %w", err)
+		}
+		return email.NewSMTPSender(email.SMTPConfig{
+			Host:     cfg.Email.SMTP.Host,
+			Port:     cfg.Email.SMTP.Port,
+			Username: cfg.Email.SMTP.Username,
+			Password: cfg.Email.SMTP.Password,
+			From:     cfg.Email.From,
+			Timeout:  timeout,
+		})
+	case "dev":
+		return email.NewDevSender(cfg.Email.DevDir, cfg.Email.From, logger)
+	default:
+		return nil, fmt.Errorf("%w: %s", email.ErrUnknownProvider, cfg.Email.Provider)
+	}
+}
+
+func newBlobStore(cfg *configs.Config) (storage.BlobStore, error) {
+	switch cfg.Media.Storage {
+	case "local":
+		store, err := storage.NewLocalStore(cfg.Media.Local.Dir, cfg.Media.Local.BaseURL, cfg.Media.Local.Secret)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "s3":
+		timeout, err := time.ParseDuration(cfg.Media.S3.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 timeout: %w", err)
+		}
+		store, err := storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.Media.S3.Endpoint,
+			Region:    cfg.Media.S3.Region,
+			Bucket:    cfg.Media.S3.Bucket,
+			AccessKey: cfg.Media.S3.AccessKey,
+			SecretKey: cfg.Media.S3.SecretKey,
+			PathStyle: cfg.Media.S3.PathStyle,
+			Timeout:   timeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", storage.ErrUnknownProvider, cfg.Media.Storage)
+	}
+}
+
+// thumbnailName keeps thumbnail size names usable inside blob keys.
+var thumbnailName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func newMediaOptions(cfg *configs.Config) (mediaservice.Options, error) {
+	urlTTL, err := time.ParseDuration(cfg.Media.URLTTL)
+	if err != nil || urlTTL <= 0 {
+		return mediaservice.Options{}, fmt.Errorf("invalid media url_ttl %q", cfg.Media.URLTTL)
+	}
+	if cfg.Media.MaxPhotoSize <= 0 || cfg.Media.MaxDocumentSize <= 0 || cfg.Media.MaxPixels <= 0 || cfg.Media.MaxPerCar <= 0 {
+		return mediaservice.Options{}, fmt.Errorf("media size and count limits must be positive")
+	}
+	for name, size := range cfg.Media.ThumbnailSizes {
+		if !thumbnailName.MatchString(name) || size <= 0 {
+			return mediaservice.Options{}, fmt.Errorf("invalid thumbnail size %s: %d", name, size)
+		}
+	}
+	return mediaservice.Options{
+		MaxPhotoSize:    cfg.Media.MaxPhotoSize,
+		MaxDocumentSize: cfg.Media.MaxDocumentSize,
+		MaxPixels:       cfg.Media.MaxPixels,
+		MaxPerCar:       cfg.Media.MaxPerCar,
+		ThumbnailSizes:  cfg.Media.ThumbnailSizes,
+		URLTTL:          urlTTL,
+	}, nil
+}
+
+func newPaymentGateway(cfg *configs.Config) (gateway.PaymentGateway, error) {
+	switch cfg.Payments.Provider {
+	case "mock":
+		latency, err := time.ParseDuration(cfg.Payments.Mock.Latency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mock latency: %w", err)
+		}
+		return gateway.NewMock(cfg.Payments.Mock.Mode, latency)
+	default:
+		return nil, fmt.Errorf("%w: %s", gateway.ErrUnknownProvider, cfg.Payments.Provider)
+	}
+}
+
 func initConfig() error {
 	viper.AddConfigPath("configs")
 	viper.SetConfigName("config")
 	return viper.ReadInConfig()
 }
+
+func newTracer(cfg *configs.Config) (*tracing.Tracer, error) {
+	var exporter tracing.Exporter
+	switch cfg.Tracing.Exporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		exporter = tracing.NewStdoutExporter(os.Stdout, cfg.Tracing.ServiceName)
+	case "otlp":
+		timeout, err := time.ParseDuration(cfg.Tracing.OTLP.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid otlp timeout: %w", err)
+		}
+		exporter = tracing.NewOTLPExporter(tracing.OTLPConfig{
+			Endpoint:    cfg.Tracing.OTLP.Endpoint,
+			Headers:     cfg.Tracing.OTLP.Headers,
+			Timeout:     timeout,
+			ServiceName: cfg.Tracing.ServiceName,
+		})
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Tracing.Exporter)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing sample ratio %v is outside [0, 1]", cfg.Tracing.SampleRatio)
+	}
+	batchTimeout, err := time.ParseDuration(cfg.Tracing.BatchTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracing batch timeout: %w", err)
+	}
+	return tracing.NewTracer(exporter, tracing.Options{
+		SampleRatio:  cfg.Tracing.SampleRatio,
+		BatchTimeout: batchTimeout,
+	}), nil
+}
@@ -8,8 +8,17 @@ import (
 
 type Config struct {
 	Server struct {
-		Port string `mapstructure:"port"`
+		Port       string `mapstructure:"port"`
+		DrainDelay string `mapstructure:"drain_delay"`
+		// TrustedProxies lists the proxy IPs or CIDRs whose X-Forwarded-For
+		// is believed; empty means client IPs come from the connection.
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
+	Health struct {
+		Timeout      string `mapstructure:"timeout"`
+		CacheFor     string `mapstructure:"cache_for"`
+		MinMigration int64  `mapstructure:"min_migration"`
+	} `mapstructure:"health"`
 	Database struct {
 		Host     string `mapstructure:"host"`
 		Port     string `mapstructure:"port"`
@@ -19,15 +28,134 @@ type Config struct {
 		SSLMode  string `mapstructure:"sslmode"`
 	} `mapstructure:"database"`
 	JWT struct {
-		Secret string `mapstructure:"secret"`
-		TTL    string `mapstructure:"ttl"`
+		Secret               string            `mapstructure:"secret"`
+		TTL                  string            `mapstructure:"ttl"`
+		RefreshTTL           string            `mapstructure:"refresh_ttl"`
+		SessionSweepInterval string            `mapstructure:"session_sweep_interval"`
+		Maker                string            `mapstructure:"maker"`
+		Algorithm            string            `mapstructure:"algorithm"`
+		KeyID                string            `mapstructure:"key_id"`
+		PrivateKeyFile       string            `mapstructure:"private_key_file"`
+		VerificationKeys     map[string]string `mapstructure:"verification_keys"`
+		PasetoKeys           map[string]string `mapstructure:"paseto_keys"`
 	} `mapstructure:"jwt"`
+	Reservation struct {
+		HoldDuration  string `mapstructure:"hold_duration"`
+		SweepInterval string `mapstructure:"sweep_interval"`
+	} `mapstructure:"reservation"`
+	Showroom struct {
+		Timezone          string   `mapstructure:"timezone"`
+		OpensAt           string   `mapstructure:"opens_at"`
+		ClosesAt          string   `mapstructure:"closes_at"`
+		WorkingDays       []string `mapstructure:"working_days"`
+		TestDriveDuration string   `mapstructure:"test_drive_duration"`
+	} `mapstructure:"showroom"`
+	Payments struct {
+		Provider          string            `mapstructure:"provider"`
+		ChargeTimeout     string            `mapstructure:"charge_timeout"`
+		ReconcileInterval string            `mapstructure:"reconcile_interval"`
+		WebhookSecrets    map[string]string `mapstructure:"webhook_secrets"`
+		Mock              struct {
+			Mode    string `mapstructure:"mode"`
+			Latency string `mapstructure:"latency"`
+		} `mapstructure:"mock"`
+	} `mapstructure:"payments"`
+	Orders struct {
+		DepositBands []DepositBand `mapstructure:"deposit_bands"`
+	} `mapstructure:"orders"`
+	Login struct {
+		Account       LoginPolicy `mapstructure:"account"`
+		IP            LoginPolicy `mapstructure:"ip"`
+		SweepInterval string      `mapstructure:"sweep_interval"`
+	} `mapstructure:"login"`
+	MFA struct {
+		Issuer        string   `mapstructure:"issuer"`
+		RequiredRoles []string `mapstructure:"required_roles"`
+		ChallengeTTL  string   `mapstructure:"challenge_ttl"`
+		MaxAttempts   int      `mapstructure:"max_attempts"`
+		SweepInterval string   `mapstructure:"sweep_interval"`
+	} `mapstructure:"mfa"`
+	Email struct {
+		Provider        string `mapstructure:"provider"`
+		From            string `mapstructure:"from"`
+		AppURL          string `mapstructure:"app_url"`
+		VerificationTTL string `mapstructure:"verification_ttl"`
+		ResetTTL        string `mapstructure:"reset_ttl"`
+		DevDir          string `mapstructure:"dev_dir"`
+		SMTP            struct {
+			Host     string `mapstructure:"host"`
+			Port     string `mapstructure:"port"`
+			Username string `mapstructure:"username"`
+			Password string `mapstructure:"password"`
+			Timeout  string `mapstructure:"timeout"`
+		} `mapstructure:"smtp"`
+	} `mapstructure:"email"`
+	Idempotency struct {
+		TTL           string `mapstructure:"ttl"`
+		SweepInterval string `mapstructure:"sweep_interval"`
+	} `mapstructure:"idempotency"`
+	Media struct {
+		Storage         string         `mapstructure:"storage"`
+		URLTTL          string         `mapstructure:"url_ttl"`
+		SweepInterval   string         `mapstructure:"sweep_interval"`
+		MaxPhotoSize    int64          `mapstructure:"max_photo_size"`
+		MaxDocumentSize int64          `mapstructure:"max_document_size"`
+		MaxPixels       int            `mapstructure:"max_pixels"`
+		MaxPerCar       int            `mapstructure:"max_per_car"`
+		ThumbnailSizes  map[string]int `mapstructure:"thumbnail_sizes"`
+		Local           struct {
+			Dir     string `mapstructure:"dir"`
+			BaseURL string `mapstructure:"base_url"`
+			Secret  string `mapstructure:"secret"`
+		} `mapstructure:"local"`
+		S3 struct {
+			Endpoint  string `mapstructure:"endpoint"`
+			Region    string `mapstructure:"region"`
+			Bucket    string `mapstructure:"bucket"`
+			AccessKey string `mapstructure:"access_key"`
+			SecretKey string `mapstructure:"secret_key"`
+			PathStyle bool   `mapstructure:"path_style"`
+			Timeout   string `mapstructure:"timeout"`
+		} `mapstructure:"s3"`
+	} `mapstructure:"media"`
+	Tracing struct {
+		Exporter     string  `mapstructure:"exporter"`
+		ServiceName  string  `mapstructure:"service_name"`
+		SampleRatio  float64 `mapstructure:"sample_ratio"`
+		BatchTimeout string  `mapstructure:"batch_timeout"`
+		OTLP         struct {
+			Endpoint string            `mapstructure:"endpoint"`
+			Headers  map[string]string `mapstructure:"headers"`
+			Timeout  string            `mapstructure:"timeout"`
+		} `mapstructure:"otlp"`
+	} `mapstructure:"tracing"`
 	App struct {
 		Environment string `mapstructure:"environment"`
 		LogLevel    string `mapstructure:"log_level"`
+		LogFormat   string `mapstructure:"log_format"`
+		Currency    string `mapstructure:"currency"`
 	} `mapstructure:"app"`
 }
 
+// DepositBand sets the minimum deposit percentage for cars priced up to
+// MaxPrice; an empty MaxPrice covers every price above the other bands.
+type DepositBand struct {
+	MaxPrice   string `mapstructure:"max_price"`
+	MinPercent int64  `mapstructure:"min_percent"`
+}
+
+// LoginPolicy throttles failed sign-ins for one scope, an account or a client
+// IP. Durations use time.ParseDuration syntax.
+type LoginPolicy struct {
+	FreeAttempts int    `mapstructure:"free_attempts"`
+	BaseDelay    string `mapstructure:"base_delay"`
+	MaxDelay     string `mapstructure:"max_delay"`
+	MaxFailures  int    `mapstructure:"max_failures"`
+	Window       string `mapstructure:"window"`
+	Lockout      string `mapstructure:"lockout"`
+	MaxLockout   string `mapstructure:"max_lockout"`
+}
+
 func LoadConfig() *Config {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -37,11 +165,86 @@ func LoadConfig() *Config {
 	viper.SetDefault("database.sslmode", "disable")
 	viper.SetDefault("app.environment", "development")
 	viper.SetDefault("app.log_level", "debug")
+	viper.SetDefault("app.log_format", "json")
+	viper.SetDefault("app.currency", "USD")
+	viper.SetDefault("jwt.ttl", "15m")
+	viper.SetDefault("jwt.refresh_ttl", "720h")
+	viper.SetDefault("jwt.session_sweep_interval", "1h")
+	viper.SetDefault("jwt.maker", "jwt")
+	viper.SetDefault("jwt.algorithm", "HS256")
+	viper.SetDefault("reservation.hold_duration", "48h")
+	viper.SetDefault("reservation.sweep_interval", "1m")
+	viper.SetDefault("showroom.timezone", "UTC")
+	viper.SetDefault("showroom.opens_at", "09:00")
+	viper.SetDefault("showroom.closes_at", "20:00")
+	viper.SetDefault("showroom.test_drive_duration", "30m")
+	viper.SetDefault("payments.provider", "mock")
+	viper.SetDefault("payments.charge_timeout", "10s")
+	viper.SetDefault("payments.reconcile_interval", "1m")
+	viper.SetDefault("payments.mock.mode", "succeed")
+	viper.SetDefault("payments.mock.latency", "0s")
+	viper.SetDefault("login.account.free_attempts", 3)
+	viper.SetDefault("login.account.base_delay", "1s")
+	viper.SetDefault("login.account.max_delay", "30s")
+	viper.SetDefault("login.account.max_failures", 10)
+	viper.SetDefault("login.account.window", "15m")
+	viper.SetDefault("login.account.lockout", "15m")
+	viper.SetDefault("login.account.max_lockout", "24h")
+	viper.SetDefault("login.ip.free_attempts", 20)
+	viper.SetDefault("login.ip.base_delay", "1s")
+	viper.SetDefault("login.ip.max_delay", "10s")
+	viper.SetDefault("login.ip.max_failures", 100)
+	viper.SetDefault("login.ip.window", "15m")
+	viper.SetDefault("login.ip.lockout", "15m")
+	viper.SetDefault("login.ip.max_lockout", "6h")
+	viper.SetDefault("login.sweep_interval", "1h")
+	viper.SetDefault("mfa.issuer", "Car Dealership")
+	viper.SetDefault("mfa.required_roles", []string{"admin", "manager"})
+	viper.SetDefault("mfa.challenge_ttl", "5m")
+	viper.SetDefault("mfa.max_attempts", 5)
+	viper.SetDefault("mfa.sweep_interval", "1h")
+	viper.SetDefault("email.provider", "dev")
+	viper.SetDefault("email.from", "Car Dealership <no-reply@localhost>")
+	viper.SetDefault("email.app_url", "http://localhost:3000")
+	viper.SetDefault("email.verification_ttl", "48h")
+	viper.SetDefault("email.reset_ttl", "1h")
+	viper.SetDefault("email.smtp.port", "587")
+	viper.SetDefault("email.smtp.timeout", "10s")
+	viper.SetDefault("idempotency.ttl", "24h")
+	viper.SetDefault("idempotency.sweep_interval", "1h")
+	viper.SetDefault("server.drain_delay", "5s")
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("health.timeout", "2s")
+	viper.SetDefault("health.cache_for", "2s")
+	viper.SetDefault("health.min_migration", 0)
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.service_name", "car-dealership")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("tracing.batch_timeout", "5s")
+	viper.SetDefault("tracing.otlp.endpoint", "http://localhost:4318/v1/traces")
+	viper.SetDefault("tracing.otlp.timeout", "10s")
+	viper.SetDefault("media.storage", "local")
+	viper.SetDefault("media.url_ttl", "15m")
+	viper.SetDefault("media.sweep_interval", "10m")
+	viper.SetDefault("media.max_photo_size", 10<<20)
+	viper.SetDefault("media.max_document_size", 20<<20)
+	viper.SetDefault("media.max_pixels", 25_000_000)
+	viper.SetDefault("media.max_per_car", 30)
+	viper.SetDefault("media.thumbnail_sizes", map[string]int{"small": 160, "medium": 480, "large": 1280})
+	viper.SetDefault("media.local.dir", "data/media")
+	viper.SetDefault("media.local.base_url", "/media")
+	viper.SetDefault("media.s3.region", "us-east-1")
+	viper.SetDefault("media.s3.timeout", "30s")
 	viper.AutomaticEnv()
 	viper.BindEnv("database.host", "DB_HOST")
 	viper.BindEnv("database.user", "DB_USER")
 	viper.BindEnv("database.password", "DB_PASSWORD")
 	viper.BindEnv("jwt.secret", "JWT_SECRET")
+	viper.BindEnv("email.smtp.username", "SMTP_USERNAME")
+	viper.BindEnv("email.smtp.password", "SMTP_PASSWORD")
+	viper.BindEnv("media.local.secret", "MEDIA_SECRET")
+	viper.BindEnv("media.s3.access_key", "S3_ACCESS_KEY")
+	viper.BindEnv("media.s3.secret_key", "S3_SECRET_KEY")
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("Error reading config file: %s", err)
@@ -56,7 +259,7 @@ func LoadConfig() *Config {
 		log.Fatal("Database host, user and dbname are required configurations")
 	}
 
-	if cfg.JWT.Secret == "" {
+	if cfg.JWT.Maker == "jwt" && cfg.JWT.Algorithm == "HS256" && cfg.JWT.Secret == "" {
 		log.Fatal("JWT secret is required")
 	}
 
@@ -0,0 +1,102 @@
+package audithandler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"myproject/internal/entities"
+	auditcase "myproject/internal/usecases/audit"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	auditUC auditcase.UseCase
+	logger  logger.Interface
+}
+
+func NewHandler(auditUC auditcase.UseCase, logger logger.Interface) *Handler {
+	return &Handler{auditUC: auditUC, logger: logger}
+}
+
+// ListEvents returns audit entries newest first, filtered by ?entity_type=,
+// ?entity_id=, ?actor_id= and the ?from=/?to= range (RFC 3339 or
+// YYYY-MM-DD, to is exclusive). ?before_id= continues from next_before_id.
+func (h *Handler) ListEvents(c *gin.Context) {
+	filter := entities.AuditFilter{EntityType: c.Query("entity_type")}
+
+	for name, dst := range map[string]*int{"entity_id": &filter.EntityID, "actor_id": &filter.ActorID, "limit": &filter.Limit} {
+		v := c.Query(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return
+		}
+		*dst = n
+	}
+	if v := c.Query("before_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before_id"})
+			return
+		}
+		filter.BeforeID = id
+	}
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		v := c.Query(name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be an RFC 3339 time or YYYY-MM-DD"})
+			return
+		}
+		*dst = t
+	}
+
+	events, err := h.auditUC.List(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidAuditFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("ListEvents: failed to list audit events", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	response := gin.H{"events": events}
+	if len(events) > 0 {
+		response["next_before_id"] = events[len(events)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Verify walks the whole audit chain and reports the first tampered entry.
+func (h *Handler) Verify(c *gin.Context) {
+	result, err := h.auditUC.Verify(c.Request.Context())
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Verify: failed to verify audit chain", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+	if !result.Valid {
+		h.logger.WithContext(c.Request.Context()).Error("Verify: audit chain is broken", "broken_at", result.BrokenAt, "reason", result.Reason)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
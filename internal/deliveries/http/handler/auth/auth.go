@@ -0,0 +1,93 @@
+package authhandler
+
+import (
+	"errors"
+	"net/http"
+
+	"myproject/internal/deliveries/http/middleware"
+	"myproject/internal/entities"
+	authcase "myproject/internal/usecases/auth"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	authUC authcase.UseCase
+	logger logger.Interface
+}
+
+func NewHandler(authUC authcase.UseCase, logger logger.Interface) *Handler {
+	return &Handler{authUC: authUC, logger: logger}
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Refresh: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	tokens, err := h.authUC.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrRefreshTokenReused):
+			h.logger.WithContext(c.Request.Context()).Warn("Refresh: refresh token reuse detected, session revoked")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		case errors.Is(err, entities.ErrInvalidRefreshToken),
+			errors.Is(err, entities.ErrSessionRevoked),
+			errors.Is(err, entities.ErrSessionExpired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		default:
+			h.logger.WithContext(c.Request.Context()).Error("Refresh: failed to refresh session", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the session the access token belongs to.
+func (h *Handler) Logout(c *gin.Context) {
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.authUC.Logout(c.Request.Context(), caller.UserID, caller.SessionID); err != nil {
+		if errors.Is(err, entities.ErrSessionNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Logout: failed to revoke session", "user_id", caller.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// LogoutAll revokes every session of the caller, this one included.
+func (h *Handler) LogoutAll(c *gin.Context) {
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	revoked, err := h.authUC.LogoutAll(c.Request.Context(), caller.UserID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("LogoutAll: failed to revoke sessions", "user_id", caller.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out from all devices", "sessions_revoked": revoked})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"myproject/internal/deliveries/http/pagination"
 	"myproject/internal/entities"
 	"myproject/internal/usecases/car"
 	"myproject/pkg/logger"
@@ -30,7 +31,15 @@ func (h *Handler) CreateCar(c *gin.Context) {
 
 	createdCar, err := h.uc.CreateCar(c.Request.Context(), &input)
 	if err != nil {
-		h.logger.Error("create car failed", "error", err)
+		if errors.Is(err, entities.ErrInvalidCar) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, entities.ErrVINTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("create car failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -51,7 +60,7 @@ func (h *Handler) GetCar(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "car not found"})
 			return
 		}
-		h.logger.Error("get car failed", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("get car failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -74,7 +83,19 @@ func (h *Handler) UpdateCar(c *gin.Context) {
 
 	updatedCar, err := h.uc.UpdateCar(c.Request.Context(), id, input)
 	if err != nil {
-		h.logger.Error("update car failed", "error", err)
+		if errors.Is(err, entities.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "car not found"})
+			return
+		}
+		if errors.Is(err, entities.ErrInvalidCar) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, entities.ErrVINTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("update car failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -91,7 +112,11 @@ func (h *Handler) DeleteCar(c *gin.Context) {
 
 	err = h.uc.DeleteCar(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Error("delete car failed", "error", err)
+		if errors.Is(err, entities.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "car not found"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("delete car failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -106,14 +131,82 @@ func (h *Handler) ListCars(c *gin.Context) {
 		return
 	}
 
-	cars, total, err := h.uc.ListCars(c.Request.Context(), filter)
+	page, err := pagination.ParseRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Page = page
+
+	cars, info, err := h.uc.ListCars(c.Request.Context(), filter)
+	if pagination.IsInvalid(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		h.logger.Error("list cars failed", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("list cars failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"items": cars, "total": total})
+	pagination.SetLink(c, info)
+	c.JSON(http.StatusOK, gin.H{"items": cars, "total": info.Total, "next_cursor": info.NextCursor})
+}
+
+// Facets takes the same filters as ListCars and returns the counts behind
+// the catalog sidebar.
+func (h *Handler) Facets(c *gin.Context) {
+	var filter entities.CarFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	facets, err := h.uc.CarFacets(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("car facets failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, facets)
+}
+
+func (h *Handler) Suggest(c *gin.Context) {
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	suggestions, err := h.uc.SuggestCars(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("suggest cars failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
+
+// DecodeVIN reports what a VIN says about the car, e.g. to prefill the
+// brand and year when a car is added.
+func (h *Handler) DecodeVIN(c *gin.Context) {
+	info, err := h.uc.DecodeVIN(c.Request.Context(), c.Param("vin"))
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidVIN) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("decode vin failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
 }
 
 func (h *Handler) ChangeCarStatus(c *gin.Context) {
@@ -133,7 +226,11 @@ func (h *Handler) ChangeCarStatus(c *gin.Context) {
 
 	car, err := h.uc.ChangeCarStatus(c.Request.Context(), id, input.Status)
 	if err != nil {
-		h.logger.Error("change car status failed", "error", err)
+		if errors.Is(err, entities.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "car not found"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("change car status failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"myproject/internal/pkg/buildinfo"
+	"myproject/internal/pkg/health"
 	"myproject/pkg/logger"
 	"net/http"
 
@@ -8,17 +10,38 @@ import (
 )
 
 type CommonHandler struct {
+	health *health.Registry
 	logger logger.Interface
 }
 
-func NewCommonHandler(logger logger.Interface) *CommonHandler {
-	return &CommonHandler{logger: logger}
+func NewCommonHandler(health *health.Registry, logger logger.Interface) *CommonHandler {
+	return &CommonHandler{health: health, logger: logger}
 }
 
-func (h *CommonHandler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "available",
-		"version": "1.0.0",
+// Livez reports whether the process should be restarted. It ignores
+// dependencies like the database, which a restart would not fix.
+func (h *CommonHandler) Livez(c *gin.Context) {
+	h.writeReport(c, h.health.Liveness(c.Request.Context()))
+}
+
+// Readyz reports whether the instance should receive traffic. It fails while
+// a dependency is down and once shutdown has started.
+func (h *CommonHandler) Readyz(c *gin.Context) {
+	h.writeReport(c, h.health.Readiness(c.Request.Context()))
+}
+
+func (h *CommonHandler) writeReport(c *gin.Context, report health.Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	if report.Status == health.StatusUnavailable {
+		h.logger.WithContext(c.Request.Context()).Warn("health probe failed", "path", c.FullPath(), "status", report.Status, "checks", report.Checks)
+	}
+	c.JSON(status, gin.H{
+		"status": report.Status,
+		"checks": report.Checks,
+		"build":  buildinfo.Get(),
 	})
 }
 
@@ -0,0 +1,86 @@
+package ledgerhandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"myproject/internal/deliveries/http/middleware"
+	ledgercase "myproject/internal/usecases/ledger"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultStatementPeriod = 30 * 24 * time.Hour
+
+type Handler struct {
+	ledgerUC ledgercase.UseCase
+	logger   logger.Interface
+}
+
+func NewHandler(ledgerUC ledgercase.UseCase, logger logger.Interface) *Handler {
+	return &Handler{ledgerUC: ledgerUC, logger: logger}
+}
+
+// GetStatement returns wallet movements for ?from=YYYY-MM-DD&to=YYYY-MM-DD.
+// Both days are inclusive and interpreted in UTC; the default is the last 30 days.
+func (h *Handler) GetStatement(c *gin.Context) {
+	userIDStr := c.Param("user_id")
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil || userID <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error("GetStatement: invalid user_id", "user_id", userIDStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return
+	}
+
+	if !middleware.CanAccessUser(c, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	if v := c.Query("to"); v != "" {
+		day, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in YYYY-MM-DD format"})
+			return
+		}
+		to = day.Add(24 * time.Hour)
+	}
+
+	from := to.Add(-defaultStatementPeriod)
+	if v := c.Query("from"); v != "" {
+		day, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in YYYY-MM-DD format"})
+			return
+		}
+		from = day
+	}
+
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	statement, err := h.ledgerUC.GetStatement(c.Request.Context(), userID, from, to)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("GetStatement: failed to build statement", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+func (h *Handler) Reconcile(c *gin.Context) {
+	drift, err := h.ledgerUC.Reconcile(c.Request.Context())
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Reconcile: failed to reconcile balances", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drift": drift, "consistent": len(drift) == 0})
+}
@@ -0,0 +1,181 @@
+package mediahandler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"myproject/internal/entities"
+	mediacase "myproject/internal/usecases/media"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRequestSize caps the whole multipart body. The service enforces the
+// real per-type limits; this only stops a client from streaming endlessly.
+const maxRequestSize = 64 << 20
+
+type Handler struct {
+	mediaUC mediacase.UseCase
+	logger  logger.Interface
+}
+
+func NewHandler(mediaUC mediacase.UseCase, logger logger.Interface) *Handler {
+	return &Handler{mediaUC: mediaUC, logger: logger}
+}
+
+// Upload takes a multipart form with the file in "file" and an optional
+// "primary" flag.
+func (h *Handler) Upload(c *gin.Context) {
+	carID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestSize)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": entities.ErrMediaTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a multipart file field named \"file\" is required"})
+		return
+	}
+	primary := false
+	if v := c.PostForm("primary"); v != "" {
+		if primary, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "primary must be true or false"})
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.respondError(c, "Upload", err)
+		return
+	}
+	defer file.Close()
+
+	media, err := h.mediaUC.UploadMedia(c.Request.Context(), carID, entities.MediaUpload{
+		Filename: header.Filename,
+		Body:     file,
+		Primary:  primary,
+	})
+	if err != nil {
+		h.respondError(c, "Upload", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, media)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	carID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	media, err := h.mediaUC.ListMedia(c.Request.Context(), carID)
+	if err != nil {
+		h.respondError(c, "List", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"media": media})
+}
+
+func (h *Handler) SetPrimary(c *gin.Context) {
+	carID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	mediaID, ok := parseID(c, "media_id")
+	if !ok {
+		return
+	}
+
+	media, err := h.mediaUC.SetPrimaryMedia(c.Request.Context(), carID, mediaID)
+	if err != nil {
+		h.respondError(c, "SetPrimary", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+type ReorderRequest struct {
+	IDs []int `json:"ids" binding:"required"`
+}
+
+func (h *Handler) Reorder(c *gin.Context) {
+	carID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	media, err := h.mediaUC.ReorderMedia(c.Request.Context(), carID, req.IDs)
+	if err != nil {
+		h.respondError(c, "Reorder", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"media": media})
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	carID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	mediaID, ok := parseID(c, "media_id")
+	if !ok {
+		return
+	}
+
+	if err := h.mediaUC.DeleteMedia(c.Request.Context(), carID, mediaID); err != nil {
+		h.respondError(c, "Delete", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func parseID(c *gin.Context, param string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) respondError(c *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "car not found"})
+	case errors.Is(err, entities.ErrMediaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+	case errors.Is(err, entities.ErrMediaTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrMediaUnsupported):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrMediaLimit):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrMediaNotPhoto),
+		errors.Is(err, entities.ErrInvalidMediaOrder),
+		errors.Is(err, entities.ErrInvalidID):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error(op+": request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,250 @@
+package mfahandler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"myproject/internal/deliveries/http/middleware"
+	"myproject/internal/entities"
+	mfacase "myproject/internal/usecases/mfa"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	mfaUC  mfacase.UseCase
+	logger logger.Interface
+}
+
+func NewHandler(mfaUC mfacase.UseCase, logger logger.Interface) *Handler {
+	return &Handler{mfaUC: mfaUC, logger: logger}
+}
+
+type ChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+}
+
+type CodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// VerifyChallenge is the second step of signing in for users with an
+// authenticator; code is a TOTP or a recovery code.
+func (h *Handler) VerifyChallenge(c *gin.Context) {
+	var req ChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		h.logger.WithContext(c.Request.Context()).Error("VerifyChallenge: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	signIn, err := h.mfaUC.VerifyChallenge(c.Request.Context(), req.ChallengeToken, req.Code, sessionMeta(c))
+	if err != nil {
+		h.writeError(c, "VerifyChallenge", err)
+		return
+	}
+	c.JSON(http.StatusOK, signInResponse(signIn))
+}
+
+// BeginChallengeEnrollment lets a user whose role requires two-factor
+// authentication set up an authenticator while signing in.
+func (h *Handler) BeginChallengeEnrollment(c *gin.Context) {
+	var req ChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("BeginChallengeEnrollment: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	enrollment, err := h.mfaUC.BeginChallengeEnrollment(c.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		h.writeError(c, "BeginChallengeEnrollment", err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *Handler) ConfirmChallengeEnrollment(c *gin.Context) {
+	var req ChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		h.logger.WithContext(c.Request.Context()).Error("ConfirmChallengeEnrollment: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	signIn, err := h.mfaUC.ConfirmChallengeEnrollment(c.Request.Context(), req.ChallengeToken, req.Code, sessionMeta(c))
+	if err != nil {
+		h.writeError(c, "ConfirmChallengeEnrollment", err)
+		return
+	}
+	c.JSON(http.StatusOK, signInResponse(signIn))
+}
+
+func (h *Handler) GetStatus(c *gin.Context) {
+	userID, ok := h.selfID(c, "GetStatus")
+	if !ok {
+		return
+	}
+
+	status, err := h.mfaUC.Status(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, "GetStatus", err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *Handler) BeginEnrollment(c *gin.Context) {
+	userID, ok := h.selfID(c, "BeginEnrollment")
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.mfaUC.BeginEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, "BeginEnrollment", err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *Handler) ConfirmEnrollment(c *gin.Context) {
+	userID, ok := h.selfID(c, "ConfirmEnrollment")
+	if !ok {
+		return
+	}
+	var req CodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ConfirmEnrollment: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	codes, err := h.mfaUC.ConfirmEnrollment(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.writeError(c, "ConfirmEnrollment", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication enabled", "recovery_codes": codes})
+}
+
+func (h *Handler) Disable(c *gin.Context) {
+	userID, ok := h.selfID(c, "Disable")
+	if !ok {
+		return
+	}
+	var req CodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Disable: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	if err := h.mfaUC.Disable(c.Request.Context(), userID, req.Code, sessionMeta(c)); err != nil {
+		h.writeError(c, "Disable", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := h.selfID(c, "RegenerateRecoveryCodes")
+	if !ok {
+		return
+	}
+	var req CodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("RegenerateRecoveryCodes: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	codes, err := h.mfaUC.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code, sessionMeta(c))
+	if err != nil {
+		h.writeError(c, "RegenerateRecoveryCodes", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Reset removes another user's authenticator; admins only.
+func (h *Handler) Reset(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error("Reset: invalid id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.mfaUC.Reset(c.Request.Context(), id); err != nil {
+		h.writeError(c, "Reset", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication reset"})
+}
+
+// selfID returns the :id path parameter when it is the caller's own ID;
+// authenticators are managed only by their owner.
+func (h *Handler) selfID(c *gin.Context, op string) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error(op+": invalid id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return 0, false
+	}
+	if caller, ok := middleware.CurrentIdentity(c); !ok || caller.UserID != id {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) writeError(c *gin.Context, op string, err error) {
+	var throttled *entities.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed sign-in attempts, try again later"})
+	case errors.Is(err, entities.ErrInvalidMFAChallenge), errors.Is(err, entities.ErrInvalidMFACode):
+		h.logger.WithContext(c.Request.Context()).Warn(op+": rejected", "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrMFAChallengeMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrMFANotEnrolled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrMFAAlreadyEnrolled), errors.Is(err, entities.ErrMFARequired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error(op+": failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func sessionMeta(c *gin.Context) entities.SessionMeta {
+	return entities.SessionMeta{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+}
+
+// signInResponse matches the body of a password sign-in without a second
+// step.
+func signInResponse(signIn *entities.SignIn) gin.H {
+	tokens := signIn.Tokens
+	body := gin.H{
+		"token":              tokens.AccessToken,
+		"session_id":         tokens.SessionID,
+		"access_token":       tokens.AccessToken,
+		"access_expires_at":  tokens.AccessExpiresAt,
+		"refresh_token":      tokens.RefreshToken,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+		"user":               signIn.User,
+	}
+	if signIn.RecoveryCodes != nil {
+		body["recovery_codes"] = signIn.RecoveryCodes
+	}
+	return body
+}
@@ -1,10 +1,14 @@
 package orderhandler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"myproject/internal/deliveries/http/middleware"
+	"myproject/internal/deliveries/http/pagination"
 	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
 	ordercase "myproject/internal/usecases/order"
 	"myproject/pkg/logger"
 
@@ -20,31 +24,51 @@ func NewHandler(orderUC ordercase.UseCase, logger logger.Interface) *Handler {
 	return &Handler{orderUC: orderUC, logger: logger}
 }
 
+// CreateOrderRequest takes no price: orders are placed at the car's current price.
 type CreateOrderRequest struct {
-	UserID     int     `json:"user_id" binding:"required,gt=0"`
-	CarID      int     `json:"car_id" binding:"required,gt=0"`
-	Deposit    float64 `json:"deposit" binding:"gte=0"`
-	TotalPrice float64 `json:"total_price" binding:"required,gt=0"`
+	CarID   int         `json:"car_id" binding:"required,gt=0"`
+	Deposit money.Money `json:"deposit" binding:"gte=0"`
 }
 
 func (h *Handler) CreateOrder(c *gin.Context) {
 	var req CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("CreateOrder: invalid input", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("CreateOrder: invalid input", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 		return
 	}
 
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
 	order := &entities.Order{
-		UserID:     req.UserID,
-		CarID:      req.CarID,
-		Deposit:    req.Deposit,
-		TotalPrice: req.TotalPrice,
+		UserID:  caller.UserID,
+		CarID:   req.CarID,
+		Deposit: req.Deposit,
 	}
 
 	orderID, err := h.orderUC.CreateOrder(c.Request.Context(), order)
 	if err != nil {
-		h.logger.Error("CreateOrder: failed to create order", "order", order, "error", err)
+		if errors.Is(err, entities.ErrCarNotAvailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": "car is not available"})
+			return
+		}
+		if errors.Is(err, entities.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "confirm your email address before placing an order"})
+			return
+		}
+		if errors.Is(err, entities.ErrInsufficientFunds) {
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "insufficient funds"})
+			return
+		}
+		if errors.Is(err, entities.ErrDepositTooLow) || errors.Is(err, entities.ErrOverpayment) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("CreateOrder: failed to create order", "order", order, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -56,15 +80,13 @@ func (h *Handler) GetOrder(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		h.logger.Error("GetOrder: invalid id", "id", idStr, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("GetOrder: invalid id", "id", idStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
-	order, err := h.orderUC.GetOrder(c.Request.Context(), id)
-	if err != nil {
-		h.logger.Error("GetOrder: failed to get order", "id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	order, ok := h.loadOwnedOrder(c, "GetOrder", id)
+	if !ok {
 		return
 	}
 
@@ -75,14 +97,19 @@ func (h *Handler) GetOrdersByUserID(c *gin.Context) {
 	userIDStr := c.Param("user_id")
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil || userID <= 0 {
-		h.logger.Error("GetOrdersByUserID: invalid user_id", "user_id", userIDStr, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("GetOrdersByUserID: invalid user_id", "user_id", userIDStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
 		return
 	}
 
+	if !middleware.CanAccessUser(c, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
 	orders, err := h.orderUC.GetOrdersByUserID(c.Request.Context(), userID)
 	if err != nil {
-		h.logger.Error("GetOrdersByUserID: failed to get orders", "user_id", userID, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("GetOrdersByUserID: failed to get orders", "user_id", userID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -92,27 +119,31 @@ func (h *Handler) GetOrdersByUserID(c *gin.Context) {
 
 type UpdateOrderStatusRequest struct {
 	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason" binding:"max=500"`
 }
 
 func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		h.logger.Error("UpdateOrderStatus: invalid id", "id", idStr, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("UpdateOrderStatus: invalid id", "id", idStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
 	var req UpdateOrderStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("UpdateOrderStatus: invalid input", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("UpdateOrderStatus: invalid input", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 		return
 	}
 
-	err = h.orderUC.UpdateOrderStatus(c.Request.Context(), id, req.Status)
+	err = h.orderUC.UpdateOrderStatus(c.Request.Context(), id, req.Status, req.Reason)
 	if err != nil {
-		h.logger.Error("UpdateOrderStatus: failed to update status", "id", id, "status", req.Status, "error", err)
+		if h.writeTransitionError(c, err) {
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("UpdateOrderStatus: failed to update status", "id", id, "status", req.Status, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -124,14 +155,21 @@ func (h *Handler) CancelOrder(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		h.logger.Error("CancelOrder: invalid id", "id", idStr, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("CancelOrder: invalid id", "id", idStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
-	err = h.orderUC.CancelOrder(c.Request.Context(), id)
+	if _, ok := h.loadOwnedOrder(c, "CancelOrder", id); !ok {
+		return
+	}
+
+	err = h.orderUC.CancelOrder(c.Request.Context(), id, c.Query("reason"))
 	if err != nil {
-		h.logger.Error("CancelOrder: failed to cancel order", "id", id, "error", err)
+		if h.writeTransitionError(c, err) {
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("CancelOrder: failed to cancel order", "id", id, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -139,13 +177,131 @@ func (h *Handler) CancelOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "order cancelled successfully"})
 }
 
+type AddPaymentRequest struct {
+	Amount money.Money `json:"amount" binding:"required,gt=0"`
+}
+
+// AddPayment pays part or all of the outstanding balance from the wallet.
+func (h *Handler) AddPayment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error("AddPayment: invalid id", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var req AddPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("AddPayment: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	if _, ok := h.loadOwnedOrder(c, "AddPayment", id); !ok {
+		return
+	}
+
+	order, err := h.orderUC.AddPayment(c.Request.Context(), id, req.Amount)
+	if err != nil {
+		if errors.Is(err, entities.ErrInsufficientFunds) {
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "insufficient funds"})
+			return
+		}
+		if errors.Is(err, entities.ErrOverpayment) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if h.writeTransitionError(c, err) {
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("AddPayment: failed to add payment", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *Handler) GetOrderHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error("GetOrderHistory: invalid id", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if _, ok := h.loadOwnedOrder(c, "GetOrderHistory", id); !ok {
+		return
+	}
+
+	history, err := h.orderUC.GetOrderHistory(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("GetOrderHistory: failed to get history", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
 func (h *Handler) ListAllOrders(c *gin.Context) {
-	orders, err := h.orderUC.ListAllOrders(c.Request.Context())
+	page, err := pagination.ParseRequest(c)
 	if err != nil {
-		h.logger.Error("ListAllOrders: failed to list all orders", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orders, info, err := h.orderUC.ListAllOrders(c.Request.Context(), page)
+	if pagination.IsInvalid(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ListAllOrders: failed to list all orders", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"orders": orders})
+	pagination.SetLink(c, info)
+	c.JSON(http.StatusOK, gin.H{"orders": orders, "total": info.Total, "next_cursor": info.NextCursor})
+}
+
+// loadOwnedOrder fetches the order and checks that the caller may access it,
+// writing the error response itself when not.
+func (h *Handler) loadOwnedOrder(c *gin.Context, op string, id int) (*entities.Order, bool) {
+	order, err := h.orderUC.GetOrder(c.Request.Context(), id)
+	if errors.Is(err, entities.ErrOrderNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return nil, false
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error(op+": failed to get order", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return nil, false
+	}
+	if !middleware.CanAccessUser(c, order.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+	return order, true
+}
+
+// writeTransitionError maps state machine errors to responses and reports
+// whether it wrote one.
+func (h *Handler) writeTransitionError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, entities.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+	case errors.Is(err, entities.ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrInvalidTransition),
+		errors.Is(err, entities.ErrOrderAlreadyClosed),
+		errors.Is(err, entities.ErrOrderNotFullyPaid):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
 }
@@ -1,10 +1,14 @@
 package paymenthandler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"myproject/internal/deliveries/http/middleware"
 	"myproject/internal/entities"
+	"myproject/internal/pkg/gateway"
+	"myproject/internal/pkg/money"
 	paymentcase "myproject/internal/usecases/payment"
 	"myproject/pkg/logger"
 
@@ -21,37 +25,206 @@ func NewHandler(paymentUC paymentcase.PaymentUseCase, logger logger.Interface) *
 }
 
 type DepositRequest struct {
-	UserID int     `json:"user_id" binding:"required,gt=0"`
-	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Amount    money.Money `json:"amount" binding:"required,gt=0"`
+	CardToken string      `json:"card_token" binding:"required"`
 }
 
 func (h *Handler) Deposit(c *gin.Context) {
 	var req DepositRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Deposit: invalid input", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Deposit: invalid input", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 		return
 	}
 
-	if err := h.paymentUC.Deposit(c.Request.Context(), req.UserID, req.Amount); err != nil {
-		h.logger.Error("Deposit: failed to deposit", "user_id", req.UserID, "amount", req.Amount, "error", err)
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	payment, err := h.paymentUC.Deposit(c.Request.Context(), caller.UserID, req.Amount, req.CardToken)
+	if errors.Is(err, entities.ErrPaymentDeclined) {
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": "payment declined", "payment": payment})
+		return
+	}
+	if err != nil {
+		h.writeError(c, "Deposit: failed to deposit", err, "user_id", caller.UserID, "amount", req.Amount)
+		return
+	}
+
+	if payment.Status == entities.PaymentStatusPending {
+		c.JSON(http.StatusAccepted, payment)
+		return
+	}
+	c.JSON(http.StatusCreated, payment)
+}
+
+func (h *Handler) GetPayment(c *gin.Context) {
+	payment, ok := h.loadOwnedPayment(c, "GetPayment")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+func (h *Handler) GetPaymentsByUser(c *gin.Context) {
+	userIDStr := c.Param("user_id")
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil || userID <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error("GetPaymentsByUser: invalid user_id", "user_id", userIDStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return
+	}
+
+	if !middleware.CanAccessUser(c, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	payments, err := h.paymentUC.GetPaymentsByUser(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("GetPaymentsByUser: failed to get payments", "user_id", userID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "deposit successful"})
+	c.JSON(http.StatusOK, gin.H{"payments": payments})
+}
+
+func (h *Handler) RefreshPayment(c *gin.Context) {
+	payment, ok := h.loadOwnedPayment(c, "RefreshPayment")
+	if !ok {
+		return
+	}
+
+	updated, err := h.paymentUC.RefreshPayment(c.Request.Context(), payment.ID)
+	if err != nil {
+		h.writeError(c, "RefreshPayment: failed to refresh payment", err, "id", payment.ID)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) RefundPayment(c *gin.Context) {
+	payment, ok := h.loadOwnedPayment(c, "RefundPayment")
+	if !ok {
+		return
+	}
+
+	updated, err := h.paymentUC.RefundPayment(c.Request.Context(), payment.ID)
+	if err != nil {
+		if errors.Is(err, entities.ErrPaymentStateConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "payment cannot be refunded"})
+			return
+		}
+		if errors.Is(err, entities.ErrInsufficientFunds) {
+			c.JSON(http.StatusConflict, gin.H{"error": "deposit has already been spent"})
+			return
+		}
+		h.writeError(c, "RefundPayment: failed to refund payment", err, "id", payment.ID)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// Webhook receives charge updates from a payment provider. It is not behind
+// Auth; the HMAC signature is the only credential.
+func (h *Handler) Webhook(c *gin.Context) {
+	provider := c.Param("provider")
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+
+	err = h.paymentUC.HandleWebhook(c.Request.Context(), provider, payload, c.GetHeader(gateway.SignatureHeader))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"received": true})
+	case errors.Is(err, gateway.ErrUnknownProvider):
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown provider"})
+	case errors.Is(err, gateway.ErrInvalidSignature):
+		h.logger.WithContext(c.Request.Context()).Warn("Webhook: invalid signature", "provider", provider, "error", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+	case errors.Is(err, entities.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+	case errors.Is(err, entities.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+	case errors.Is(err, entities.ErrPaymentStateConflict):
+		h.logger.WithContext(c.Request.Context()).Warn("Webhook: event conflicts with payment", "provider", provider, "error", err)
+		c.JSON(http.StatusConflict, gin.H{"error": "event conflicts with payment state"})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error("Webhook: failed to apply event", "provider", provider, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+// writeError answers a failed payment operation: 400 for requests the
+// service rejected, 502 only when the payment provider failed.
+func (h *Handler) writeError(c *gin.Context, msg string, err error, args ...any) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidInput),
+		errors.Is(err, entities.ErrInvalidPosting),
+		errors.Is(err, money.ErrCurrencyMismatch),
+		errors.Is(err, money.ErrUnknownCurrency),
+		errors.Is(err, money.ErrPrecision),
+		errors.Is(err, money.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+	case errors.Is(err, entities.ErrPaymentStateConflict):
+		h.logger.WithContext(c.Request.Context()).Warn(msg, append(args, "error", err)...)
+		c.JSON(http.StatusConflict, gin.H{"error": "payment conflicts with the provider's state"})
+	case errors.Is(err, entities.ErrPaymentProvider):
+		h.logger.WithContext(c.Request.Context()).Error(msg, append(args, "error", err)...)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "payment provider error"})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error(msg, append(args, "error", err)...)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
+
+func (h *Handler) loadOwnedPayment(c *gin.Context, op string) (*entities.Payment, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error(op+": invalid id", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	payment, err := h.paymentUC.GetPayment(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, entities.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return nil, false
+		}
+		h.logger.WithContext(c.Request.Context()).Error(op+": failed to get payment", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return nil, false
+	}
+
+	if !middleware.CanAccessUser(c, payment.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+
+	return payment, true
 }
 
 type CreateTransactionRequest struct {
-	UserID int     `json:"user_id" binding:"required,gt=0"`
-	Amount float64 `json:"amount" binding:"required"`
-	Type   string  `json:"type" binding:"required"`
+	UserID int         `json:"user_id" binding:"required,gt=0"`
+	Amount money.Money `json:"amount" binding:"required"`
+	Type   string      `json:"type" binding:"required"`
 }
 
 func (h *Handler) CreateTransaction(c *gin.Context) {
 	var req CreateTransactionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("CreateTransaction: invalid input", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("CreateTransaction: invalid input", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 		return
 	}
@@ -63,7 +236,7 @@ func (h *Handler) CreateTransaction(c *gin.Context) {
 	}
 
 	if err := h.paymentUC.CreateTransaction(c.Request.Context(), tx); err != nil {
-		h.logger.Error("CreateTransaction: failed to create transaction", "transaction", tx, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("CreateTransaction: failed to create transaction", "transaction", tx, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -75,14 +248,19 @@ func (h *Handler) GetTransactionsByUser(c *gin.Context) {
 	userIDStr := c.Param("user_id")
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil || userID <= 0 {
-		h.logger.Error("GetTransactionsByUser: invalid user_id", "user_id", userIDStr, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("GetTransactionsByUser: invalid user_id", "user_id", userIDStr, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
 		return
 	}
 
+	if !middleware.CanAccessUser(c, userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
 	transactions, err := h.paymentUC.GetTransactionsByUser(c.Request.Context(), userID)
 	if err != nil {
-		h.logger.Error("GetTransactionsByUser: failed to get transactions", "user_id", userID, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("GetTransactionsByUser: failed to get transactions", "user_id", userID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -0,0 +1,183 @@
+package reservationhandler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"myproject/internal/deliveries/http/middleware"
+	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
+	reservationcase "myproject/internal/usecases/reservation"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	reservationUC reservationcase.UseCase
+	logger        logger.Interface
+}
+
+func NewHandler(reservationUC reservationcase.UseCase, logger logger.Interface) *Handler {
+	return &Handler{reservationUC: reservationUC, logger: logger}
+}
+
+type CreateReservationRequest struct {
+	CarID int `json:"car_id" binding:"required,gt=0"`
+}
+
+func (h *Handler) CreateReservation(c *gin.Context) {
+	var req CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("CreateReservation: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	reservation, err := h.reservationUC.CreateReservation(c.Request.Context(), caller.UserID, req.CarID)
+	if err != nil {
+		if errors.Is(err, entities.ErrCarNotAvailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": "car is not available"})
+			return
+		}
+		if errors.Is(err, entities.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "car not found"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("CreateReservation: failed to create reservation", "car_id", req.CarID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+func (h *Handler) GetReservation(c *gin.Context) {
+	reservation, ok := h.loadOwnedReservation(c, "GetReservation")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (h *Handler) ListReservations(c *gin.Context) {
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var (
+		reservations []entities.Reservation
+		err          error
+	)
+	if caller.IsStaff() {
+		reservations, err = h.reservationUC.ListAllReservations(c.Request.Context())
+	} else {
+		reservations, err = h.reservationUC.GetReservationsByUserID(c.Request.Context(), caller.UserID)
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ListReservations: failed to list reservations", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reservations": reservations})
+}
+
+func (h *Handler) CancelReservation(c *gin.Context) {
+	reservation, ok := h.loadOwnedReservation(c, "CancelReservation")
+	if !ok {
+		return
+	}
+
+	if err := h.reservationUC.CancelReservation(c.Request.Context(), reservation.ID); err != nil {
+		if errors.Is(err, entities.ErrReservationNotActive) {
+			c.JSON(http.StatusConflict, gin.H{"error": "reservation is not active"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("CancelReservation: failed to cancel reservation", "id", reservation.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reservation cancelled successfully"})
+}
+
+type ConvertReservationRequest struct {
+	Deposit money.Money `json:"deposit" binding:"gte=0"`
+}
+
+func (h *Handler) ConvertToOrder(c *gin.Context) {
+	var req ConvertReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ConvertToOrder: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	reservation, ok := h.loadOwnedReservation(c, "ConvertToOrder")
+	if !ok {
+		return
+	}
+
+	orderID, err := h.reservationUC.ConvertToOrder(c.Request.Context(), reservation.ID, req.Deposit)
+	if err != nil {
+		if errors.Is(err, entities.ErrReservationNotActive) {
+			c.JSON(http.StatusConflict, gin.H{"error": "reservation is not active"})
+			return
+		}
+		if errors.Is(err, entities.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "confirm your email address before placing an order"})
+			return
+		}
+		if errors.Is(err, entities.ErrInsufficientFunds) {
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "insufficient funds"})
+			return
+		}
+		if errors.Is(err, entities.ErrDepositTooLow) || errors.Is(err, entities.ErrOverpayment) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("ConvertToOrder: failed to convert reservation", "id", reservation.ID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": orderID, "message": "order created successfully"})
+}
+
+func (h *Handler) loadOwnedReservation(c *gin.Context, op string) (*entities.Reservation, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error(op+": invalid id", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	reservation, err := h.reservationUC.GetReservation(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, entities.ErrReservationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reservation not found"})
+			return nil, false
+		}
+		h.logger.WithContext(c.Request.Context()).Error(op+": failed to get reservation", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return nil, false
+	}
+
+	if !middleware.CanAccessUser(c, reservation.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+
+	return reservation, true
+}
@@ -0,0 +1,214 @@
+package testdrivehandler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"myproject/internal/deliveries/http/middleware"
+	"myproject/internal/entities"
+	testdrivecase "myproject/internal/usecases/testdrive"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	testDriveUC testdrivecase.UseCase
+	logger      logger.Interface
+}
+
+func NewHandler(testDriveUC testdrivecase.UseCase, logger logger.Interface) *Handler {
+	return &Handler{testDriveUC: testDriveUC, logger: logger}
+}
+
+type ScheduleTestDriveRequest struct {
+	CarID         int       `json:"car_id" binding:"required,gt=0"`
+	Date          time.Time `json:"date" binding:"required"`
+	SalespersonID *int      `json:"salesperson_id" binding:"omitempty,gt=0"`
+}
+
+func (h *Handler) ScheduleTestDrive(c *gin.Context) {
+	var req ScheduleTestDriveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ScheduleTestDrive: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	testDrive, err := h.testDriveUC.ScheduleTestDrive(c.Request.Context(), &entities.TestDrive{
+		UserID:        caller.UserID,
+		CarID:         req.CarID,
+		SalespersonID: req.SalespersonID,
+		Date:          req.Date,
+	})
+	if err != nil {
+		h.respondError(c, "ScheduleTestDrive", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, testDrive)
+}
+
+func (h *Handler) GetTestDrive(c *gin.Context) {
+	testDrive, ok := h.loadOwnedTestDrive(c, "GetTestDrive")
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, testDrive)
+}
+
+func (h *Handler) ListTestDrives(c *gin.Context) {
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var (
+		testDrives []entities.TestDrive
+		err        error
+	)
+	if caller.IsStaff() {
+		testDrives, err = h.testDriveUC.ListAllTestDrives(c.Request.Context())
+	} else {
+		testDrives, err = h.testDriveUC.GetTestDrivesByUserID(c.Request.Context(), caller.UserID)
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ListTestDrives: failed to list test drives", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"test_drives": testDrives})
+}
+
+func (h *Handler) AvailableSlots(c *gin.Context) {
+	carID, err := strconv.Atoi(c.Query("car_id"))
+	if err != nil || carID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid car_id"})
+		return
+	}
+
+	slots, err := h.testDriveUC.AvailableSlots(c.Request.Context(), carID, c.Query("date"))
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidTestDriveDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("AvailableSlots: failed to list slots", "car_id", carID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"slots": slots})
+}
+
+type RescheduleTestDriveRequest struct {
+	Date time.Time `json:"date" binding:"required"`
+}
+
+func (h *Handler) RescheduleTestDrive(c *gin.Context) {
+	var req RescheduleTestDriveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("RescheduleTestDrive: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	testDrive, ok := h.loadOwnedTestDrive(c, "RescheduleTestDrive")
+	if !ok {
+		return
+	}
+
+	updated, err := h.testDriveUC.RescheduleTestDrive(c.Request.Context(), testDrive.ID, req.Date)
+	if err != nil {
+		h.respondError(c, "RescheduleTestDrive", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+type UpdateTestDriveStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+func (h *Handler) UpdateTestDriveStatus(c *gin.Context) {
+	var req UpdateTestDriveStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("UpdateTestDriveStatus: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	testDrive, ok := h.loadOwnedTestDrive(c, "UpdateTestDriveStatus")
+	if !ok {
+		return
+	}
+
+	// Customers may only cancel; completing or marking a no-show is up to staff.
+	if caller, _ := middleware.CurrentIdentity(c); !caller.IsStaff() && req.Status != entities.TestDriveStatusCancelled {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	if err := h.testDriveUC.UpdateTestDriveStatus(c.Request.Context(), testDrive.ID, req.Status); err != nil {
+		h.respondError(c, "UpdateTestDriveStatus", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "test drive status updated successfully"})
+}
+
+func (h *Handler) loadOwnedTestDrive(c *gin.Context, op string) (*entities.TestDrive, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error(op+": invalid id", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	testDrive, err := h.testDriveUC.GetTestDrive(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, op, err)
+		return nil, false
+	}
+
+	if !middleware.CanAccessUser(c, testDrive.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return nil, false
+	}
+
+	return testDrive, true
+}
+
+func (h *Handler) respondError(c *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrTestDriveNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "test drive not found"})
+	case errors.Is(err, entities.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "car not found"})
+	case errors.Is(err, entities.ErrTestDriveConflict),
+		errors.Is(err, entities.ErrTestDriveNotScheduled),
+		errors.Is(err, entities.ErrCarNotAvailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, entities.ErrTestDriveOutsideHours),
+		errors.Is(err, entities.ErrTestDriveInPast),
+		errors.Is(err, entities.ErrInvalidTestDriveStatus),
+		errors.Is(err, entities.ErrInvalidSalesperson),
+		errors.Is(err, entities.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error(op+": request failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
@@ -0,0 +1,156 @@
+package userhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"myproject/internal/deliveries/http/middleware"
+	"myproject/internal/entities"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("VerifyEmail: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	if err := h.userUC.VerifyEmail(c.Request.Context(), input.Token); err != nil {
+		if errors.Is(err, entities.ErrInvalidAccountToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, entities.ErrEmailAlreadyVerified) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("VerifyEmail: failed to verify email", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email address confirmed"})
+}
+
+func (h *Handler) ResendVerification(c *gin.Context) {
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.userUC.ResendVerification(c.Request.Context(), caller.UserID); err != nil {
+		if errors.Is(err, entities.ErrEmailAlreadyVerified) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, entities.ErrEmailNotSent) {
+			h.logger.WithContext(c.Request.Context()).Error("ResendVerification: email not sent", "user_id", caller.UserID, "error", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "email could not be sent, try again later"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("ResendVerification: failed to resend verification", "user_id", caller.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
+
+// ForgotPassword answers the same way whether or not the address belongs to
+// an account. The reset is requested in the background so that the response
+// time does not reveal it either.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ForgotPassword: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	ctx := context.WithoutCancel(c.Request.Context())
+	go func() {
+		if err := h.userUC.RequestPasswordReset(ctx, input.Email); err != nil {
+			h.logger.WithContext(ctx).Error("ForgotPassword: failed to request password reset", "error", err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if the address is registered, a reset link has been sent"})
+}
+
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var input struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ResetPassword: invalid input", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+
+	err := h.userUC.ResetPassword(c.Request.Context(), input.Token, input.NewPassword)
+	if errors.Is(err, entities.ErrEmailNotSent) {
+		h.logger.WithContext(c.Request.Context()).Warn("ResetPassword: password changed notice not sent", "error", err)
+		err = nil
+	}
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidAccountToken) || errors.Is(err, entities.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("ResetPassword: failed to reset password", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password has been reset, sign in with the new password"})
+}
+
+func (h *Handler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error("UnlockUser: invalid id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := h.userUC.UnlockUser(c.Request.Context(), id); err != nil {
+		if errors.Is(err, entities.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("UnlockUser: failed to unlock user", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+func (h *Handler) ListLockoutEvents(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		h.logger.WithContext(c.Request.Context()).Error("ListLockoutEvents: invalid id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	events, err := h.userUC.ListLockoutEvents(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("ListLockoutEvents: failed to list lockout events", "id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": events})
+}
@@ -2,9 +2,12 @@ package userhandler
 
 import (
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
+	"myproject/internal/deliveries/http/middleware"
+	"myproject/internal/deliveries/http/pagination"
 	"myproject/internal/entities"
 	usercase "myproject/internal/usecases/user"
 
@@ -25,41 +28,56 @@ func NewHandler(userUC usercase.UseCase, logger logger.Interface) *Handler {
 func (h *Handler) CreateUser(c *gin.Context) {
 	var input entities.User
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.logger.Error("CreateUser: invalid input", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("CreateUser: invalid input", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 		return
 	}
 
-	if err := h.userUC.Create(c.Request.Context(), &input); err != nil {
-		h.logger.Error("CreateUser: user creation failed", "error", err)
+	err := h.userUC.Create(c.Request.Context(), &input)
+	if errors.Is(err, entities.ErrEmailNotSent) {
+		// The account exists; the user can ask for the link again.
+		h.logger.WithContext(c.Request.Context()).Warn("CreateUser: verification email not sent", "id", input.ID, "error", err)
+		err = nil
+	}
+	if err != nil {
+		if errors.Is(err, entities.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("CreateUser: user creation failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "user created successfully"})
+	c.JSON(http.StatusCreated, gin.H{"id": input.ID, "message": "user created successfully, check your email to confirm the address"})
 }
 
 func (h *Handler) GetUserByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil || id <= 0 {
-		h.logger.Error("GetUserByID: invalid id", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("GetUserByID: invalid id", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
+	if !middleware.CanAccessUser(c, id) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
 	user, err := h.userUC.GetByID(c.Request.Context(), id)
 	if err != nil {
 		if errors.Is(err, entities.ErrInvalidID) {
-			h.logger.Warn("GetUserByID: invalid user ID", "id", id)
+			h.logger.WithContext(c.Request.Context()).Warn("GetUserByID: invalid user ID", "id", id)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 			return
 		}
 		if errors.Is(err, entities.ErrNotFound) {
-			h.logger.Warn("GetUserByID: user not found", "id", id)
+			h.logger.WithContext(c.Request.Context()).Warn("GetUserByID: user not found", "id", id)
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
-		h.logger.Error("GetUserByID: failed to get user", "id", id, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("GetUserByID: failed to get user", "id", id, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -70,31 +88,46 @@ func (h *Handler) GetUserByID(c *gin.Context) {
 func (h *Handler) UpdateUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil || id <= 0 {
-		h.logger.Error("UpdateUser: invalid id", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("UpdateUser: invalid id", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
+	caller, ok := middleware.CurrentIdentity(c)
+	if !ok || (caller.UserID != id && caller.Role != entities.RoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
 	var input entities.User
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.logger.Error("UpdateUser: invalid input", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("UpdateUser: invalid input", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 		return
 	}
 	input.ID = id
+	if caller.Role != entities.RoleAdmin {
+		input.Role = ""
+	}
 
-	if err := h.userUC.Update(c.Request.Context(), &input); err != nil {
+	err = h.userUC.Update(c.Request.Context(), &input)
+	if errors.Is(err, entities.ErrEmailNotSent) {
+		// The change is saved; the user can ask for the link again.
+		h.logger.WithContext(c.Request.Context()).Warn("UpdateUser: verification email not sent", "id", id, "error", err)
+		err = nil
+	}
+	if err != nil {
 		if errors.Is(err, entities.ErrInvalidID) {
-			h.logger.Warn("UpdateUser: invalid user ID", "id", id)
+			h.logger.WithContext(c.Request.Context()).Warn("UpdateUser: invalid user ID", "id", id)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required in the request body"})
 			return
 		}
 		if errors.Is(err, entities.ErrNotFound) {
-			h.logger.Warn("UpdateUser: user not found", "id", id)
+			h.logger.WithContext(c.Request.Context()).Warn("UpdateUser: user not found", "id", id)
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
-		h.logger.Error("UpdateUser: failed to update user", "id", id, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("UpdateUser: failed to update user", "id", id, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -105,18 +138,22 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 func (h *Handler) DeleteUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil || id <= 0 {
-		h.logger.Error("DeleteUser: invalid id", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("DeleteUser: invalid id", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
 	if err := h.userUC.Delete(c.Request.Context(), id); err != nil {
 		if errors.Is(err, entities.ErrInvalidID) {
-			h.logger.Warn("DeleteUser: invalid user ID", "id", id)
+			h.logger.WithContext(c.Request.Context()).Warn("DeleteUser: invalid user ID", "id", id)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 			return
 		}
-		h.logger.Error("DeleteUser: failed to delete user", "id", id, "error", err)
+		if errors.Is(err, entities.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("DeleteUser: failed to delete user", "id", id, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -125,74 +162,76 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 }
 
 func (h *Handler) ListUsers(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		h.logger.Error("ListUsers: invalid limit", "error", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+	page, err := pagination.ParseRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		h.logger.Error("ListUsers: invalid offset", "error", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+	users, info, err := h.userUC.List(c.Request.Context(), page)
+	if pagination.IsInvalid(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	users, err := h.userUC.List(c.Request.Context(), limit, offset)
 	if err != nil {
-		h.logger.Error("ListUsers: failed to list users", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("ListUsers: failed to list users", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
 
-	count, err := h.userUC.Count(c.Request.Context())
-	if err != nil {
-		h.logger.Error("ListUsers: failed to count users", "error", err)
-	}
-
-	c.JSON(http.StatusOK, gin.H{"items": users, "total": count})
+	pagination.SetLink(c, info)
+	c.JSON(http.StatusOK, gin.H{"items": users, "total": info.Total, "next_cursor": info.NextCursor})
 }
 
 func (h *Handler) ChangePassword(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil || id <= 0 {
-		h.logger.Error("ChangePassword: invalid id", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("ChangePassword: invalid id", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
 
+	if caller, ok := middleware.CurrentIdentity(c); !ok || caller.UserID != id {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
 	var input struct {
 		OldPassword string `json:"old_password"`
 		NewPassword string `json:"new_password"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.logger.Error("ChangePassword: invalid input", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("ChangePassword: invalid input", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 		return
 	}
 
 	err = h.userUC.ChangePassword(c.Request.Context(), id, input.OldPassword, input.NewPassword)
+	if errors.Is(err, entities.ErrEmailNotSent) {
+		h.logger.WithContext(c.Request.Context()).Warn("ChangePassword: password changed notice not sent", "id", id, "error", err)
+		err = nil
+	}
 	if err != nil {
+		if errors.Is(err, entities.ErrWeakPassword) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, entities.ErrInvalidID) { // Используем ошибки из entity
-			h.logger.Warn("ChangePassword: invalid user ID", "id", id)
+			h.logger.WithContext(c.Request.Context()).Warn("ChangePassword: invalid user ID", "id", id)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
 			return
 		}
 		if errors.Is(err, errors.New("new password is required")) {
-			h.logger.Warn("ChangePassword: new password required", "id", id)
+			h.logger.WithContext(c.Request.Context()).Warn("ChangePassword: new password required", "id", id)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "new password is required"})
 			return
 		}
 		if errors.Is(err, errors.New("invalid old password")) {
-			h.logger.Warn("ChangePassword: invalid old password", "id", id)
+			h.logger.WithContext(c.Request.Context()).Warn("ChangePassword: invalid old password", "id", id)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid old password"})
 			return
 		}
-		h.logger.Error("ChangePassword: failed to change password", "id", id, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("ChangePassword: failed to change password", "id", id, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
@@ -207,35 +246,59 @@ func (h *Handler) AuthenticateUser(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		h.logger.Error("AuthenticateUser: invalid input", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("AuthenticateUser: invalid input", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 		return
 	}
 
-	token, user, err := h.userUC.Authenticate(c.Request.Context(), input.Email, input.Password)
+	meta := entities.SessionMeta{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+	signIn, err := h.userUC.Authenticate(c.Request.Context(), input.Email, input.Password, meta)
 	if err != nil {
 		if errors.Is(err, errors.New("email and password are required")) {
-			h.logger.Warn("AuthenticateUser: email and password required")
+			h.logger.WithContext(c.Request.Context()).Warn("AuthenticateUser: email and password required")
 			c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
 			return
 		}
-		if errors.Is(err, entities.ErrNotFound) {
-			h.logger.Warn("AuthenticateUser: invalid credentials (user not found)")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		var throttled *entities.LoginThrottledError
+		if errors.As(err, &throttled) {
+			h.logger.WithContext(c.Request.Context()).Warn("AuthenticateUser: sign-in throttled", "ip", meta.IPAddress, "retry_after", throttled.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed sign-in attempts, try again later"})
 			return
 		}
-		if errors.Is(err, errors.New("invalid credentials")) {
-			h.logger.Warn("AuthenticateUser: invalid credentials")
+		if errors.Is(err, entities.ErrInvalidCredentials) {
+			h.logger.WithContext(c.Request.Context()).Warn("AuthenticateUser: invalid credentials", "ip", meta.IPAddress)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 			return
 		}
-		h.logger.Error("AuthenticateUser: authentication failed", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("AuthenticateUser: authentication failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user":  user,
-	})
+	if signIn.Challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":    true,
+			"challenge_token": signIn.Challenge.Token,
+			"challenge_type":  signIn.Challenge.Purpose,
+			"expires_at":      signIn.Challenge.ExpiresAt,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, signInResponse(signIn))
+}
+
+// signInResponse is the body returned once a sign-in opened a session.
+func signInResponse(signIn *entities.SignIn) gin.H {
+	tokens := signIn.Tokens
+	return gin.H{
+		"token":              tokens.AccessToken,
+		"session_id":         tokens.SessionID,
+		"access_token":       tokens.AccessToken,
+		"access_expires_at":  tokens.AccessExpiresAt,
+		"refresh_token":      tokens.RefreshToken,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+		"user":               signIn.User,
+	}
 }
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/identity"
+	"myproject/internal/pkg/token"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ContextUserID = "user_id"
+	ContextRole   = "role"
+)
+
+type TokenValidator interface {
+	VerifyToken(tokenString string) (*token.Payload, error)
+}
+
+type SessionChecker interface {
+	IsActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// Auth validates the bearer token, rejects tokens of revoked sessions and
+// stores the caller identity both in the gin context and in the request
+// context so usecases can read it.
+func Auth(validator TokenValidator, sessions SessionChecker, log logger.Interface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(tokenString) == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		payload, err := validator.VerifyToken(strings.TrimSpace(tokenString))
+		if errors.Is(err, token.ErrExpiredToken) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has expired"})
+			return
+		}
+		if err != nil {
+			log.WithContext(c.Request.Context()).Warn("Auth: invalid token", "error", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		active, err := sessions.IsActive(c.Request.Context(), payload.SessionID)
+		if err != nil {
+			log.WithContext(c.Request.Context()).Error("Auth: failed to check session", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		if !active {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+			return
+		}
+
+		id := identity.Identity{UserID: payload.UserID, Role: normalizeRole(payload.Role), SessionID: payload.SessionID}
+
+		c.Set(ContextUserID, id.UserID)
+		c.Set(ContextRole, id.Role)
+		ctx := identity.NewContext(c.Request.Context(), id)
+		ctx = logger.NewContext(ctx, "user_id", id.UserID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireRoles must be mounted after Auth.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := CurrentIdentity(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if !id.HasRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func CurrentIdentity(c *gin.Context) (identity.Identity, bool) {
+	return identity.FromContext(c.Request.Context())
+}
+
+// CanAccessUser reports whether the caller may read or modify data owned by userID.
+func CanAccessUser(c *gin.Context, userID int) bool {
+	id, ok := CurrentIdentity(c)
+	if !ok {
+		return false
+	}
+	return id.UserID == userID || id.IsStaff()
+}
+
+// normalizeRole maps legacy and empty roles onto the customer role.
+func normalizeRole(role string) string {
+	switch role {
+	case entities.RoleManager, entities.RoleAdmin:
+		return role
+	default:
+		return entities.RoleCustomer
+	}
+}
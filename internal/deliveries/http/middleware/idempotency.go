@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"myproject/internal/entities"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+type IdempotencyStore interface {
+	Begin(ctx context.Context, userID int, key, fingerprint string) (*entities.IdempotencyRecord, error)
+	Complete(ctx context.Context, userID int, key string, status int, body []byte, contentType string) error
+	Release(ctx context.Context, userID int, key string) error
+}
+
+// Idempotency honors the Idempotency-Key header: the first request with a key
+// runs normally and its response is stored, repeats get the stored response
+// back, and reusing the key for a different request is rejected. Keys are
+// scoped to the caller, so it must be mounted after Auth. Requests without the
+// header pass through unchanged.
+func Idempotency(store IdempotencyStore, logger logger.Interface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		id, ok := CurrentIdentity(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentRequestBytes+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		if len(body) > maxIdempotentRequestBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		record, err := store.Begin(ctx, id.UserID, key, fingerprint(c.Request.Method, c.Request.URL.Path, body))
+		switch {
+		case errors.Is(err, entities.ErrIdempotencyKeyMismatch):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, entities.ErrIdempotencyKeyInProgress):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			logger.WithContext(c.Request.Context()).Error("Idempotency: failed to begin request", "error", err, "key", key)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			return
+		case record != nil:
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(record.ResponseStatus, record.ContentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not stored so the client can retry with the same key.
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(context.WithoutCancel(ctx), id.UserID, key); err != nil {
+				logger.WithContext(c.Request.Context()).Error("Idempotency: failed to release key", "error", err, "key", key)
+			}
+			return
+		}
+		err = store.Complete(context.WithoutCancel(ctx), id.UserID, key, status, recorder.body.Bytes(), recorder.Header().Get("Content-Type"))
+		if err != nil {
+			logger.WithContext(c.Request.Context()).Error("Idempotency: failed to store response", "error", err, "key", key)
+		}
+	}
+}
+
+// fingerprint identifies the request a key was first used with.
+func fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body while writing it through.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"myproject/internal/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests no route matched, so scanners probing
+// random paths do not create a series per path.
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels methods outside the standard set, which clients can
+// make up freely.
+const otherMethod = "other"
+
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// Metrics counts requests and observes their latency, labeled by the route
+// template (e.g. /api/cars/:id) rather than the raw path.
+func Metrics(registry *metrics.Registry) gin.HandlerFunc {
+	requests := registry.NewCounterVec("http_requests_total",
+		"HTTP requests served, by method, route and status.", "method", "route", "status")
+	latency := registry.NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency in seconds, by method and route.", metrics.DefBuckets, "method", "route")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		if !standardMethods[method] {
+			method = otherMethod
+		}
+		requests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		latency.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"myproject/internal/pkg/requestinfo"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderRequestID = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestInfo keeps the X-Request-ID sent by the client, or assigns a new
+// one, and echoes it in the response. The ID and the client address are
+// stored in the request context for usecases and for loggers bound to it.
+func RequestInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := strings.TrimSpace(c.GetHeader(HeaderRequestID))
+		if len(requestID) > maxRequestIDLength {
+			requestID = requestID[:maxRequestIDLength]
+		}
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		c.Header(HeaderRequestID, requestID)
+
+		info := requestinfo.Info{
+			RequestID: requestID,
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+		ctx := requestinfo.NewContext(c.Request.Context(), info)
+		ctx = logger.NewContext(ctx, "request_id", requestID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// AccessLog logs every request once it is served. It must be mounted after
+// RequestInfo so entries carry the request ID.
+func AccessLog(log logger.Interface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		c.Next()
+
+		status := c.Writer.Status()
+		args := []interface{}{
+			"method", c.Request.Method,
+			"path", path,
+			"route", c.FullPath(),
+			"status", status,
+			"latency", time.Since(start),
+			"bytes", c.Writer.Size(),
+			"ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			args = append(args, "errors", c.Errors.String())
+		}
+
+		// c.Request now carries whatever later middleware added, e.g. the user.
+		l := log.WithContext(c.Request.Context())
+		switch {
+		case status >= http.StatusInternalServerError:
+			l.Error("request served", args...)
+		case status >= http.StatusBadRequest:
+			l.Warn("request served", args...)
+		default:
+			l.Info("request served", args...)
+		}
+	}
+}
+
+// Recovery turns a panic into a 500 response and logs it with the request ID.
+func Recovery(log logger.Interface) gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, err any) {
+		log.WithContext(c.Request.Context()).Error("Recovery: panic while serving request", "error", err, "path", c.Request.URL.Path)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	})
+}
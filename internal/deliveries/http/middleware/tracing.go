@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"myproject/internal/pkg/tracing"
+	"myproject/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tracing starts a server span per request, continuing the trace of an
+// incoming traceparent header, and adds the trace ID to log entries. It
+// must be mounted after RequestInfo.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if parent, err := tracing.ParseTraceparent(c.GetHeader(tracing.HeaderTraceparent)); err == nil {
+			ctx = tracing.ContextWithRemoteParent(ctx, parent)
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		ctx, span := tracing.StartSpan(ctx, fmt.Sprintf("%s %s", c.Request.Method, route), tracing.SpanKindServer,
+			tracing.Attr("http.method", c.Request.Method),
+			tracing.Attr("http.route", route),
+			tracing.Attr("http.target", c.Request.URL.RequestURI()),
+			tracing.Attr("http.client_ip", c.ClientIP()),
+			tracing.Attr("http.user_agent", c.Request.UserAgent()),
+			tracing.Attr("http.request_id", c.Writer.Header().Get(HeaderRequestID)),
+		)
+		defer span.End()
+
+		if span != nil {
+			ctx = logger.NewContext(ctx, "trace_id", span.SpanContext().TraceID.String())
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(tracing.Attr("http.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.SetAttributes(tracing.Attr("gin.errors", c.Errors.String()))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Package pagination maps list query parameters to entities.PageRequest and
+// advertises the next page in the response.
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"myproject/internal/entities"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseRequest reads limit, offset, cursor and sort from the query string.
+// The older sort_by/sort_order pair is accepted when sort is absent.
+func ParseRequest(c *gin.Context) (entities.PageRequest, error) {
+	page := entities.PageRequest{
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+	if page.Sort == "" && c.Query("sort_by") != "" {
+		page.Sort = c.Query("sort_by")
+		if strings.EqualFold(c.Query("sort_order"), "desc") {
+			page.Sort = "-" + page.Sort
+		}
+	}
+
+	var err error
+	if v := c.Query("limit"); v != "" {
+		if page.Limit, err = strconv.Atoi(v); err != nil || page.Limit <= 0 {
+			return page, fmt.Errorf("%w: limit must be a positive integer", entities.ErrInvalidPage)
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		if page.Offset, err = strconv.Atoi(v); err != nil || page.Offset < 0 {
+			return page, fmt.Errorf("%w: offset must be a non-negative integer", entities.ErrInvalidPage)
+		}
+	}
+	return page, nil
+}
+
+// IsInvalid reports whether err was caused by bad paging parameters, which
+// handlers answer with 400.
+func IsInvalid(err error) bool {
+	return errors.Is(err, entities.ErrInvalidPage) ||
+		errors.Is(err, entities.ErrInvalidSort) ||
+		errors.Is(err, entities.ErrInvalidCursor)
+}
+
+// SetLink adds a Link header pointing at the next page, if there is one. The
+// URL is the current request with the cursor swapped in and any offset
+// dropped.
+func SetLink(c *gin.Context, page entities.PageInfo) {
+	if page.NextCursor == "" {
+		return
+	}
+	next := *c.Request.URL
+	query := next.Query()
+	query.Del("offset")
+	query.Set("cursor", page.NextCursor)
+	next.RawQuery = query.Encode()
+	c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
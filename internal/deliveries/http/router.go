@@ -1,14 +1,35 @@
 package http
 
 import (
+	"fmt"
+	"net/http"
+
 	"myproject/internal/deliveries/http/handler"
+	audithandler "myproject/internal/deliveries/http/handler/audit"
+	authhandler "myproject/internal/deliveries/http/handler/auth"
 	carhandler "myproject/internal/deliveries/http/handler/car"
+	ledgerhandler "myproject/internal/deliveries/http/handler/ledger"
+	mediahandler "myproject/internal/deliveries/http/handler/media"
+	mfahandler "myproject/internal/deliveries/http/handler/mfa"
 	orderhandler "myproject/internal/deliveries/http/handler/order"
 	paymenthandler "myproject/internal/deliveries/http/handler/payment"
+	reservationhandler "myproject/internal/deliveries/http/handler/reservation"
+	testdrivehandler "myproject/internal/deliveries/http/handler/testdrive"
 	userhandler "myproject/internal/deliveries/http/handler/user"
+	"myproject/internal/deliveries/http/middleware"
+	"myproject/internal/entities"
+	"myproject/internal/pkg/health"
+	"myproject/internal/pkg/metrics"
+	auditcase "myproject/internal/usecases/audit"
+	authcase "myproject/internal/usecases/auth"
 	"myproject/internal/usecases/car"
+	ledgercase "myproject/internal/usecases/ledger"
+	mediacase "myproject/internal/usecases/media"
+	mfacase "myproject/internal/usecases/mfa"
 	ordercase "myproject/internal/usecases/order"
 	paymentcase "myproject/internal/usecases/payment"
+	reservationcase "myproject/internal/usecases/reservation"
+	testdrivecase "myproject/internal/usecases/testdrive"
 	usercase "myproject/internal/usecases/user"
 	"myproject/pkg/logger"
 
@@ -16,68 +37,187 @@ import (
 )
 
 type RouterDependencies struct {
-	UserUC    usercase.UseCase
-	CarUC     car.CarUseCase
-	OrderUC   ordercase.UseCase
-	PaymentUC paymentcase.PaymentUseCase
-	Logger    logger.Interface
+	UserUC        usercase.UseCase
+	CarUC         car.CarUseCase
+	MediaUC       mediacase.UseCase
+	OrderUC       ordercase.UseCase
+	PaymentUC     paymentcase.PaymentUseCase
+	ReservationUC reservationcase.UseCase
+	TestDriveUC   testdrivecase.UseCase
+	LedgerUC      ledgercase.UseCase
+	AuthUC        authcase.UseCase
+	MFAUC         mfacase.UseCase
+	AuditUC       auditcase.UseCase
+	Idempotency   middleware.IdempotencyStore
+	Sessions      middleware.SessionChecker
+	Auth          middleware.TokenValidator
+	Metrics       *metrics.Registry
+	Health        *health.Registry
+	// MediaFiles serves stored media under /media when blobs are kept on
+	// local disk; nil when the store hands out its own URLs.
+	MediaFiles http.Handler
+	// TrustedProxies are the only peers whose forwarding headers are used
+	// for the client IP; nil trusts none.
+	TrustedProxies []string
+	Logger         logger.Interface
 }
 
-func NewRouter(deps RouterDependencies) *gin.Engine {
-	router := gin.Default()
+func NewRouter(deps RouterDependencies) (*gin.Engine, error) {
+	registerValidators()
+	router := gin.New()
+	if err := router.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	router.Use(
+		middleware.RequestInfo(),
+		middleware.Tracing(),
+		middleware.AccessLog(deps.Logger),
+		middleware.Metrics(deps.Metrics),
+		middleware.Recovery(deps.Logger),
+	)
 
-	commonHandler := handler.NewCommonHandler(deps.Logger)
+	commonHandler := handler.NewCommonHandler(deps.Health, deps.Logger)
 
 	userHandler := userhandler.NewHandler(deps.UserUC, deps.Logger)
 	carHandler := carhandler.NewHandler(deps.CarUC, deps.Logger)
+	mediaHandler := mediahandler.NewHandler(deps.MediaUC, deps.Logger)
 	orderHandler := orderhandler.NewHandler(deps.OrderUC, deps.Logger)
 	paymentHandler := paymenthandler.NewHandler(deps.PaymentUC, deps.Logger)
+	reservationHandler := reservationhandler.NewHandler(deps.ReservationUC, deps.Logger)
+	testDriveHandler := testdrivehandler.NewHandler(deps.TestDriveUC, deps.Logger)
+	ledgerHandler := ledgerhandler.NewHandler(deps.LedgerUC, deps.Logger)
+	authHandler := authhandler.NewHandler(deps.AuthUC, deps.Logger)
+	mfaHandler := mfahandler.NewHandler(deps.MFAUC, deps.Logger)
+	auditHandler := audithandler.NewHandler(deps.AuditUC, deps.Logger)
 
-	router.GET("/health", commonHandler.HealthCheck)
+	authenticated := middleware.Auth(deps.Auth, deps.Sessions, deps.Logger)
+	staffOnly := middleware.RequireRoles(entities.RoleManager, entities.RoleAdmin)
+	adminOnly := middleware.RequireRoles(entities.RoleAdmin)
+	idempotent := middleware.Idempotency(deps.Idempotency, deps.Logger)
+
+	router.GET("/livez", commonHandler.Livez)
+	router.GET("/readyz", commonHandler.Readyz)
+	router.GET("/health", commonHandler.Readyz)
+	// Metrics expose traffic and business counters, so only admins may read
+	// them.
+	router.GET("/metrics", authenticated, adminOnly, gin.WrapH(deps.Metrics.Handler()))
+	if deps.MediaFiles != nil {
+		router.GET("/media/*key", gin.WrapH(http.StripPrefix("/media", deps.MediaFiles)))
+	}
 
 	api := router.Group("/api")
 	{
+		authRoutes := api.Group("/auth")
+		{
+			authRoutes.POST("/login", userHandler.AuthenticateUser)
+			authRoutes.POST("/refresh", authHandler.Refresh)
+			authRoutes.POST("/logout", authenticated, authHandler.Logout)
+			authRoutes.POST("/logout-all", authenticated, authHandler.LogoutAll)
+			authRoutes.POST("/mfa/verify", mfaHandler.VerifyChallenge)
+			authRoutes.POST("/mfa/enroll", mfaHandler.BeginChallengeEnrollment)
+			authRoutes.POST("/mfa/enroll/confirm", mfaHandler.ConfirmChallengeEnrollment)
+			authRoutes.POST("/verify-email", userHandler.VerifyEmail)
+			authRoutes.POST("/verify-email/resend", authenticated, userHandler.ResendVerification)
+			authRoutes.POST("/forgot-password", userHandler.ForgotPassword)
+			authRoutes.POST("/reset-password", userHandler.ResetPassword)
+		}
+
 		userRoutes := api.Group("/users")
 		{
 			userRoutes.POST("", userHandler.CreateUser)
-			userRoutes.GET("/:id", userHandler.GetUserByID)
-			userRoutes.PUT("/:id", userHandler.UpdateUser)
-			userRoutes.DELETE("/:id", userHandler.DeleteUser)
-			userRoutes.GET("", userHandler.ListUsers)
-			userRoutes.POST("/:id/password", userHandler.ChangePassword)
 			userRoutes.POST("/auth", userHandler.AuthenticateUser)
+			userRoutes.GET("/:id", authenticated, userHandler.GetUserByID)
+			userRoutes.PUT("/:id", authenticated, userHandler.UpdateUser)
+			userRoutes.DELETE("/:id", authenticated, adminOnly, userHandler.DeleteUser)
+			userRoutes.GET("", authenticated, staffOnly, userHandler.ListUsers)
+			userRoutes.POST("/:id/password", authenticated, userHandler.ChangePassword)
+			userRoutes.POST("/:id/unlock", authenticated, adminOnly, userHandler.UnlockUser)
+			userRoutes.GET("/:id/lockouts", authenticated, adminOnly, userHandler.ListLockoutEvents)
+			userRoutes.GET("/:id/mfa", authenticated, mfaHandler.GetStatus)
+			userRoutes.POST("/:id/mfa", authenticated, mfaHandler.BeginEnrollment)
+			userRoutes.POST("/:id/mfa/confirm", authenticated, mfaHandler.ConfirmEnrollment)
+			userRoutes.POST("/:id/mfa/recovery-codes", authenticated, mfaHandler.RegenerateRecoveryCodes)
+			userRoutes.POST("/:id/mfa/disable", authenticated, mfaHandler.Disable)
+			userRoutes.POST("/:id/mfa/reset", authenticated, adminOnly, mfaHandler.Reset)
 		}
 
 		carRoutes := api.Group("/cars")
 		{
-			carRoutes.POST("", carHandler.CreateCar)
 			carRoutes.GET("/:id", carHandler.GetCar)
-			carRoutes.PUT("/:id", carHandler.UpdateCar)
-			carRoutes.DELETE("/:id", carHandler.DeleteCar)
 			carRoutes.GET("", carHandler.ListCars)
-			carRoutes.PATCH("/:id/status", carHandler.ChangeCarStatus)
+			carRoutes.GET("/suggest", carHandler.Suggest)
+			carRoutes.GET("/facets", carHandler.Facets)
+			carRoutes.GET("/vin/:vin", carHandler.DecodeVIN)
+			carRoutes.POST("", authenticated, staffOnly, carHandler.CreateCar)
+			carRoutes.PUT("/:id", authenticated, staffOnly, carHandler.UpdateCar)
+			carRoutes.DELETE("/:id", authenticated, adminOnly, carHandler.DeleteCar)
+			carRoutes.PATCH("/:id/status", authenticated, staffOnly, carHandler.ChangeCarStatus)
+			carRoutes.GET("/:id/media", mediaHandler.List)
+			carRoutes.POST("/:id/media", authenticated, staffOnly, mediaHandler.Upload)
+			carRoutes.PUT("/:id/media/order", authenticated, staffOnly, mediaHandler.Reorder)
+			carRoutes.POST("/:id/media/:media_id/primary", authenticated, staffOnly, mediaHandler.SetPrimary)
+			carRoutes.DELETE("/:id/media/:media_id", authenticated, staffOnly, mediaHandler.Delete)
 		}
 
-		orderRoutes := api.Group("/orders")
+		orderRoutes := api.Group("/orders", authenticated)
 		{
-			orderRoutes.POST("", orderHandler.CreateOrder)
+			orderRoutes.POST("", idempotent, orderHandler.CreateOrder)
 			orderRoutes.GET("/:id", orderHandler.GetOrder)
+			orderRoutes.GET("/:id/history", orderHandler.GetOrderHistory)
+			orderRoutes.POST("/:id/payments", idempotent, orderHandler.AddPayment)
 			orderRoutes.GET("/user/:user_id", orderHandler.GetOrdersByUserID)
-			orderRoutes.PATCH("/:id/status", orderHandler.UpdateOrderStatus)
+			orderRoutes.PATCH("/:id/status", staffOnly, orderHandler.UpdateOrderStatus)
 			orderRoutes.DELETE("/:id", orderHandler.CancelOrder)
-			orderRoutes.GET("", orderHandler.ListAllOrders)
+			orderRoutes.GET("", staffOnly, orderHandler.ListAllOrders)
 		}
 
-		paymentRoutes := api.Group("/payments")
+		api.POST("/payments/webhooks/:provider", paymentHandler.Webhook)
+
+		paymentRoutes := api.Group("/payments", authenticated)
 		{
-			paymentRoutes.POST("/deposit", paymentHandler.Deposit)
-			paymentRoutes.POST("/transactions", paymentHandler.CreateTransaction)
+			paymentRoutes.POST("/deposit", idempotent, paymentHandler.Deposit)
+			paymentRoutes.GET("/:id", paymentHandler.GetPayment)
+			paymentRoutes.POST("/:id/refresh", paymentHandler.RefreshPayment)
+			paymentRoutes.POST("/:id/refund", adminOnly, idempotent, paymentHandler.RefundPayment)
+			paymentRoutes.GET("/user/:user_id", paymentHandler.GetPaymentsByUser)
+			paymentRoutes.POST("/transactions", adminOnly, idempotent, paymentHandler.CreateTransaction)
 			paymentRoutes.GET("/user/:user_id/transactions", paymentHandler.GetTransactionsByUser)
 		}
+
+		ledgerRoutes := api.Group("/ledger", authenticated)
+		{
+			ledgerRoutes.GET("/users/:user_id/statement", ledgerHandler.GetStatement)
+			ledgerRoutes.GET("/reconcile", adminOnly, ledgerHandler.Reconcile)
+		}
+
+		auditRoutes := api.Group("/audit", authenticated, adminOnly)
+		{
+			auditRoutes.GET("", auditHandler.ListEvents)
+			auditRoutes.GET("/verify", auditHandler.Verify)
+		}
+
+		reservationRoutes := api.Group("/reservations", authenticated)
+		{
+			reservationRoutes.POST("", reservationHandler.CreateReservation)
+			reservationRoutes.GET("", reservationHandler.ListReservations)
+			reservationRoutes.GET("/:id", reservationHandler.GetReservation)
+			reservationRoutes.DELETE("/:id", reservationHandler.CancelReservation)
+			reservationRoutes.POST("/:id/order", idempotent, reservationHandler.ConvertToOrder)
+		}
+
+		testDriveRoutes := api.Group("/test-drives", authenticated)
+		{
+			testDriveRoutes.POST("", testDriveHandler.ScheduleTestDrive)
+			testDriveRoutes.GET("", testDriveHandler.ListTestDrives)
+			testDriveRoutes.GET("/slots", testDriveHandler.AvailableSlots)
+			testDriveRoutes.GET("/:id", testDriveHandler.GetTestDrive)
+			testDriveRoutes.PATCH("/:id", testDriveHandler.RescheduleTestDrive)
+			testDriveRoutes.PATCH("/:id/status", testDriveHandler.UpdateTestDriveStatus)
+		}
 	}
 
 	router.NoRoute(commonHandler.NotFound)
 	router.NoMethod(commonHandler.MethodNotAllowed)
 
-	return router
+	return router, nil
 }
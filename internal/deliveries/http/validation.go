@@ -0,0 +1,25 @@
+package http
+
+import (
+	"reflect"
+
+	"myproject/internal/pkg/money"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// registerValidators teaches the binding validator to check money.Money by
+// its minor units, so tags such as `binding:"required,gt=0"` keep working.
+func registerValidators() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if m, ok := field.Interface().(money.Money); ok {
+			return m.Amount
+		}
+		return nil
+	}, money.Money{})
+}
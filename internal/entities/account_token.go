@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// AccountToken is a single-use token mailed to a user, for confirming their
+// email address or resetting their password. Only its hash is stored.
+type AccountToken struct {
+	ID        int
+	UserID    int
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+var (
+	ErrInvalidAccountToken  = errors.New("token is invalid, expired or already used")
+	ErrEmailNotVerified     = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+	ErrEmailNotSent         = errors.New("email could not be sent")
+	ErrWeakPassword         = errors.New("password must be at least 8 characters long")
+)
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// AuditEvent is one entry of the append-only audit trail. Hash covers every
+// other field including PrevHash, so editing or dropping an entry breaks the
+// chain from that point on.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	ActorID    *int            `json:"actor_id,omitempty"`
+	ActorRole  string          `json:"actor_role,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int             `json:"entity_id"`
+	Changes    json.RawMessage `json:"changes"`
+	RequestID  string          `json:"request_id,omitempty"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+// AuditFilter narrows an audit query. Zero fields are ignored; results are
+// returned newest first, starting below BeforeID when it is set.
+type AuditFilter struct {
+	EntityType string
+	EntityID   int
+	ActorID    int
+	From       time.Time
+	To         time.Time
+	BeforeID   int64
+	Limit      int
+}
+
+// AuditVerification is the result of walking the audit chain. BrokenAt is
+// the first entry whose hash or link does not match.
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	LastHash string `json:"last_hash"`
+	BrokenAt *int64 `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+const (
+	AuditEntityCar         = "car"
+	AuditEntityOrder       = "order"
+	AuditEntityUser        = "user"
+	AuditEntityPayment     = "payment"
+	AuditEntityTransaction = "transaction"
+
+	AuditCarCreate       = "car.create"
+	AuditCarUpdate       = "car.update"
+	AuditCarDelete       = "car.delete"
+	AuditCarStatusChange = "car.status_change"
+	AuditCarMediaAdd     = "car.media_add"
+	AuditCarMediaDelete  = "car.media_delete"
+
+	AuditOrderCreate       = "order.create"
+	AuditOrderPayment      = "order.payment"
+	AuditOrderStatusChange = "order.status_change"
+
+	AuditUserCreate         = "user.create"
+	AuditUserUpdate         = "user.update"
+	AuditUserDelete         = "user.delete"
+	AuditUserPasswordChange = "user.password_change"
+	AuditUserPasswordReset  = "user.password_reset"
+	AuditUserUnlock         = "user.unlock"
+
+	AuditPaymentDeposit     = "payment.deposit"
+	AuditPaymentRefund      = "payment.refund"
+	AuditPaymentTransaction = "payment.transaction"
+
+	// AuditGenesisHash is the PrevHash of the first entry.
+	AuditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+var ErrInvalidAuditFilter = errors.New("invalid audit filter")
@@ -3,19 +3,48 @@ package entities
 import (
 	"errors"
 	"time"
+
+	"myproject/internal/pkg/money"
 )
 
 type Car struct {
-	ID        int       `json:"id" db:"id"`
-	Brand     string    `json:"brand" db:"brand"`
-	Model     string    `json:"model" db:"model"`
-	Year      int       `json:"year" db:"year"`
-	Price     float64   `json:"price" db:"price"`
-	Mileage   int       `json:"mileage" db:"mileage"`
-	Color     string    `json:"color" db:"color"`
-	Status    CarStatus `json:"status" db:"status"`
+	ID          int         `json:"id" db:"id"`
+	Brand       string      `json:"brand" db:"brand"`
+	Model       string      `json:"model" db:"model"`
+	Year        int         `json:"year" db:"year"`
+	Price       money.Money `json:"price" db:"price"`
+	Mileage     int         `json:"mileage" db:"mileage"`
+	Color       string      `json:"color" db:"color"`
+	Description string      `json:"description" db:"description"`
+	Status      CarStatus   `json:"status" db:"status"`
+	CarSpec
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// Media is only filled in for a single car.
+	Media []*CarMedia `json:"media,omitempty" db:"-"`
+	// Highlight is set on search results: the matched text with the query
+	// terms wrapped in <mark>. It is safe HTML; everything but the <mark>
+	// tags is escaped.
+	Highlight string `json:"highlight,omitempty" db:"-"`
+}
+
+// CarSpec is the technical specification of a car. Empty strings and zero
+// numbers mean the value is not known.
+type CarSpec struct {
+	VIN          string       `json:"vin,omitempty" db:"vin"`
+	BodyType     BodyType     `json:"body_type,omitempty" db:"body_type"`
+	FuelType     FuelType     `json:"fuel_type,omitempty" db:"fuel_type"`
+	Transmission Transmission `json:"transmission,omitempty" db:"transmission"`
+	Drivetrain   Drivetrain   `json:"drivetrain,omitempty" db:"drivetrain"`
+	// EngineVolume is in litres; electric cars have none.
+	EngineVolume float64      `json:"engine_volume,omitempty" db:"engine_volume"`
+	PowerHP      int          `json:"power_hp,omitempty" db:"power_hp"`
+	Doors        int          `json:"doors,omitempty" db:"doors"`
+	Seats        int          `json:"seats,omitempty" db:"seats"`
+	Condition    CarCondition `json:"condition" db:"condition"`
+	// Features holds optional equipment, e.g. {"sunroof": true,
+	// "airbags": 6}. Keys are snake_case; values are scalars.
+	Features map[string]any `json:"features,omitempty" db:"features"`
 }
 
 type CarStatus string
@@ -26,31 +55,129 @@ const (
 	CarStatusSold      CarStatus = "sold"
 )
 
+type BodyType string
+
+const (
+	BodySedan       BodyType = "sedan"
+	BodyHatchback   BodyType = "hatchback"
+	BodyWagon       BodyType = "wagon"
+	BodyCoupe       BodyType = "coupe"
+	BodyConvertible BodyType = "convertible"
+	BodySUV         BodyType = "suv"
+	BodyCrossover   BodyType = "crossover"
+	BodyMinivan     BodyType = "minivan"
+	BodyVan         BodyType = "van"
+	BodyPickup      BodyType = "pickup"
+)
+
+type FuelType string
+
+const (
+	FuelPetrol       FuelType = "petrol"
+	FuelDiesel       FuelType = "diesel"
+	FuelHybrid       FuelType = "hybrid"
+	FuelPluginHybrid FuelType = "plugin_hybrid"
+	FuelElectric     FuelType = "electric"
+	FuelLPG          FuelType = "lpg"
+)
+
+type Transmission string
+
+const (
+	TransmissionManual    Transmission = "manual"
+	TransmissionAutomatic Transmission = "automatic"
+	TransmissionCVT       Transmission = "cvt"
+	TransmissionDCT       Transmission = "dct"
+)
+
+type Drivetrain string
+
+const (
+	DrivetrainFWD Drivetrain = "fwd"
+	DrivetrainRWD Drivetrain = "rwd"
+	DrivetrainAWD Drivetrain = "awd"
+	Drivetrain4WD Drivetrain = "4wd"
+)
+
+type CarCondition string
+
+const (
+	ConditionNew  CarCondition = "new"
+	ConditionUsed CarCondition = "used"
+)
+
 type CarFilter struct {
-	Brand     *string  `json:"brand,omitempty"`
-	Model     *string  `json:"model,omitempty"`
-	YearFrom  *int     `json:"year_from,omitempty"`
-	YearTo    *int     `json:"year_to,omitempty"`
-	MinPrice  *float64 `json:"min_price,omitempty"`
-	MaxPrice  *float64 `json:"max_price,omitempty"`
-	Status    *string  `json:"status,omitempty"`
-	Color     *string  `json:"color,omitempty"`
-	Limit     *int     `json:"limit,omitempty"`
-	Offset    *int     `json:"offset,omitempty"`
-	SortBy    *string  `json:"sort_by,omitempty"`
-	SortOrder *string  `json:"sort_order,omitempty"`
+	Brand    *string      `json:"brand,omitempty" form:"brand"`
+	Model    *string      `json:"model,omitempty" form:"model"`
+	YearFrom *int         `json:"year_from,omitempty" form:"year_from"`
+	YearTo   *int         `json:"year_to,omitempty" form:"year_to"`
+	MinPrice *money.Money `json:"min_price,omitempty" form:"min_price"`
+	MaxPrice *money.Money `json:"max_price,omitempty" form:"max_price"`
+	Status   *string      `json:"status,omitempty" form:"status"`
+	Color    *string      `json:"color,omitempty" form:"color"`
+
+	BodyType        *string  `json:"body_type,omitempty" form:"body_type"`
+	FuelType        *string  `json:"fuel_type,omitempty" form:"fuel_type"`
+	Transmission    *string  `json:"transmission,omitempty" form:"transmission"`
+	Drivetrain      *string  `json:"drivetrain,omitempty" form:"drivetrain"`
+	Condition       *string  `json:"condition,omitempty" form:"condition"`
+	MinEngineVolume *float64 `json:"min_engine_volume,omitempty" form:"min_engine_volume"`
+	MaxEngineVolume *float64 `json:"max_engine_volume,omitempty" form:"max_engine_volume"`
+	MinPower        *int     `json:"min_power,omitempty" form:"min_power"`
+	MaxPower        *int     `json:"max_power,omitempty" form:"max_power"`
+	MinSeats        *int     `json:"min_seats,omitempty" form:"min_seats"`
+	Doors           *int     `json:"doors,omitempty" form:"doors"`
+	VIN             *string  `json:"vin,omitempty" form:"vin"`
+	// Features lists equipment the car must have, i.e. keys present in
+	// CarSpec.Features.
+	Features []string `json:"features,omitempty" form:"feature"`
+
+	// Query is free text matched against brand, model, year, color and
+	// description, tolerating typos. Results are ranked by relevance unless
+	// the page asks for another sort.
+	Query *string     `json:"q,omitempty" form:"q"`
+	Page  PageRequest `json:"-" form:"-"`
 }
 
 type CarUpdate struct {
-	Brand   *string    `json:"brand,omitempty"`
-	Model   *string    `json:"model,omitempty"`
-	Year    *int       `json:"year,omitempty"`
-	Price   *float64   `json:"price,omitempty"`
-	Mileage *int       `json:"mileage,omitempty"`
-	Color   *string    `json:"color,omitempty"`
-	Status  *CarStatus `json:"status,omitempty"`
+	Brand       *string      `json:"brand,omitempty"`
+	Model       *string      `json:"model,omitempty"`
+	Year        *int         `json:"year,omitempty"`
+	Price       *money.Money `json:"price,omitempty"`
+	Mileage     *int         `json:"mileage,omitempty"`
+	Color       *string      `json:"color,omitempty"`
+	Description *string      `json:"description,omitempty"`
+	Status      *CarStatus   `json:"status,omitempty"`
+
+	VIN          *string       `json:"vin,omitempty"`
+	BodyType     *BodyType     `json:"body_type,omitempty"`
+	FuelType     *FuelType     `json:"fuel_type,omitempty"`
+	Transmission *Transmission `json:"transmission,omitempty"`
+	Drivetrain   *Drivetrain   `json:"drivetrain,omitempty"`
+	EngineVolume *float64      `json:"engine_volume,omitempty"`
+	PowerHP      *int          `json:"power_hp,omitempty"`
+	Doors        *int          `json:"doors,omitempty"`
+	Seats        *int          `json:"seats,omitempty"`
+	Condition    *CarCondition `json:"condition,omitempty"`
+	// Features replaces the whole feature set.
+	Features *map[string]any `json:"features,omitempty"`
+}
+
+// CarSuggestion is an autocomplete entry: a brand, or a brand and model.
+type CarSuggestion struct {
+	Text  string `json:"text"`
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
 }
 
+const (
+	CarSuggestionBrand = "brand"
+	CarSuggestionModel = "model"
+)
+
 var (
-	ErrNotFound = errors.New("car not found")
+	ErrNotFound   = errors.New("car not found")
+	ErrInvalidCar = errors.New("invalid car")
+	ErrInvalidVIN = errors.New("invalid vin")
+	ErrVINTaken   = errors.New("another car already has this vin")
 )
@@ -0,0 +1,29 @@
+package entities
+
+const (
+	FacetTerms     = "terms"
+	FacetRange     = "range"
+	FacetHistogram = "histogram"
+)
+
+// FacetSummary counts the rows matching a filter, broken down per facet.
+type FacetSummary struct {
+	Total  int     `json:"total"`
+	Facets []Facet `json:"facets"`
+}
+
+type Facet struct {
+	Name    string        `json:"name"`
+	Type    string        `json:"type"`
+	Buckets []FacetBucket `json:"buckets"`
+}
+
+// FacetBucket is one value of a terms facet, or one interval of a range or
+// histogram facet. From is inclusive and To exclusive; an empty bound is
+// open.
+type FacetBucket struct {
+	Value string `json:"value"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// IdempotencyRecord remembers the outcome of a request sent with an
+// Idempotency-Key header so retries can be answered without re-executing it.
+type IdempotencyRecord struct {
+	Key            string
+	UserID         int
+	Fingerprint    string
+	Status         string
+	ResponseStatus int
+	ResponseBody   []byte
+	ContentType    string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+var (
+	ErrIdempotencyKeyNotFound   = errors.New("idempotency key not found")
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key was used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is in progress")
+)
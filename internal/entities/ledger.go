@@ -0,0 +1,111 @@
+package entities
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"myproject/internal/pkg/money"
+)
+
+type AccountType string
+
+// Account types follow the usual normal-balance rules: assets increase with
+// debits, liabilities, equity and revenue increase with credits.
+const (
+	AccountTypeAsset     AccountType = "asset"
+	AccountTypeLiability AccountType = "liability"
+	AccountTypeEquity    AccountType = "equity"
+	AccountTypeRevenue   AccountType = "revenue"
+)
+
+// System account codes. Customer wallets use WalletAccountCode.
+const (
+	AccountCodeCash           = "cash"
+	AccountCodeDepositsHeld   = "deposits_held"
+	AccountCodeRevenue        = "dealership_revenue"
+	AccountCodeRefunds        = "refunds"
+	AccountCodeOpeningBalance = "opening_balances"
+)
+
+// Account.Balance is the running sum of its postings (debits minus credits).
+type Account struct {
+	ID        int         `json:"id"`
+	Code      string      `json:"code"`
+	Type      AccountType `json:"type"`
+	UserID    *int        `json:"user_id,omitempty"`
+	Name      string      `json:"name"`
+	Balance   money.Money `json:"balance"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+const (
+	JournalEntryDeposit         = "deposit"
+	JournalEntryDepositRefund   = "deposit_refund"
+	JournalEntryOrderPayment    = "order_payment"
+	JournalEntryOrderRefund     = "order_refund"
+	JournalEntryOrderCompletion = "order_completion"
+	JournalEntryOpeningBalance  = "opening_balance"
+)
+
+// JournalEntry is immutable once posted; corrections are made with new entries.
+type JournalEntry struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Postings    []Posting `json:"postings"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Posting amounts are signed: debits are positive and credits negative, so the
+// postings of a balanced entry sum to zero.
+type Posting struct {
+	ID        int         `json:"id"`
+	EntryID   int         `json:"entry_id"`
+	AccountID int         `json:"account_id"`
+	Amount    money.Money `json:"amount"`
+}
+
+type StatementLine struct {
+	EntryID     int         `json:"entry_id"`
+	Type        string      `json:"type"`
+	Reference   string      `json:"reference"`
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
+	Balance     money.Money `json:"balance"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Statement lists wallet movements from the customer's point of view: credits
+// to the wallet are positive amounts.
+type Statement struct {
+	UserID         int             `json:"user_id"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	OpeningBalance money.Money     `json:"opening_balance"`
+	ClosingBalance money.Money     `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+}
+
+// BalanceDrift reports an account whose cached balance disagrees with its
+// postings. Wallet balances are shown from the customer's point of view;
+// system account balances are debits minus credits.
+type BalanceDrift struct {
+	AccountID     int         `json:"account_id"`
+	Code          string      `json:"code"`
+	UserID        int         `json:"user_id,omitempty"`
+	CachedBalance money.Money `json:"cached_balance"`
+	LedgerBalance money.Money `json:"ledger_balance"`
+}
+
+var (
+	ErrAccountNotFound  = errors.New("ledger account not found")
+	ErrUnbalancedEntry  = errors.New("journal entry is not balanced")
+	ErrInvalidPosting   = errors.New("invalid posting")
+	ErrInvalidDateRange = errors.New("invalid date range")
+)
+
+func WalletAccountCode(userID int) string {
+	return "wallet:" + strconv.Itoa(userID)
+}
@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LoginThrottle counts failed sign-ins for one account (keyed by normalized
+// email, registered or not) or one client IP. Failures older than the policy
+// window are forgotten; Lockouts grows with every lockout so repeated ones
+// last longer.
+type LoginThrottle struct {
+	Scope          string
+	Subject        string
+	Failures       int
+	Lockouts       int
+	FirstFailureAt time.Time
+	LastFailureAt  time.Time
+	LockedUntil    *time.Time
+}
+
+// LockoutEvent records an account or IP being locked, or unlocked by an admin.
+type LockoutEvent struct {
+	ID          int        `json:"id"`
+	Event       string     `json:"event"`
+	Scope       string     `json:"scope"`
+	Subject     string     `json:"subject"`
+	UserID      *int       `json:"user_id,omitempty"`
+	IPAddress   string     `json:"ip_address,omitempty"`
+	Failures    int        `json:"failures"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	ActorID     *int       `json:"actor_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+const (
+	LockoutScopeAccount = "account"
+	LockoutScopeIP      = "ip"
+
+	LockoutEventLocked   = "locked"
+	LockoutEventUnlocked = "unlocked"
+)
+
+var ErrTooManyLoginAttempts = errors.New("too many failed sign-in attempts")
+
+// LoginThrottledError is returned while sign-in is delayed or locked; it
+// matches ErrTooManyLoginAttempts.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrTooManyLoginAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+type MediaKind string
+
+const (
+	MediaPhoto    MediaKind = "photo"
+	MediaDocument MediaKind = "document"
+)
+
+// CarMedia is a photo or document attached to a car. The file itself lives in
+// blob storage; URL and Thumbnails are signed, expiring links filled in when
+// the media is returned to a client.
+type CarMedia struct {
+	ID          int       `json:"id"`
+	CarID       int       `json:"car_id"`
+	Kind        MediaKind `json:"kind"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width,omitempty"`
+	Height      int       `json:"height,omitempty"`
+	Position    int       `json:"position"`
+	IsPrimary   bool      `json:"is_primary"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	URL        string            `json:"url,omitempty"`
+	Thumbnails map[string]string `json:"thumbnails,omitempty"`
+	ExpiresAt  *time.Time        `json:"urls_expire_at,omitempty"`
+
+	StorageKey string `json:"-"`
+	// ThumbnailKeys maps a thumbnail size name to its storage key.
+	ThumbnailKeys map[string]string `json:"-"`
+}
+
+// MediaUpload is one uploaded file before it is stored.
+type MediaUpload struct {
+	Filename string
+	Body     io.Reader
+	Primary  bool
+}
+
+var (
+	ErrMediaNotFound     = errors.New("media not found")
+	ErrMediaTooLarge     = errors.New("file is too large")
+	ErrMediaUnsupported  = errors.New("unsupported file type")
+	ErrMediaLimit        = errors.New("car has too many media files")
+	ErrMediaNotPhoto     = errors.New("only photos can be the primary photo")
+	ErrInvalidMediaOrder = errors.New("order must list every media file of the car once")
+)
@@ -0,0 +1,75 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// MFAFactor is a user's TOTP authenticator. It is pending until the user
+// confirms it with a first code. LastUsedStep is the time step of the last
+// accepted code, so a code cannot be used twice.
+type MFAFactor struct {
+	UserID       int
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// MFAChallenge is the second step of a sign-in. The password was correct;
+// the user now has to give a TOTP or recovery code, or, when their role
+// requires 2FA and they have none yet, enroll an authenticator.
+type MFAChallenge struct {
+	TokenHash string
+	UserID    int
+	Purpose   string
+	Attempts  int
+	UserAgent string
+	IPAddress string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// MFAChallengeToken is what the client receives instead of a session.
+type MFAChallengeToken struct {
+	Token     string    `json:"challenge_token"`
+	Purpose   string    `json:"challenge_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SignIn is the outcome of a password check: either a session or, for users
+// with 2FA, a challenge to complete first. RecoveryCodes is set only when
+// the sign-in enrolled a new authenticator.
+type SignIn struct {
+	Tokens        *TokenPair
+	User          *User
+	Challenge     *MFAChallengeToken
+	RecoveryCodes []string
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"`
+	ConfirmedAt            *time.Time `json:"confirmed_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+const (
+	MFAChallengeVerify = "verify"
+	MFAChallengeEnroll = "enroll"
+)
+
+var (
+	ErrMFANotEnrolled       = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnrolled   = errors.New("two-factor authentication is already enabled")
+	ErrMFARequired          = errors.New("two-factor authentication is required for this role")
+	ErrInvalidMFACode       = errors.New("invalid authentication code")
+	ErrInvalidMFAChallenge  = errors.New("sign-in challenge is invalid or expired")
+	ErrMFAChallengeMismatch = errors.New("sign-in challenge does not allow this step")
+)
@@ -3,17 +3,24 @@ package entities
 import (
 	"errors"
 	"time"
+
+	"myproject/internal/pkg/money"
 )
 
+// Order.Deposit is the amount paid when the order was placed; PaidAmount
+// includes it together with every later payment, and Outstanding is what is
+// still due.
 type Order struct {
-	ID         int       `json:"id"`
-	UserID     int       `json:"user_id"`
-	CarID      int       `json:"car_id"`
-	Status     string    `json:"status"`
-	Deposit    float64   `json:"deposit"`
-	TotalPrice float64   `json:"total_price"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          int         `json:"id"`
+	UserID      int         `json:"user_id"`
+	CarID       int         `json:"car_id"`
+	Status      string      `json:"status"`
+	Deposit     money.Money `json:"deposit"`
+	TotalPrice  money.Money `json:"total_price"`
+	PaidAmount  money.Money `json:"paid_amount"`
+	Outstanding money.Money `json:"outstanding"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
 }
 
 const (
@@ -26,9 +33,26 @@ const (
 	OrderStatusConfirmed = "confirmed"
 )
 
+// OrderStatusChange is one row of an order's status history. FromStatus is
+// empty for the entry written when the order is placed.
+type OrderStatusChange struct {
+	ID         int       `json:"id"`
+	OrderID    int       `json:"order_id"`
+	FromStatus string    `json:"from_status,omitempty"`
+	ToStatus   string    `json:"to_status"`
+	ActorID    *int      `json:"actor_id,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 var (
 	ErrOrderNotFound      = errors.New("order not found")
 	ErrInvalidOrderData   = errors.New("invalid order data")
 	ErrOrderAlreadyClosed = errors.New("order is already completed or cancelled")
 	ErrInvalidStatus      = errors.New("invalid order status")
+	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrInvalidTransition  = errors.New("order status transition is not allowed")
+	ErrOrderNotFullyPaid  = errors.New("order is not fully paid")
+	ErrDepositTooLow      = errors.New("deposit is below the minimum for this price")
+	ErrOverpayment        = errors.New("payment exceeds the outstanding amount")
 )
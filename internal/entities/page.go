@@ -0,0 +1,28 @@
+package entities
+
+import "errors"
+
+// PageRequest selects one page of a list. Sort is a comma-separated list of
+// fields, each optionally prefixed with "-" for descending order. Cursor
+// continues after the last item of a previous page and cannot be combined
+// with Offset.
+type PageRequest struct {
+	Sort   string
+	Cursor string
+	Limit  int
+	Offset int
+}
+
+// PageInfo describes the page returned for a PageRequest. NextCursor is empty
+// on the last page.
+type PageInfo struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidPage   = errors.New("invalid page")
+)
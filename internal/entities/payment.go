@@ -1,23 +1,50 @@
 package entities
 
-import "time"
+import (
+	"errors"
+	"time"
 
+	"myproject/internal/pkg/money"
+)
+
+// Payment is a card charge made through a payment gateway. It starts pending
+// and moves once to succeeded or failed; succeeded payments may be refunded.
 type Payment struct {
-	ID            int       `json:"id"`
-	UserID        int       `json:"user_id"`
-	Amount        float64   `json:"amount"`
-	PaymentMethod string    `json:"payment_method"`
-	Status        string    `json:"status"`
-	TransactionID int       `json:"transaction_id"`
-	ProviderID    string    `json:"provider_id"`
+	ID            int         `json:"id"`
+	UserID        int         `json:"user_id"`
+	Amount        money.Money `json:"amount"`
+	PaymentMethod string      `json:"payment_method"`
+	Status        string      `json:"status"`
+	Provider      string      `json:"provider"`
+	ProviderID    string      `json:"provider_id,omitempty"`
+	FailureReason string      `json:"failure_reason,omitempty"`
+	// TransactionID is the ledger journal entry that credited the wallet.
+	TransactionID *int      `json:"transaction_id,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusSucceeded = "succeeded"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusRefunded  = "refunded"
+)
+
+var (
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrPaymentDeclined      = errors.New("payment declined")
+	ErrPaymentStateConflict = errors.New("payment is not in a valid state for this operation")
+	// ErrPaymentProvider wraps failures of the payment gateway itself, as
+	// opposed to invalid requests or local errors.
+	ErrPaymentProvider = errors.New("payment provider error")
+)
+
 type Transaction struct {
-	ID          int       `json:"id"`
-	UserID      int       `json:"user_id"`
-	Amount      float64   `json:"amount"`
-	Type        string    `json:"type"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int         `json:"id"`
+	UserID      int         `json:"user_id"`
+	Amount      money.Money `json:"amount"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	CreatedAt   time.Time   `json:"created_at"`
 }
@@ -1,12 +1,30 @@
 package entities
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type Reservation struct {
 	ID        int       `json:"id"`
 	UserID    int       `json:"user_id"`
 	CarID     int       `json:"car_id"`
+	OrderID   *int      `json:"order_id,omitempty"`
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
+
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusExpired   = "expired"
+	ReservationStatusCancelled = "cancelled"
+	ReservationStatusConverted = "converted"
+)
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationNotActive = errors.New("reservation is not active")
+	ErrCarNotAvailable      = errors.New("car is not available")
+)
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// Session is one login on one device. Its refresh token rotates on every use;
+// only the hash of the current token is stored.
+type Session struct {
+	ID               string     `json:"id"`
+	UserID           int        `json:"user_id"`
+	RefreshTokenHash string     `json:"-"`
+	Generation       int        `json:"generation"`
+	UserAgent        string     `json:"user_agent"`
+	IPAddress        string     `json:"ip_address"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastUsedAt       time.Time  `json:"last_used_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason     string     `json:"revoke_reason,omitempty"`
+}
+
+// SessionMeta describes the client a session was started from.
+type SessionMeta struct {
+	UserAgent string
+	IPAddress string
+}
+
+type TokenPair struct {
+	SessionID        string    `json:"session_id"`
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedLogoutAll      = "logout_all"
+	SessionRevokedTokenReuse     = "refresh_token_reuse"
+	SessionRevokedPasswordChange = "password_change"
+	SessionRevokedPasswordReset  = "password_reset"
+	SessionRevokedRoleChange     = "role_change"
+)
+
+var (
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrSessionExpired      = errors.New("session has expired")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+)
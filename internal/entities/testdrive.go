@@ -1,13 +1,41 @@
 package entities
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type TestDrive struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	CarID     int       `json:"car_id"`
-	Date      time.Time `json:"date"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	CarID         int       `json:"car_id"`
+	SalespersonID *int      `json:"salesperson_id,omitempty"`
+	Date          time.Time `json:"date"`
+	EndsAt        time.Time `json:"ends_at"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
+
+type TestDriveSlot struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+const (
+	TestDriveStatusScheduled = "scheduled"
+	TestDriveStatusCompleted = "completed"
+	TestDriveStatusCancelled = "cancelled"
+	TestDriveStatusNoShow    = "no_show"
+)
+
+var (
+	ErrTestDriveNotFound      = errors.New("test drive not found")
+	ErrTestDriveConflict      = errors.New("test drive slot is already taken")
+	ErrTestDriveOutsideHours  = errors.New("test drive is outside showroom opening hours")
+	ErrTestDriveNotScheduled  = errors.New("test drive is not scheduled")
+	ErrTestDriveInPast        = errors.New("test drive cannot be scheduled in the past")
+	ErrInvalidTestDriveStatus = errors.New("invalid test drive status")
+	ErrInvalidSalesperson     = errors.New("salesperson must be a staff member")
+	ErrInvalidTestDriveDate   = errors.New("date must be in YYYY-MM-DD format")
+)
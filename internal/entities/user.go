@@ -3,6 +3,8 @@ package entities
 import (
 	"errors"
 	"time"
+
+	"myproject/internal/pkg/money"
 )
 
 var (
@@ -11,15 +13,24 @@ var (
 )
 
 type User struct {
-	ID           int       `json:"id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Password     string    `json:"password"`
-	Balance      float64   `json:"balance"`
-	Role         string    `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int         `json:"id"`
+	Name         string      `json:"name"`
+	Email        string      `json:"email"`
+	PasswordHash string      `json:"-"`
+	Password     string      `json:"password"`
+	Balance      money.Money `json:"balance"`
+	Role         string      `json:"role"`
+	// EmailVerifiedAt is nil until the user opens the link from the
+	// verification email.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
+const (
+	RoleCustomer = "customer"
+	RoleManager  = "manager"
+	RoleAdmin    = "admin"
+)
+
 var ErrNotFoundund = errors.New("user not found")
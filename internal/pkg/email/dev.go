@@ -0,0 +1,56 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"myproject/pkg/logger"
+)
+
+// DevSender never talks to a mail server. It logs every message and, when a
+// directory is configured, stores it there as an .eml file that any mail
+// client can open, so links in the mail can be followed locally.
+type DevSender struct {
+	dir    string
+	from   string
+	logger logger.Interface
+}
+
+func NewDevSender(dir, from string, logger logger.Interface) (*DevSender, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create mail directory: %w", err)
+		}
+	}
+	return &DevSender{dir: dir, from: from, logger: logger}, nil
+}
+
+func (s *DevSender) SendEmail(ctx context.Context, msg Message) error {
+	now := time.Now()
+	if s.dir == "" {
+		s.logger.Info("email: message not delivered (dev sender)", "to", msg.To, "subject", msg.Subject, "body", msg.Text)
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), sanitize(msg.To))
+	path := filepath.Join(s.dir, name)
+	if err := os.WriteFile(path, compose(s.from, msg, now), 0o644); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	s.logger.Info("email: message written (dev sender)", "to", msg.To, "subject", msg.Subject, "file", path)
+	return nil
+}
+
+func sanitize(address string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, address)
+}
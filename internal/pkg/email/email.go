@@ -1,5 +1,24 @@
+// Package email sends transactional mail. Messages are rendered from the
+// embedded templates and handed to a Sender: SMTP in production, the dev
+// sender everywhere else.
 package email
 
+import (
+	"context"
+	"errors"
+)
+
+var ErrUnknownProvider = errors.New("unknown email provider")
+
+// Message is one email with a plain text body and an optional HTML
+// alternative.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
 type Sender interface {
-	SendEmail(to, subject, body string) error
+	SendEmail(ctx context.Context, msg Message) error
 }
@@ -0,0 +1,73 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"time"
+)
+
+// compose renders msg as an RFC 5322 message: text/plain alone, or
+// multipart/alternative when there is an HTML body too.
+func compose(from string, msg Message, now time.Time) []byte {
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", from)
+	writeHeader(&buf, "To", msg.To)
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		writeHeader(&buf, "Content-Type", `text/plain; charset="utf-8"`)
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		writeQuotedPrintable(&buf, msg.Text)
+		return buf.Bytes()
+	}
+
+	boundary := newBoundary()
+	writeHeader(&buf, "Content-Type", fmt.Sprintf(`multipart/alternative; boundary="%s"`, boundary))
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		writeHeader(&buf, "Content-Type", part.contentType+`; charset="utf-8"`)
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		writeQuotedPrintable(&buf, part.body)
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes()
+}
+
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	fmt.Fprintf(buf, "%s: %s\r\n", name, value)
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, body string) {
+	w := quotedprintable.NewWriter(buf)
+	w.Write([]byte(body))
+	w.Close()
+}
+
+func newBoundary() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// envelopeAddress strips the display name from an address for SMTP commands.
+func envelopeAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid email address %q: %w", address, err)
+	}
+	return parsed.Address, nil
+}
@@ -0,0 +1,98 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+const (
+	TemplateVerifyEmail     = "verify_email"
+	TemplatePasswordReset   = "password_reset"
+	TemplatePasswordChanged = "password_changed"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Every template has a <name>.txt file that defines a "subject" block and the
+// plain text body, and may have a <name>.html alternative.
+type templateSet struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Mailer renders a named template and passes the result to a Sender.
+type Mailer struct {
+	sender    Sender
+	templates map[string]templateSet
+}
+
+func NewMailer(sender Sender) (*Mailer, error) {
+	templates := make(map[string]templateSet)
+	for _, name := range []string{TemplateVerifyEmail, TemplatePasswordReset, TemplatePasswordChanged} {
+		set, err := loadTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = set
+	}
+	return &Mailer{sender: sender, templates: templates}, nil
+}
+
+func loadTemplate(name string) (templateSet, error) {
+	var set templateSet
+	text, err := texttemplate.ParseFS(templateFS, "templates/"+name+".txt")
+	if err != nil {
+		return set, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	if text.Lookup("subject") == nil {
+		return set, fmt.Errorf("template %s has no subject", name)
+	}
+	set.text = text
+
+	if _, err := templateFS.Open("templates/" + name + ".html"); err == nil {
+		if set.html, err = htmltemplate.ParseFS(templateFS, "templates/"+name+".html"); err != nil {
+			return set, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+	}
+	return set, nil
+}
+
+func (m *Mailer) Send(ctx context.Context, to, template string, data any) error {
+	msg, err := m.Render(to, template, data)
+	if err != nil {
+		return err
+	}
+	return m.sender.SendEmail(ctx, msg)
+}
+
+func (m *Mailer) Render(to, template string, data any) (Message, error) {
+	set, ok := m.templates[template]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", template)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := set.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", template, err)
+	}
+	if err := set.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s: %w", template, err)
+	}
+	if set.html != nil {
+		if err := set.html.Execute(&html, data); err != nil {
+			return Message{}, fmt.Errorf("failed to render %s: %w", template, err)
+		}
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,74 @@
+// Package gateway defines the contract between the payment service and card
+// payment providers, plus webhook signing shared by all providers.
+package gateway
+
+import (
+	"context"
+	"errors"
+
+	"myproject/internal/pkg/money"
+)
+
+type ChargeStatus string
+
+const (
+	// ChargeAuthorized means the funds are reserved and must be captured.
+	ChargeAuthorized ChargeStatus = "authorized"
+	ChargePending    ChargeStatus = "pending"
+	ChargeSucceeded  ChargeStatus = "succeeded"
+	ChargeFailed     ChargeStatus = "failed"
+	ChargeRefunded   ChargeStatus = "refunded"
+)
+
+var (
+	ErrDeclined         = errors.New("payment declined")
+	ErrTimeout          = errors.New("payment provider timed out")
+	ErrChargeNotFound   = errors.New("charge not found")
+	ErrInvalidState     = errors.New("charge is not in a valid state for this operation")
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+type ChargeRequest struct {
+	Amount money.Money
+	// Token is the card token produced by the provider's client-side SDK.
+	Token string
+	// Reference is echoed back in webhooks so charges can be matched to
+	// payments. It doubles as an idempotency key: repeating CreateCharge with
+	// the same reference returns the original charge instead of charging twice.
+	Reference string
+}
+
+type Charge struct {
+	ID            string       `json:"id"`
+	Status        ChargeStatus `json:"status"`
+	Amount        money.Money  `json:"amount"`
+	Reference     string       `json:"reference"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+}
+
+// PaymentGateway is implemented by every card payment provider.
+// CreateCharge returns ErrDeclined together with the failed charge when the
+// card is declined, and ErrTimeout when the outcome is unknown. GetStatus
+// accepts a charge ID or a reference, so charges whose creation timed out can
+// still be looked up.
+type PaymentGateway interface {
+	Name() string
+	CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	Capture(ctx context.Context, chargeID string) (*Charge, error)
+	Refund(ctx context.Context, chargeID string, amount money.Money) (*Charge, error)
+	GetStatus(ctx context.Context, chargeID string) (*Charge, error)
+}
+
+const (
+	EventChargeSucceeded = "charge.succeeded"
+	EventChargeFailed    = "charge.failed"
+	EventChargeRefunded  = "charge.refunded"
+)
+
+// Event is the webhook payload providers send when a charge changes state.
+type Event struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Charge Charge `json:"charge"`
+}
@@ -0,0 +1,191 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"myproject/internal/pkg/money"
+)
+
+type MockMode string
+
+const (
+	MockSucceed MockMode = "succeed"
+	MockDecline MockMode = "decline"
+	// MockTimeout charges the card but reports ErrTimeout, as if the response
+	// was lost; the outcome is then visible through GetStatus and webhooks.
+	MockTimeout MockMode = "timeout"
+)
+
+// Card tokens that force an outcome regardless of the configured mode.
+const (
+	MockTokenSucceed = "tok_succeed"
+	MockTokenDecline = "tok_decline"
+	MockTokenTimeout = "tok_timeout"
+)
+
+// Mock is an in-memory provider for development and demos.
+type Mock struct {
+	mode    MockMode
+	latency time.Duration
+
+	mu          sync.Mutex
+	charges     map[string]*Charge
+	byReference map[string]string
+}
+
+func NewMock(mode string, latency time.Duration) (*Mock, error) {
+	switch MockMode(mode) {
+	case MockSucceed, MockDecline, MockTimeout:
+	case "":
+		mode = string(MockSucceed)
+	default:
+		return nil, fmt.Errorf("unknown mock gateway mode %q", mode)
+	}
+	return &Mock{
+		mode:        MockMode(mode),
+		latency:     latency,
+		charges:     make(map[string]*Charge),
+		byReference: make(map[string]string),
+	}, nil
+}
+
+func (m *Mock) Name() string {
+	return "mock"
+}
+
+func (m *Mock) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id, ok := m.byReference[req.Reference]; ok && req.Reference != "" {
+		existing := copyCharge(m.charges[id])
+		if existing.Status == ChargeFailed {
+			return existing, ErrDeclined
+		}
+		return existing, nil
+	}
+
+	charge := &Charge{
+		ID:        "ch_mock_" + randomHex(12),
+		Status:    ChargeAuthorized,
+		Amount:    req.Amount,
+		Reference: req.Reference,
+	}
+
+	mode := m.mode
+	switch req.Token {
+	case MockTokenSucceed:
+		mode = MockSucceed
+	case MockTokenDecline:
+		mode = MockDecline
+	case MockTokenTimeout:
+		mode = MockTimeout
+	}
+
+	m.charges[charge.ID] = charge
+	if req.Reference != "" {
+		m.byReference[req.Reference] = charge.ID
+	}
+	switch mode {
+	case MockDecline:
+		charge.Status = ChargeFailed
+		charge.FailureReason = "card_declined"
+		return copyCharge(charge), ErrDeclined
+	case MockTimeout:
+		charge.Status = ChargeSucceeded
+		return nil, ErrTimeout
+	default:
+		return copyCharge(charge), nil
+	}
+}
+
+func (m *Mock) Capture(ctx context.Context, chargeID string) (*Charge, error) {
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	charge, ok := m.charges[chargeID]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	switch charge.Status {
+	case ChargeAuthorized:
+		charge.Status = ChargeSucceeded
+	case ChargeSucceeded:
+	default:
+		return nil, ErrInvalidState
+	}
+	return copyCharge(charge), nil
+}
+
+func (m *Mock) Refund(ctx context.Context, chargeID string, amount money.Money) (*Charge, error) {
+	if err := m.wait(ctx); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	charge, ok := m.charges[chargeID]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	if charge.Status != ChargeSucceeded {
+		return nil, ErrInvalidState
+	}
+	if cmp, err := amount.Cmp(charge.Amount); err != nil || cmp != 0 {
+		return nil, fmt.Errorf("%w: only full refunds are supported", ErrInvalidState)
+	}
+	charge.Status = ChargeRefunded
+	return copyCharge(charge), nil
+}
+
+func (m *Mock) GetStatus(ctx context.Context, chargeID string) (*Charge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	charge, ok := m.charges[chargeID]
+	if !ok {
+		id, found := m.byReference[chargeID]
+		if !found {
+			return nil, ErrChargeNotFound
+		}
+		charge = m.charges[id]
+	}
+	return copyCharge(charge), nil
+}
+
+func (m *Mock) wait(ctx context.Context) error {
+	if m.latency <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(m.latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ErrTimeout
+	}
+}
+
+func copyCharge(charge *Charge) *Charge {
+	c := *charge
+	return &c
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
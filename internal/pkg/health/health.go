@@ -0,0 +1,185 @@
+// Package health runs the dependency checks behind the liveness and
+// readiness probes.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusFailing     = "failing"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+
+	defaultTimeout = 2 * time.Second
+)
+
+// Check is a single named dependency check.
+type Check struct {
+	Name string
+	// Timeout bounds one run; zero uses the registry default.
+	Timeout time.Duration
+	// CacheFor reuses the last result for this long so frequent probes do
+	// not hammer the dependency.
+	CacheFor time.Duration
+	// Live makes the check part of liveness as well as readiness. Only
+	// failures a restart would fix, such as a stuck worker, belong there.
+	Live bool
+	Func func(ctx context.Context) error
+}
+
+// Result is the outcome of one check run.
+type Result struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  float64   `json:"duration_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached,omitempty"`
+}
+
+// Report is the combined result of the checks behind a probe.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Healthy reports whether the probe should succeed.
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+type entry struct {
+	check Check
+
+	// mu serialises runs so concurrent probes share one result instead of
+	// each hitting the dependency.
+	mu   sync.Mutex
+	last Result
+}
+
+type Registry struct {
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	entries  map[string]*entry
+	draining atomic.Bool
+}
+
+// NewRegistry returns a registry whose checks time out after timeout unless
+// they set their own.
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Registry{timeout: timeout, entries: make(map[string]*entry)}
+}
+
+// Register adds a check. It panics on an empty or duplicate name; checks are
+// registered once at startup, so that is a programming error.
+func (r *Registry) Register(check Check) {
+	if check.Name == "" || check.Func == nil {
+		panic("health: check needs a name and a func")
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = r.timeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[check.Name]; ok {
+		panic(fmt.Sprintf("health: check %s registered twice", check.Name))
+	}
+	r.entries[check.Name] = &entry{check: check}
+}
+
+// SetDraining marks the process as shutting down. Readiness fails from then
+// on so load balancers stop sending traffic before the server closes.
+func (r *Registry) SetDraining() {
+	r.draining.Store(true)
+}
+
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Liveness runs the checks marked Live.
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, func(c Check) bool { return c.Live })
+}
+
+// Readiness runs every check, or none while draining.
+func (r *Registry) Readiness(ctx context.Context) Report {
+	if r.Draining() {
+		return Report{Status: StatusDraining}
+	}
+	return r.run(ctx, func(Check) bool { return true })
+}
+
+func (r *Registry) run(ctx context.Context, include func(Check) bool) Report {
+	r.mu.RLock()
+	entries := make([]*entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		if include(e.check) {
+			entries = append(entries, e)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].check.Name < entries[j].check.Name })
+
+	results := make([]Result, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = e.result(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(entries))}
+	for i, e := range entries {
+		report.Checks[e.check.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+func (e *entry) result(ctx context.Context) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.last.CheckedAt.IsZero() && time.Since(e.last.CheckedAt) < e.check.CacheFor {
+		cached := e.last
+		cached.Cached = true
+		return cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, e.check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := e.check.Func(checkCtx)
+	result := Result{
+		Status:    StatusOK,
+		Duration:  float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	// A probe that gave up early says nothing about the dependency.
+	if ctx.Err() == nil {
+		e.last = result
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Heartbeat tracks a background loop. Its check fails once the loop has not
+// completed a run within maxAge, which means it is stuck or has exited.
+type Heartbeat struct {
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	last    time.Time
+	lastErr error
+}
+
+// NewHeartbeat starts the clock now, so a loop has maxAge to finish its
+// first run.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	return &Heartbeat{maxAge: maxAge, last: time.Now()}
+}
+
+// Beat records a finished run and its error, if any.
+func (h *Heartbeat) Beat(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = time.Now()
+	h.lastErr = err
+}
+
+// Check fails when the last run is older than maxAge. A run that returned an
+// error still counts: the loop is alive, and the dependency it failed on has
+// a check of its own.
+func (h *Heartbeat) Check(context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if age := time.Since(h.last); age > h.maxAge {
+		if h.lastErr != nil {
+			return fmt.Errorf("no run for %s, last error: %w", age.Round(time.Second), h.lastErr)
+		}
+		return fmt.Errorf("no run for %s", age.Round(time.Second))
+	}
+	return nil
+}
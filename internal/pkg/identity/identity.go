@@ -0,0 +1,37 @@
+package identity
+
+import (
+	"context"
+
+	"myproject/internal/entities"
+)
+
+type Identity struct {
+	UserID    int
+	Role      string
+	SessionID string
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
+
+func (i Identity) IsStaff() bool {
+	return i.Role == entities.RoleManager || i.Role == entities.RoleAdmin
+}
+
+func (i Identity) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if i.Role == role {
+			return true
+		}
+	}
+	return false
+}
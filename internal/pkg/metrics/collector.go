@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Sample is one value read by a collector; Values match the collector's
+// labels in order.
+type Sample struct {
+	Values []string
+	Value  float64
+}
+
+// CollectFunc reads the current samples at scrape time.
+type CollectFunc func(ctx context.Context) ([]Sample, error)
+
+type collector struct {
+	metricName string
+	help       string
+	typ        string
+	labels     []string
+	collect    CollectFunc
+}
+
+// NewCollector registers a metric whose samples come from fn on every
+// scrape, for values owned elsewhere such as pool stats or database counts.
+// typ is TypeGauge or TypeCounter.
+func (r *Registry) NewCollector(name, help, typ string, labels []string, fn CollectFunc) {
+	if typ != TypeGauge && typ != TypeCounter {
+		panic(fmt.Sprintf("metrics: collector %s has unsupported type %q", name, typ))
+	}
+	r.register(&collector{metricName: name, help: help, typ: typ, labels: labels, collect: fn}, labels)
+}
+
+// NewGaugeFunc registers an unlabeled gauge read from fn.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.NewCollector(name, help, TypeGauge, nil, func(context.Context) ([]Sample, error) {
+		return []Sample{{Value: fn()}}, nil
+	})
+}
+
+func (c *collector) name() string { return c.metricName }
+
+func (c *collector) write(ctx context.Context, w *bufio.Writer) error {
+	samples, err := c.collect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to collect %s: %w", c.metricName, err)
+	}
+	for _, s := range samples {
+		checkValues(c.metricName, c.labels, s.Values)
+	}
+	sort.Slice(samples, func(i, j int) bool { return labelKey(samples[i].Values) < labelKey(samples[j].Values) })
+
+	writeHeader(w, c.metricName, c.help, c.typ)
+	for _, s := range samples {
+		writeSample(w, c.metricName, c.labels, s.Values, s.Value)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"sort"
+	"sync"
+)
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{metricName: name, help: help, labels: labels, series: make(map[string]*counterSeries)}
+	r.register(c, labels)
+	return c
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add increases the counter; negative deltas are ignored.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	checkValues(c.metricName, c.labels, values)
+	if delta < 0 {
+		return
+	}
+
+	key := labelKey(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.value += delta
+}
+
+func (c *CounterVec) name() string { return c.metricName }
+
+func (c *CounterVec) write(_ context.Context, w *bufio.Writer) error {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.series))
+	for k := range c.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	samples := make([]counterSeries, len(keys))
+	for i, k := range keys {
+		samples[i] = *c.series[k]
+	}
+	c.mu.Unlock()
+
+	writeHeader(w, c.metricName, c.help, TypeCounter)
+	for _, s := range samples {
+		writeSample(w, c.metricName, c.labels, s.values, s.value)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// DefBuckets suit request latencies in seconds.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec counts observations into cumulative buckets per label set.
+type HistogramVec struct {
+	metricName string
+	help       string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	for _, l := range labels {
+		if l == "le" {
+			panic(fmt.Sprintf("metrics: %s cannot use the label le", name))
+		}
+	}
+
+	h := &HistogramVec{metricName: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogramSeries)}
+	r.register(h, labels)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	checkValues(h.metricName, h.labels, values)
+
+	key := labelKey(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{values: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) name() string { return h.metricName }
+
+func (h *HistogramVec) write(_ context.Context, w *bufio.Writer) error {
+	h.mu.Lock()
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	samples := make([]histogramSeries, len(keys))
+	for i, k := range keys {
+		s := *h.series[k]
+		s.counts = append([]uint64(nil), s.counts...)
+		samples[i] = s
+	}
+	h.mu.Unlock()
+
+	writeHeader(w, h.metricName, h.help, TypeHistogram)
+	labels := append(append([]string(nil), h.labels...), "le")
+	for _, s := range samples {
+		values := append(append([]string(nil), s.values...), "")
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			values[len(values)-1] = formatFloat(upper)
+			writeSample(w, h.metricName+"_bucket", labels, values, float64(cumulative))
+		}
+		values[len(values)-1] = formatFloat(math.Inf(1))
+		writeSample(w, h.metricName+"_bucket", labels, values, float64(s.count))
+		writeSample(w, h.metricName+"_sum", h.labels, s.values, s.sum)
+		writeSample(w, h.metricName+"_count", h.labels, s.values, float64(s.count))
+	}
+	return nil
+}
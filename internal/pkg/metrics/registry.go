@@ -0,0 +1,148 @@
+// Package metrics keeps counters, histograms and gauges in memory and
+// writes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"myproject/pkg/logger"
+)
+
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// family is one metric name with all its label combinations.
+type family interface {
+	name() string
+	write(ctx context.Context, w *bufio.Writer) error
+}
+
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+// register panics on invalid or duplicate names; metrics are defined once
+// at startup, so that is a programming error.
+func (r *Registry) register(f family, labels []string) {
+	for _, n := range append([]string{f.name()}, labels...) {
+		if !namePattern.MatchString(n) {
+			panic(fmt.Sprintf("metrics: invalid name %q", n))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[f.name()]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", f.name()))
+	}
+	r.families[f.name()] = f
+}
+
+// Write renders every metric, sorted by name. Collectors run with ctx; one
+// that fails is skipped and its error returned after the others are written.
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	families := make([]family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name() < families[j].name() })
+
+	bw := bufio.NewWriter(w)
+	var firstErr error
+	for _, f := range families {
+		if err := f.write(ctx, bw); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// Handler serves the registry. Metrics whose collector failed are logged
+// and left out rather than failing the scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		if err := r.Write(req.Context(), w); err != nil {
+			logger.FromContext(req.Context()).Warn("metrics: scrape incomplete", "error", err)
+		}
+	})
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(values[i]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labelKey joins label values into a map key; \xff cannot appear in UTF-8.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func checkValues(name string, labels, values []string) {
+	if len(values) != len(labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", name, len(labels), len(values)))
+	}
+}
@@ -0,0 +1,143 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jackc/pgtype"
+)
+
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as {"amount": "1500.25", "currency": "USD"}.
+// The amount is a string so clients never round-trip it through a float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{Amount: m.Decimal(), Currency: m.currency(m)})
+}
+
+// UnmarshalJSON accepts the object form as well as a bare number or string,
+// which is interpreted in the default currency. Any other currency is
+// rejected: amounts are stored without one, so a foreign amount would be
+// charged in its own currency but read back in the default one.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	currency := DefaultCurrency()
+	raw := data
+	if len(data) > 0 && data[0] == '{' {
+		var obj jsonMoney
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		if obj.Currency != "" && !strings.EqualFold(obj.Currency, currency) {
+			return fmt.Errorf("%w: only %s is accepted, got %q", ErrCurrencyMismatch, currency, obj.Currency)
+		}
+		raw = bytes.TrimSpace(obj.Amount)
+	}
+
+	var value string
+	if len(raw) > 0 && raw[0] == '"' {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+	} else {
+		var number json.Number
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, raw)
+		}
+		value = number.String()
+	}
+
+	parsed, err := Parse(value, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// UnmarshalParam lets gin bind query and form parameters such as ?min_price=1000.
+func (m *Money) UnmarshalParam(param string) error {
+	parsed, err := Parse(param, DefaultCurrency())
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value sends the amount to the database as a decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+// EncodeText makes pgx send the amount in text format, which Postgres parses
+// into numeric without loss.
+func (m Money) EncodeText(ci *pgtype.ConnInfo, buf []byte) ([]byte, error) {
+	return append(buf, m.Decimal()...), nil
+}
+
+func (m *Money) DecodeText(ci *pgtype.ConnInfo, src []byte) error {
+	var n pgtype.Numeric
+	if err := n.DecodeText(ci, src); err != nil {
+		return err
+	}
+	return m.setNumeric(n)
+}
+
+func (m *Money) DecodeBinary(ci *pgtype.ConnInfo, src []byte) error {
+	var n pgtype.Numeric
+	if err := n.DecodeBinary(ci, src); err != nil {
+		return err
+	}
+	return m.setNumeric(n)
+}
+
+// Scan implements sql.Scanner for callers that go through database/sql.
+func (m *Money) Scan(src interface{}) error {
+	var n pgtype.Numeric
+	if err := n.Scan(src); err != nil {
+		return err
+	}
+	return m.setNumeric(n)
+}
+
+// setNumeric converts a numeric column value into minor units of the default
+// currency. NULL becomes zero.
+func (m *Money) setNumeric(n pgtype.Numeric) error {
+	currency := DefaultCurrency()
+	if n.Status != pgtype.Present {
+		*m = Money{Currency: currency}
+		return nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.None {
+		return fmt.Errorf("%w: non-finite numeric", ErrInvalidAmount)
+	}
+
+	rat := new(big.Rat).SetInt(n.Int)
+	if n.Exp > 0 {
+		rat.Mul(rat, new(big.Rat).SetInt(pow10(int(n.Exp))))
+	} else if n.Exp < 0 {
+		rat.Quo(rat, new(big.Rat).SetInt(pow10(int(-n.Exp))))
+	}
+
+	parsed, err := fromRat(rat, currency, nil)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
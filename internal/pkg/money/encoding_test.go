@@ -0,0 +1,44 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestUnmarshalJSONCurrency(t *testing.T) {
+	if err := SetDefaultCurrency("KZT"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetDefaultCurrency("USD") })
+
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr error
+	}{
+		{input: `"100"`, want: 10000},
+		{input: `100.5`, want: 10050},
+		{input: `{"amount":"100"}`, want: 10000},
+		{input: `{"amount":"100","currency":"KZT"}`, want: 10000},
+		{input: `{"amount":"100","currency":"kzt"}`, want: 10000},
+		{input: `{"amount":"100","currency":"USD"}`, wantErr: ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		var m Money
+		err := json.Unmarshal([]byte(tt.input), &m)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: got error %v, want %v", tt.input, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.input, err)
+			continue
+		}
+		if m.Amount != tt.want || m.Currency != "KZT" {
+			t.Errorf("%s: got %d %s, want %d KZT", tt.input, m.Amount, m.Currency, tt.want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Package money implements an exact monetary amount stored as integer minor
+// units together with an ISO 4217 currency code.
+//
+// The database stores amounts as numeric columns without a currency, so values
+// read from Postgres are assumed to be in the dealership currency returned by
+// DefaultCurrency.
+//
+// Rounding rules: amounts coming from clients or the database must not carry
+// more fraction digits than the currency allows (extra digits are rejected);
+// amounts derived by arithmetic such as MulRat use the rounding mode passed by
+// the caller, RoundHalfEven unless there is a reason to prefer another.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync/atomic"
+)
+
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+type RoundingMode int
+
+const (
+	RoundHalfEven RoundingMode = iota
+	RoundHalfUp
+	RoundDown
+	RoundUp
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrPrecision        = errors.New("amount has more fraction digits than the currency allows")
+	ErrOverflow         = errors.New("amount overflows int64 minor units")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// exponents maps supported currencies to the number of minor unit digits.
+var exponents = map[string]int{
+	"AED": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"KGS": 2,
+	"KRW": 0,
+	"KZT": 2,
+	"RUB": 2,
+	"TRY": 2,
+	"USD": 2,
+	"UZS": 2,
+}
+
+var defaultCurrency atomic.Value
+
+func init() {
+	defaultCurrency.Store("USD")
+}
+
+func DefaultCurrency() string {
+	return defaultCurrency.Load().(string)
+}
+
+// SetDefaultCurrency sets the currency assumed for database values.
+func SetDefaultCurrency(code string) error {
+	code = strings.ToUpper(code)
+	if _, ok := exponents[code]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+	}
+	defaultCurrency.Store(code)
+	return nil
+}
+
+// Exponent returns the number of minor unit digits of the currency.
+func Exponent(currency string) (int, error) {
+	exp, ok := exponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+	return exp, nil
+}
+
+func New(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// FromMinor builds an amount in the default currency.
+func FromMinor(minor int64) Money {
+	return Money{Amount: minor, Currency: DefaultCurrency()}
+}
+
+// Zero returns a zero amount in the default currency.
+func Zero() Money {
+	return FromMinor(0)
+}
+
+// Parse reads a decimal amount such as "1500.25" exactly; it fails if the
+// value has more fraction digits than the currency allows.
+func Parse(value, currency string) (Money, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	return fromRat(rat, currency, nil)
+}
+
+// ParseRound reads a decimal amount and rounds it to the currency precision.
+func ParseRound(value, currency string, mode RoundingMode) (Money, error) {
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+	return fromRat(rat, currency, &mode)
+}
+
+// MustParse is Parse for constants known to be valid; it panics otherwise.
+func MustParse(value, currency string) Money {
+	m, err := Parse(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func fromRat(rat *big.Rat, currency string, mode *RoundingMode) (Money, error) {
+	currency = strings.ToUpper(currency)
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	scaled := new(big.Rat).Mul(rat, new(big.Rat).SetInt(pow10(exp)))
+	var minor *big.Int
+	if scaled.IsInt() {
+		minor = new(big.Int).Set(scaled.Num())
+	} else if mode == nil {
+		return Money{}, ErrPrecision
+	} else {
+		minor = roundQuo(scaled.Num(), scaled.Denom(), *mode)
+	}
+
+	if !minor.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: minor.Int64(), Currency: currency}, nil
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	sum := m.Amount + o.Amount
+	if (o.Amount > 0 && sum < m.Amount) || (o.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: sum, Currency: m.currency(o)}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(o.Neg())
+}
+
+// Cmp returns -1, 0 or 1 depending on whether m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// MulRat multiplies the amount by num/den and rounds the result with mode.
+func (m Money) MulRat(num, den int64, mode RoundingMode) (Money, error) {
+	if den == 0 {
+		return Money{}, errors.New("division by zero")
+	}
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	divisor := big.NewInt(den)
+	if divisor.Sign() < 0 {
+		product.Neg(product)
+		divisor.Neg(divisor)
+	}
+	result := roundQuo(product, divisor, mode)
+	if !result.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: result.Int64(), Currency: m.Currency}, nil
+}
+
+// Decimal formats the amount without the currency, e.g. "1500.25".
+func (m Money) Decimal() string {
+	exp, ok := exponents[m.currency(m)]
+	if !ok {
+		exp = 2
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%d", m.Amount)
+	}
+
+	sign := ""
+	abs := new(big.Int).Abs(big.NewInt(m.Amount))
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	q, r := new(big.Int).QuoRem(abs, pow10(exp), new(big.Int))
+	return fmt.Sprintf("%s%s.%0*d", sign, q.String(), exp, r.Int64())
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.currency(m)
+}
+
+// sameCurrency treats an empty currency as compatible with anything so the
+// zero value can be used as an accumulator.
+func (m Money) sameCurrency(o Money) error {
+	if m.Currency != "" && o.Currency != "" && m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+func (m Money) currency(o Money) string {
+	if m.Currency != "" {
+		return m.Currency
+	}
+	if o.Currency != "" {
+		return o.Currency
+	}
+	return DefaultCurrency()
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// roundQuo divides num by a positive den and rounds the quotient with mode.
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	away := big.NewInt(int64(num.Sign()))
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(den)
+
+	switch mode {
+	case RoundDown:
+		return q
+	case RoundUp:
+		return q.Add(q, away)
+	case RoundHalfUp:
+		if half >= 0 {
+			q.Add(q, away)
+		}
+	default:
+		if half > 0 || (half == 0 && q.Bit(0) == 1) {
+			q.Add(q, away)
+		}
+	}
+	return q
+}
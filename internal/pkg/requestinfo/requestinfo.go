@@ -0,0 +1,21 @@
+package requestinfo
+
+import "context"
+
+// Info describes the HTTP request a usecase runs on behalf of.
+type Info struct {
+	RequestID string
+	IPAddress string
+	UserAgent string
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+func FromContext(ctx context.Context) (Info, bool) {
+	info, ok := ctx.Value(contextKey{}).(Info)
+	return info, ok
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	t.Helper()
+	store, err := NewLocalStore(t.TempDir(), "http://localhost/media/", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.now = func() time.Time { return time.Unix(1_700_000_000, 0) }
+	return store
+}
+
+func putString(t *testing.T, store BlobStore, key, body string) {
+	t.Helper()
+	if err := store.Put(context.Background(), key, strings.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+		t.Fatalf("Put(%q): %v", key, err)
+	}
+}
+
+func readBlob(t *testing.T, store BlobStore, key string) (string, error) {
+	t.Helper()
+	body, err := store.Get(context.Background(), key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), nil
+}
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+
+	putString(t, store, "cars/1/a.txt", "hello")
+	if got, err := readBlob(t, store, "cars/1/a.txt"); err != nil || got != "hello" {
+		t.Fatalf("Get = %q, %v; want hello", got, err)
+	}
+	if err := store.Put(ctx, "cars/1/b.txt", strings.NewReader("short"), 10, ""); err == nil {
+		t.Error("Put with a wrong size succeeded")
+	}
+	if _, err := readBlob(t, store, "cars/1/b.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after failed Put = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(ctx, "cars/1/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBlob(t, store, "cars/1/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "cars/1/a.txt"); err != nil {
+		t.Errorf("Delete of a missing blob = %v, want nil", err)
+	}
+	if err := store.Delete(ctx, "../a.txt"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Delete(../a.txt) = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestLocalStoreVerify(t *testing.T) {
+	store := newTestLocalStore(t)
+	signed, err := store.SignedURL(context.Background(), "cars/1/a.txt", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/media/cars/1/a.txt" {
+		t.Errorf("signed url path = %q", u.Path)
+	}
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+
+	tests := []struct {
+		name      string
+		key       string
+		expires   string
+		signature string
+		after     time.Duration
+		valid     bool
+	}{
+		{name: "valid", key: "cars/1/a.txt", expires: expires, signature: signature, valid: true},
+		{name: "at expiry", key: "cars/1/a.txt", expires: expires, signature: signature, after: time.Minute, valid: true},
+		{name: "expired", key: "cars/1/a.txt", expires: expires, signature: signature, after: time.Minute + time.Second},
+		{name: "other key", key: "cars/1/b.txt", expires: expires, signature: signature},
+		{name: "extended expiry", key: "cars/1/a.txt", expires: "9999999999", signature: signature},
+		{name: "bad expiry", key: "cars/1/a.txt", expires: "soon", signature: signature},
+		{name: "bad signature", key: "cars/1/a.txt", expires: expires, signature: strings.Repeat("0", len(signature))},
+		{name: "no signature", key: "cars/1/a.txt", expires: expires},
+	}
+	start := store.now()
+	for _, tt := range tests {
+		store.now = func() time.Time { return start.Add(tt.after) }
+		err := store.Verify(tt.key, tt.expires, tt.signature)
+		if tt.valid && err != nil {
+			t.Errorf("%s: Verify = %v, want nil", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: Verify = %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+}
+
+func TestLocalStoreServeHTTP(t *testing.T) {
+	store := newTestLocalStore(t)
+	putString(t, store, "cars/1/a.txt", "hello")
+	signed, err := store.SignedURL(context.Background(), "cars/1/a.txt", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, err := store.SignedURL(context.Background(), "cars/1/missing.txt", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The store is mounted with the /media prefix stripped.
+	handler := http.StripPrefix("/media", store)
+	path := func(signed string) string { return strings.TrimPrefix(signed, "http://localhost") }
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		body   string
+	}{
+		{name: "signed", method: http.MethodGet, target: path(signed), status: http.StatusOK, body: "hello"},
+		{name: "head", method: http.MethodHead, target: path(signed), status: http.StatusOK},
+		{name: "unsigned", method: http.MethodGet, target: "/media/cars/1/a.txt", status: http.StatusForbidden},
+		{name: "tampered", method: http.MethodGet, target: strings.Replace(path(signed), "a.txt", "b.txt", 1), status: http.StatusForbidden},
+		{name: "traversal", method: http.MethodGet, target: "/media/cars/../a.txt?" + strings.SplitN(signed, "?", 2)[1], status: http.StatusForbidden},
+		{name: "missing", method: http.MethodGet, target: path(missing), status: http.StatusNotFound},
+		{name: "post", method: http.MethodPost, target: path(signed), status: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+			continue
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s: body = %q, want %q", tt.name, rec.Body.String(), tt.body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path(signed), nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", got)
+	}
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+
+	store.now = func() time.Time { return time.Unix(1_700_000_000, 0).Add(2 * time.Minute) }
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path(signed), nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expired: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,56 @@
+// Package storage keeps uploaded files. A BlobStore is either a directory on
+// local disk, served by the application itself, or a bucket on an
+// S3-compatible service. Both hand out signed URLs that expire, so stored
+// files are never public.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound         = errors.New("blob not found")
+	ErrInvalidKey       = errors.New("invalid blob key")
+	ErrUnknownProvider  = errors.New("unknown storage provider")
+	ErrInvalidSignature = errors.New("invalid or expired blob signature")
+)
+
+type BlobStore interface {
+	// Put stores size bytes read from body under key, replacing any blob
+	// already there.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that downloads the blob until ttl has passed.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Check reports whether the store can be used, for readiness probes.
+	Check(ctx context.Context) error
+}
+
+// checkKey accepts slash-separated keys of letters, digits, '-', '_' and '.'
+// without empty or dot-only segments, which keeps them safe as file paths and
+// as URL paths without escaping.
+func checkKey(key string) error {
+	if key == "" || len(key) > 512 {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || strings.Trim(segment, ".") == "" {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+		for _, r := range segment {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			default:
+				return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+			}
+		}
+	}
+	return nil
+}
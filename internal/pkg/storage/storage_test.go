@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{key: "cars/1/abc.jpg", valid: true},
+		{key: "cars/1/abc_small.jpg", valid: true},
+		{key: "a-b_c.d", valid: true},
+		{key: "..a", valid: true},
+		{key: strings.Repeat("a", 512), valid: true},
+		{key: ""},
+		{key: strings.Repeat("a", 513)},
+		{key: "/cars/1.jpg"},
+		{key: "cars/1/"},
+		{key: "cars//1.jpg"},
+		{key: "cars/../1.jpg"},
+		{key: "cars/./1.jpg"},
+		{key: "..."},
+		{key: `cars\1.jpg`},
+		{key: "cars/1 2.jpg"},
+		{key: "cars/1.jpg?x=1"},
+		{key: "cars/%2e%2e/1.jpg"},
+		{key: "cars/ё.jpg"},
+	}
+	for _, tt := range tests {
+		err := checkKey(tt.key)
+		if tt.valid && err != nil {
+			t.Errorf("checkKey(%q) = %v, want nil", tt.key, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("checkKey(%q) = %v, want ErrInvalidKey", tt.key, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package thumbnail scales photos down for listings and galleries using only
+// the standard library image decoders.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+// Quality is the JPEG quality thumbnails are encoded with.
+const Quality = 82
+
+var ErrTooLarge = errors.New("image dimensions are too large")
+
+// Decode reads a JPEG, PNG or GIF image. It checks the dimensions from the
+// header before decoding so a small file cannot claim a huge canvas.
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// Fit returns the size of a w×h image scaled down so its longer side is at
+// most maxSide. Images that already fit keep their size.
+func Fit(w, h, maxSide int) (int, int) {
+	if w <= maxSide && h <= maxSide {
+		return w, h
+	}
+	if w >= h {
+		return maxSide, max(1, (h*maxSide+w/2)/w)
+	}
+	return max(1, (w*maxSide+h/2)/h), maxSide
+}
+
+// Resize scales src down to fit maxSide using a box filter: every output
+// pixel is the area-weighted average of the source pixels it covers, which
+// avoids the aliasing of nearest-neighbour sampling. It never scales up.
+// Transparent areas are flattened onto white since thumbnails are JPEG.
+func Resize(src image.Image, maxSide int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := Fit(sw, sh, maxSide)
+
+	flat := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, b.Min, draw.Over)
+	if dw == sw && dh == sh {
+		return flat
+	}
+
+	// Scale horizontally into a float buffer, then vertically into dst.
+	row := resample(sw, dw)
+	col := resample(sh, dh)
+	tmp := make([]float32, dw*sh*3)
+	for y := 0; y < sh; y++ {
+		line := flat.Pix[y*flat.Stride:]
+		for x, taps := range row {
+			var r, g, bl float64
+			for _, t := range taps {
+				p := line[t.index*4:]
+				r += float64(p[0]) * t.weight
+				g += float64(p[1]) * t.weight
+				bl += float64(p[2]) * t.weight
+			}
+			o := (y*dw + x) * 3
+			tmp[o], tmp[o+1], tmp[o+2] = float32(r), float32(g), float32(bl)
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y, taps := range col {
+		for x := 0; x < dw; x++ {
+			var r, g, bl float64
+			for _, t := range taps {
+				o := (t.index*dw + x) * 3
+				r += float64(tmp[o]) * t.weight
+				g += float64(tmp[o+1]) * t.weight
+				bl += float64(tmp[o+2]) * t.weight
+			}
+			p := dst.Pix[y*dst.Stride+x*4:]
+			p[0], p[1], p[2], p[3] = clamp(r), clamp(g), clamp(bl), 0xff
+		}
+	}
+	return dst
+}
+
+// EncodeJPEG encodes img as a thumbnail.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: Quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type tap struct {
+	index  int
+	weight float64
+}
+
+// resample lists, for each of the n output positions, the source positions
+// of a length-m line it covers and how much of each.
+func resample(m, n int) [][]tap {
+	scale := float64(m) / float64(n)
+	taps := make([][]tap, n)
+	for i := range taps {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(start); j < m && float64(j) < end; j++ {
+			overlap := min(end, float64(j+1)) - max(start, float64(j))
+			if overlap > 0 {
+				taps[i] = append(taps[i], tap{index: j, weight: overlap / scale})
+			}
+		}
+	}
+	return taps
+}
+
+func clamp(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
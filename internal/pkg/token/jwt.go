@@ -0,0 +1,201 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// JWTKey is one key of a JWTMaker. Its ID goes into the "kid" header, so keys
+// retired by a rotation can keep verifying the tokens they signed until those
+// expire. SignKey is nil for verification-only keys.
+type JWTKey struct {
+	ID        string
+	Algorithm string
+	SignKey   any
+	VerifyKey any
+}
+
+// NewHMACKey returns an HS256 key; the secret both signs and verifies.
+func NewHMACKey(id string, secret []byte) JWTKey {
+	return JWTKey{ID: id, Algorithm: AlgorithmHS256, SignKey: secret, VerifyKey: secret}
+}
+
+// ParseJWTKey reads an RS256 or EdDSA key from PEM. A private key can sign and
+// verify, a public key only verify.
+func ParseJWTKey(id, algorithm string, data []byte) (JWTKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return JWTKey{}, fmt.Errorf("key %q: no PEM block found", id)
+	}
+
+	key := JWTKey{ID: id, Algorithm: algorithm}
+	switch algorithm {
+	case AlgorithmRS256:
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			key.SignKey, key.VerifyKey = private, &private.PublicKey
+			return key, nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("key %q: %w", id, err)
+		}
+		key.VerifyKey = public
+	case AlgorithmEdDSA:
+		if private, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			edPrivate, ok := private.(ed25519.PrivateKey)
+			if !ok {
+				return JWTKey{}, fmt.Errorf("key %q is not an Ed25519 key", id)
+			}
+			key.SignKey, key.VerifyKey = edPrivate, edPrivate.Public()
+			return key, nil
+		}
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("key %q: %w", id, err)
+		}
+		edPublic, ok := public.(ed25519.PublicKey)
+		if !ok {
+			return JWTKey{}, fmt.Errorf("key %q is not an Ed25519 key", id)
+		}
+		key.VerifyKey = edPublic
+	default:
+		return JWTKey{}, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+	return key, nil
+}
+
+type jwtClaims struct {
+	UserID    int    `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+type JWTMaker struct {
+	current JWTKey
+	keys    map[string]JWTKey
+	methods []string
+}
+
+// NewJWTMaker signs with current and verifies with current and previous.
+func NewJWTMaker(current JWTKey, previous ...JWTKey) (*JWTMaker, error) {
+	if current.SignKey == nil {
+		return nil, fmt.Errorf("key %q cannot sign tokens", current.ID)
+	}
+
+	m := &JWTMaker{current: current, keys: map[string]JWTKey{}}
+	seen := map[string]bool{}
+	for _, key := range append([]JWTKey{current}, previous...) {
+		if signingMethod(key.Algorithm) == nil {
+			return nil, fmt.Errorf("unsupported JWT algorithm %q", key.Algorithm)
+		}
+		if key.VerifyKey == nil {
+			return nil, fmt.Errorf("key %q cannot verify tokens", key.ID)
+		}
+		if _, dup := m.keys[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		m.keys[key.ID] = key
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			m.methods = append(m.methods, key.Algorithm)
+		}
+	}
+	return m, nil
+}
+
+func (m *JWTMaker) CreateToken(userID int, role, sessionID string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(userID, role, sessionID, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := jwt.NewWithClaims(signingMethod(m.current.Algorithm), jwtClaims{
+		UserID:    payload.UserID,
+		Role:      payload.Role,
+		SessionID: payload.SessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.ID,
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiresAt),
+		},
+	})
+	if m.current.ID != "" {
+		token.Header["kid"] = m.current.ID
+	}
+
+	signed, err := token.SignedString(m.current.SignKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, payload, nil
+}
+
+func (m *JWTMaker) VerifyToken(tokenString string) (*Payload, error) {
+	var claims jwtClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, m.keyFor,
+		jwt.WithValidMethods(m.methods),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrExpiredToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	payload := &Payload{
+		ID:        claims.ID,
+		UserID:    claims.UserID,
+		Role:      claims.Role,
+		SessionID: claims.SessionID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if claims.IssuedAt != nil {
+		payload.IssuedAt = claims.IssuedAt.Time
+	}
+	if err := payload.Valid(time.Now()); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// keyFor picks the key named by the "kid" header. Tokens without one are
+// checked against the current key.
+func (m *JWTMaker) keyFor(token *jwt.Token) (any, error) {
+	key := m.current
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok = m.keys[kid]; !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.VerifyKey, nil
+}
+
+func signingMethod(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case AlgorithmHS256:
+		return jwt.SigningMethodHS256
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// pasetoFooter is sent unencrypted; it only names the key.
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// PasetoMaker issues PASETO v4.local tokens.
+type PasetoMaker struct {
+	currentID string
+	keys      map[string]paseto.V4SymmetricKey
+}
+
+// NewPasetoMaker encrypts with the key named currentID and decrypts with any
+// key in keys, which maps key IDs to hex-encoded 32-byte keys.
+func NewPasetoMaker(currentID string, keys map[string]string) (*PasetoMaker, error) {
+	m := &PasetoMaker{currentID: currentID, keys: map[string]paseto.V4SymmetricKey{}}
+	for id, encoded := range keys {
+		key, err := paseto.V4SymmetricKeyFromHex(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("paseto key %q: %w", id, err)
+		}
+		m.keys[id] = key
+	}
+	if _, ok := m.keys[currentID]; !ok {
+		return nil, fmt.Errorf("paseto key %q is not configured", currentID)
+	}
+	return m, nil
+}
+
+func (m *PasetoMaker) CreateToken(userID int, role, sessionID string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(userID, role, sessionID, duration)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := paseto.NewToken()
+	token.SetJti(payload.ID)
+	token.SetIssuedAt(payload.IssuedAt)
+	token.SetNotBefore(payload.IssuedAt)
+	token.SetExpiration(payload.ExpiresAt)
+	if err := token.Set("user_id", payload.UserID); err != nil {
+		return "", nil, err
+	}
+	token.SetString("role", payload.Role)
+	token.SetString("sid", payload.SessionID)
+
+	footer, err := json.Marshal(pasetoFooter{KeyID: m.currentID})
+	if err != nil {
+		return "", nil, err
+	}
+	token.SetFooter(footer)
+
+	return token.V4Encrypt(m.keys[m.currentID], nil), payload, nil
+}
+
+func (m *PasetoMaker) VerifyToken(tokenString string) (*Payload, error) {
+	parser := paseto.NewParserWithoutExpiryCheck()
+
+	rawFooter, err := parser.UnsafeParseFooter(paseto.V4Local, tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	var footer pasetoFooter
+	if err := json.Unmarshal(rawFooter, &footer); err != nil {
+		return nil, fmt.Errorf("%w: malformed footer", ErrInvalidToken)
+	}
+	key, ok := m.keys[footer.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, footer.KeyID)
+	}
+
+	// Expiry is checked by Payload.Valid so it maps onto ErrExpiredToken.
+	parsed, err := parser.ParseV4Local(key, tokenString, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var payload Payload
+	if payload.ID, err = parsed.GetJti(); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := parsed.Get("user_id", &payload.UserID); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if payload.Role, err = parsed.GetString("role"); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if payload.SessionID, err = parsed.GetString("sid"); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if payload.IssuedAt, err = parsed.GetIssuedAt(); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if payload.ExpiresAt, err = parsed.GetExpiration(); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := payload.Valid(time.Now()); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
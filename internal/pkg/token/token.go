@@ -1,8 +1,61 @@
+// Package token issues and verifies the access tokens handed out at login.
+// Every format implements Maker, so callers never depend on JWT or PASETO
+// directly.
 package token
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Payload is what every access token carries.
+type Payload struct {
+	ID        string    `json:"jti"`
+	UserID    int       `json:"user_id"`
+	Role      string    `json:"role"`
+	SessionID string    `json:"sid"`
+	IssuedAt  time.Time `json:"iat"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
+func NewPayload(userID int, role, sessionID string, duration time.Duration) (*Payload, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate token id: %w", err)
+	}
+	now := time.Now().Truncate(time.Second)
+	return &Payload{
+		ID:        hex.EncodeToString(buf),
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(duration),
+	}, nil
+}
+
+// Valid checks the payload once the token signature has been verified.
+func (p *Payload) Valid(now time.Time) error {
+	if p.UserID <= 0 || p.SessionID == "" {
+		return ErrInvalidToken
+	}
+	if !now.Before(p.ExpiresAt) {
+		return ErrExpiredToken
+	}
+	return nil
+}
 
 type Maker interface {
-	CreateToken(email string, duration time.Duration) (string, error)
-	VerifyToken(token string) (string, error)
+	CreateToken(userID int, role, sessionID string, duration time.Duration) (string, *Payload, error)
+	// VerifyToken returns ErrExpiredToken or ErrInvalidToken when the token
+	// must not be accepted.
+	VerifyToken(token string) (*Payload, error)
 }
@@ -0,0 +1,87 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 appendix B, "12345678901234567890",
+// base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestCodeRFC6238 uses the SHA-1 test vectors of RFC 6238 appendix B. The RFC
+// lists 8-digit codes; the 6-digit codes are their last six digits.
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if got, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", Step(time.Unix(59, 0))); err != nil || got != "287082" {
+		t.Errorf("Code with a lower-case secret = %s, %v; want 287082", got, err)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code with an invalid secret succeeded")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	// 1111111111 is step 37037037; the RFC gives codes for it and the step
+	// before.
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	previous := "081804"
+	next, err := Code(rfcSecret, current+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	twoAhead, err := Code(rfcSecret, current+2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current", code: "050471", skew: 0, wantStep: current, wantOK: true},
+		{name: "previous without skew", code: previous, skew: 0},
+		{name: "previous within skew", code: previous, skew: 1, wantStep: current - 1, wantOK: true},
+		{name: "next within skew", code: next, skew: 1, wantStep: current + 1, wantOK: true},
+		{name: "outside skew", code: twoAhead, skew: 1},
+		{name: "wider skew", code: twoAhead, skew: 2, wantStep: current + 2, wantOK: true},
+		{name: "wrong code", code: "000000", skew: 1},
+		{name: "too short", code: "50471", skew: 1},
+		{name: "too long", code: "0050471", skew: 1},
+		{name: "empty", code: "", skew: 1},
+	}
+	for _, tt := range tests {
+		step, ok := Validate(rfcSecret, tt.code, now, tt.skew)
+		if ok != tt.wantOK || step != tt.wantStep {
+			t.Errorf("%s: Validate = %d, %v; want %d, %v", tt.name, step, ok, tt.wantStep, tt.wantOK)
+		}
+	}
+
+	if _, ok := Validate("not base32!", "050471", now, 1); ok {
+		t.Error("Validate with an invalid secret succeeded")
+	}
+}
@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// StdoutExporter writes one JSON object per span, for local debugging.
+type StdoutExporter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	service string
+}
+
+func NewStdoutExporter(w io.Writer, service string) *StdoutExporter {
+	return &StdoutExporter{w: w, service: service}
+}
+
+type stdoutSpan struct {
+	Service      string         `json:"service"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	Start        time.Time      `json:"start"`
+	DurationMS   float64        `json:"duration_ms"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       string         `json:"status,omitempty"`
+	StatusDetail string         `json:"status_detail,omitempty"`
+}
+
+func (e *StdoutExporter) ExportSpans(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.w)
+	for _, s := range spans {
+		out := stdoutSpan{
+			Service:      e.service,
+			TraceID:      s.Context.TraceID.String(),
+			SpanID:       s.Context.SpanID.String(),
+			Name:         s.Name,
+			Kind:         kindName(s.Kind),
+			Start:        s.Start.UTC(),
+			DurationMS:   float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			StatusDetail: s.StatusDetail,
+		}
+		if s.Parent.IsValid() {
+			out.ParentSpanID = s.Parent.String()
+		}
+		if s.Status == StatusError {
+			out.Status = "error"
+		}
+		if len(s.Attributes) > 0 {
+			out.Attributes = make(map[string]any, len(s.Attributes))
+			for _, a := range s.Attributes {
+				out.Attributes[a.Key] = a.Value
+			}
+		}
+		if err := enc.Encode(out); err != nil {
+			return fmt.Errorf("failed to write span: %w", err)
+		}
+	}
+	return nil
+}
+
+func kindName(k SpanKind) string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	}
+	return "internal"
+}
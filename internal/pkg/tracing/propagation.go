@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	HeaderTraceparent = "traceparent"
+
+	flagSampled = 0x01
+)
+
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// ParseTraceparent reads a W3C trace context header,
+// version-traceid-parentid-flags.
+func ParseTraceparent(header string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// Version 00 has exactly four fields; later versions may append more.
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var sc SpanContext
+	var f [1]byte
+	if err := decodeHex(sc.TraceID[:], traceID); err != nil {
+		return SpanContext{}, err
+	}
+	if err := decodeHex(sc.SpanID[:], spanID); err != nil {
+		return SpanContext{}, err
+	}
+	if err := decodeHex(f[:], flags); err != nil {
+		return SpanContext{}, err
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	sc.Sampled = f[0]&flagSampled != 0
+	return sc, nil
+}
+
+// Traceparent formats sc as a version 00 W3C trace context header.
+func (sc SpanContext) Traceparent() string {
+	flags := 0
+	if sc.Sampled {
+		flags = flagSampled
+	}
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, flags)
+}
+
+// decodeHex accepts lowercase hex only, as the specification requires.
+func decodeHex(dst []byte, s string) error {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return ErrInvalidTraceparent
+	}
+	if _, err := hex.Decode(dst, []byte(s)); err != nil {
+		return ErrInvalidTraceparent
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Package tracing records spans in the OpenTelemetry data model, propagates
+// them through context.Context and W3C traceparent headers, and exports them
+// in batches to stdout or an OTLP/HTTP collector.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (t TraceID) IsValid() bool  { return t != TraceID{} }
+
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+func (s SpanID) IsValid() bool  { return s != SpanID{} }
+
+// SpanKind follows the OTLP enumeration.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode follows the OTLP enumeration.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// SpanContext identifies a span across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attr builds an attribute. Values other than strings, bools, integers and
+// floats are exported as their fmt representation.
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanData is a finished span as handed to exporters.
+type SpanData struct {
+	Context      SpanContext
+	Parent       SpanID
+	Name         string
+	Kind         SpanKind
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	Status       StatusCode
+	StatusDetail string
+}
+
+// Span is an operation in progress. All methods are safe on a nil span,
+// which is what Start returns while tracing is off.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.Context
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil || !s.data.Context.Sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Attributes = append(s.data.Attributes, attrs...)
+	}
+}
+
+// RecordError marks the span failed; a nil err is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.SetStatus(StatusError, err.Error())
+}
+
+func (s *Span) SetStatus(code StatusCode, detail string) {
+	if s == nil || !s.data.Context.Sampled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.data.Status = code
+		s.data.StatusDetail = detail
+	}
+}
+
+// End finishes the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.Context.Sampled {
+		s.tracer.enqueue(data)
+	}
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// SpanFromContext returns the span started by the innermost Start, if any.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent makes spans started from ctx children of a span
+// in another process, as read from a traceparent header.
+func ContextWithRemoteParent(ctx context.Context, parent SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, parent)
+}
+
+// TraceIDFromContext returns the trace ID of the current span, or "".
+func TraceIDFromContext(ctx context.Context) string {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext().TraceID.String()
+	}
+	return ""
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		mustRead(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		mustRead(id[:])
+	}
+	return id
+}
+
+func mustRead(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("tracing: failed to read random bytes: %v", err))
+	}
+}
+
+// sampledByRatio keeps a deterministic share of traces so every service
+// sampling by the same ratio agrees on a trace.
+func sampledByRatio(id TraceID, ratio float64) bool {
+	switch {
+	case ratio >= 1:
+		return true
+	case ratio <= 0:
+		return false
+	}
+	bound := uint64(ratio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
@@ -0,0 +1,174 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"myproject/pkg/logger"
+)
+
+const (
+	defaultBatchSize    = 512
+	defaultBatchTimeout = 5 * time.Second
+	defaultQueueSize    = 2048
+	exportTimeout       = 10 * time.Second
+)
+
+// Exporter sends finished spans somewhere.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+}
+
+type Options struct {
+	// SampleRatio is the share of new traces recorded; traces started
+	// elsewhere follow the caller's decision.
+	SampleRatio  float64
+	BatchSize    int
+	BatchTimeout time.Duration
+}
+
+// Tracer starts spans and exports the sampled ones in the background.
+type Tracer struct {
+	exporter Exporter
+	opts     Options
+
+	queue   chan SpanData
+	stop    chan struct{}
+	done    chan struct{}
+	stopped sync.Once
+}
+
+func NewTracer(exporter Exporter, opts Options) *Tracer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.BatchTimeout <= 0 {
+		opts.BatchTimeout = defaultBatchTimeout
+	}
+	t := &Tracer{
+		exporter: exporter,
+		opts:     opts,
+		queue:    make(chan SpanData, defaultQueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault sets the tracer used for spans without a parent in the context.
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Start starts an internal span, a child of the span in ctx if there is one.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return StartSpan(ctx, name, SpanKindInternal, attrs...)
+}
+
+// StartSpan starts a span of the given kind. It returns a nil span, which
+// ignores all calls, when no tracer is configured.
+func StartSpan(ctx context.Context, name string, kind SpanKind, attrs ...Attribute) (context.Context, *Span) {
+	var tracer *Tracer
+	var parent SpanContext
+	if span := SpanFromContext(ctx); span != nil {
+		tracer, parent = span.tracer, span.data.Context
+	} else {
+		tracer = defaultTracer.Load()
+		parent, _ = ctx.Value(remoteKey{}).(SpanContext)
+	}
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID, sc.Sampled = parent.TraceID, parent.Sampled
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = sampledByRatio(sc.TraceID, tracer.opts.SampleRatio)
+	}
+
+	span := &Span{tracer: tracer, data: SpanData{
+		Context: sc,
+		Parent:  parent.SpanID,
+		Name:    name,
+		Kind:    kind,
+		Start:   time.Now(),
+	}}
+	if sc.Sampled {
+		span.data.Attributes = attrs
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// enqueue drops the span rather than block the request when the exporter
+// falls behind or the tracer has shut down.
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case <-t.stop:
+	case t.queue <- data:
+	default:
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	batch := make([]SpanData, 0, t.opts.BatchSize)
+	ticker := time.NewTicker(t.opts.BatchTimeout)
+	defer ticker.Stop()
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		if err := t.exporter.ExportSpans(ctx, batch); err != nil {
+			logger.FromContext(ctx).Warn("tracing: failed to export spans", "spans", len(batch), "error", err)
+		}
+		batch = make([]SpanData, 0, t.opts.BatchSize)
+	}
+
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			for {
+				select {
+				case data := <-t.queue:
+					batch = append(batch, data)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown exports the queued spans and stops the tracer. It is a no-op on a
+// nil tracer.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.stopped.Do(func() { close(t.stop) })
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
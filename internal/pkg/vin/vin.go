@@ -0,0 +1,176 @@
+// Package vin validates and decodes 17-character vehicle identification
+// numbers (ISO 3779) offline, using a bundled table of world manufacturer
+// identifiers.
+package vin
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	Length = 17
+
+	// yearCodes maps position 10 to the model year; the sequence repeats
+	// every 30 years starting in 1980.
+	yearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+	firstYear = 1980
+)
+
+var (
+	ErrInvalidLength     = errors.New("vin must be 17 characters")
+	ErrInvalidCharacter  = errors.New("vin contains an invalid character")
+	ErrInvalidCheckDigit = errors.New("vin check digit does not match")
+)
+
+// weights are the position weights of the check digit calculation.
+var weights = [Length]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// Info is what a VIN encodes.
+type Info struct {
+	VIN          string `json:"vin"`
+	WMI          string `json:"wmi"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Country      string `json:"country,omitempty"`
+	Region       string `json:"region"`
+	// ModelYear is the most recent year the year code can stand for that is
+	// not after next year; the code itself repeats every 30 years.
+	ModelYear int    `json:"model_year"`
+	PlantCode string `json:"plant_code"`
+	Serial    string `json:"serial"`
+}
+
+type manufacturer struct {
+	name    string
+	country string
+}
+
+//go:embed wmi.csv
+var wmiCSV string
+
+var wmiTable = loadWMI(wmiCSV)
+
+// now is the clock model years are resolved against; tests replace it.
+var now = time.Now
+
+func loadWMI(data string) map[string]manufacturer {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("vin: bundled wmi table: %v", err))
+	}
+	table := make(map[string]manufacturer, len(records))
+	for _, r := range records[1:] {
+		table[r[0]] = manufacturer{name: r[1], country: r[2]}
+	}
+	return table
+}
+
+// Normalize upper-cases the VIN and strips surrounding space.
+func Normalize(vin string) string {
+	return strings.ToUpper(strings.TrimSpace(vin))
+}
+
+// Validate checks the length, the alphabet (I, O and Q are never used) and
+// the check digit in position 9. The check digit is only mandatory for
+// vehicles built for North America and China; elsewhere, e.g. on most
+// European VINs, position 9 is a filler and is not checked.
+func Validate(vin string) error {
+	if len(vin) != Length {
+		return ErrInvalidLength
+	}
+	sum := 0
+	for i := 0; i < Length; i++ {
+		v, ok := value(vin[i])
+		if !ok {
+			return fmt.Errorf("%w %q at position %d", ErrInvalidCharacter, vin[i], i+1)
+		}
+		sum += v * weights[i]
+	}
+	want := byte('0' + sum%11)
+	if sum%11 == 10 {
+		want = 'X'
+	}
+	if requiresCheckDigit(vin[0]) && vin[8] != want {
+		return ErrInvalidCheckDigit
+	}
+	return nil
+}
+
+// Decode validates the VIN and splits it into its parts. An unknown
+// manufacturer is not an error; Manufacturer is then empty.
+func Decode(vin string) (Info, error) {
+	vin = Normalize(vin)
+	if err := Validate(vin); err != nil {
+		return Info{}, err
+	}
+
+	info := Info{
+		VIN:       vin,
+		WMI:       vin[:3],
+		Region:    region(vin[0]),
+		PlantCode: vin[10:11],
+		Serial:    vin[11:],
+	}
+	if m, ok := wmiTable[info.WMI]; ok {
+		info.Manufacturer, info.Country = m.name, m.country
+	}
+	if i := strings.IndexByte(yearCodes, vin[9]); i >= 0 {
+		latest := now().Year() + 1
+		year := firstYear + i
+		for year+len(yearCodes) <= latest {
+			year += len(yearCodes)
+		}
+		info.ModelYear = year
+	}
+	return info, nil
+}
+
+// MatchesYear reports whether year is one the model year code can stand for.
+func (i Info) MatchesYear(year int) bool {
+	if i.ModelYear == 0 || year < firstYear {
+		return false
+	}
+	return (year-i.ModelYear)%len(yearCodes) == 0
+}
+
+// value transliterates a VIN character for the check digit.
+func value(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c == 'I' || c == 'O' || c == 'Q':
+		return 0, false
+	case c >= 'A' && c <= 'H':
+		return int(c-'A') + 1, true
+	case c >= 'J' && c <= 'R':
+		return int(c-'J') + 1, true
+	case c >= 'S' && c <= 'Z':
+		return int(c-'S') + 2, true
+	}
+	return 0, false
+}
+
+func requiresCheckDigit(c byte) bool {
+	return (c >= '1' && c <= '5') || c == 'L'
+}
+
+func region(c byte) string {
+	switch {
+	case c >= 'A' && c <= 'H':
+		return "Africa"
+	case c >= 'J' && c <= 'R':
+		return "Asia"
+	case c >= 'S' && c <= 'Z':
+		return "Europe"
+	case c >= '1' && c <= '5':
+		return "North America"
+	case c == '6' || c == '7':
+		return "Oceania"
+	default:
+		return "South America"
+	}
+}
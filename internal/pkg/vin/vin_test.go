@@ -0,0 +1,208 @@
+package vin
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		vin  string
+		want error
+	}{
+		{name: "north america", vin: "1HGCM82633A004352"},
+		{name: "x check digit", vin: "1M8GDM9AXKP042788"},
+		{name: "tesla", vin: "5YJ3E1EA2KF317000"},
+		{name: "china", vin: "LVSHCAMB1CE054249"},
+		{name: "japan, check digit not required", vin: "JHMCM56557C404453"},
+		{name: "europe, filler in position 9", vin: "WVWZZZ1JZXW000001"},
+		{name: "wrong check digit", vin: "5YJ3E1EA7KF317000", want: ErrInvalidCheckDigit},
+		{name: "digit instead of x", vin: "1M8GDM9A0KP042788", want: ErrInvalidCheckDigit},
+		{name: "china wrong check digit", vin: "LVSHCAMB2CE054249", want: ErrInvalidCheckDigit},
+		{name: "letter I", vin: "1HGCM82633A00435I", want: ErrInvalidCharacter},
+		{name: "letter O", vin: "1HGCM82633AO04352", want: ErrInvalidCharacter},
+		{name: "letter Q", vin: "WVWZZZ1JZXWQ00001", want: ErrInvalidCharacter},
+		{name: "lower case", vin: "1hgcm82633a004352", want: ErrInvalidCharacter},
+		{name: "punctuation", vin: "1HGCM8263-A004352", want: ErrInvalidCharacter},
+		{name: "too short", vin: "1HGCM82633A00435", want: ErrInvalidLength},
+		{name: "too long", vin: "1HGCM82633A0043521", want: ErrInvalidLength},
+		{name: "empty", vin: "", want: ErrInvalidLength},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.vin)
+		if tt.want == nil && err != nil {
+			t.Errorf("%s: Validate(%q) = %v, want nil", tt.name, tt.vin, err)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: Validate(%q) = %v, want %v", tt.name, tt.vin, err, tt.want)
+		}
+	}
+}
+
+func setNow(t *testing.T, year int) {
+	t.Helper()
+	now = func() time.Time { return time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestDecode(t *testing.T) {
+	setNow(t, 2026)
+
+	tests := []struct {
+		vin  string
+		want Info
+	}{
+		{
+			vin: "1HGCM82633A004352",
+			want: Info{
+				VIN: "1HGCM82633A004352", WMI: "1HG", Manufacturer: "Honda", Country: "United States",
+				Region: "North America", ModelYear: 2003, PlantCode: "A", Serial: "004352",
+			},
+		},
+		{
+			vin: " 5yj3e1ea2kf317000 ",
+			want: Info{
+				VIN: "5YJ3E1EA2KF317000", WMI: "5YJ", Manufacturer: "Tesla", Country: "United States",
+				Region: "North America", ModelYear: 2019, PlantCode: "F", Serial: "317000",
+			},
+		},
+		{
+			vin: "WVWZZZ1JZXW000001",
+			want: Info{
+				VIN: "WVWZZZ1JZXW000001", WMI: "WVW", Manufacturer: "Volkswagen", Country: "Germany",
+				Region: "Europe", ModelYear: 1999, PlantCode: "W", Serial: "000001",
+			},
+		},
+		{
+			// An unknown manufacturer still decodes.
+			vin: "9ZZZZZ1JZAW000001",
+			want: Info{
+				VIN: "9ZZZZZ1JZAW000001", WMI: "9ZZ", Region: "South America",
+				ModelYear: 2010, PlantCode: "W", Serial: "000001",
+			},
+		},
+		{
+			vin: "6ZZZZZ1JZTW000001",
+			want: Info{
+				VIN: "6ZZZZZ1JZTW000001", WMI: "6ZZ", Region: "Oceania",
+				ModelYear: 2026, PlantCode: "W", Serial: "000001",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Decode(tt.vin)
+		if err != nil {
+			t.Errorf("Decode(%q): %v", tt.vin, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Decode(%q) =\n%+v\nwant\n%+v", tt.vin, got, tt.want)
+		}
+	}
+
+	if _, err := Decode("5YJ3E1EA7KF317000"); !errors.Is(err, ErrInvalidCheckDigit) {
+		t.Errorf("Decode with a wrong check digit = %v, want ErrInvalidCheckDigit", err)
+	}
+}
+
+// TestDecodeModelYear covers the 30-year cycle of the year code: a code
+// resolves to its latest year that is not after next year.
+func TestDecodeModelYear(t *testing.T) {
+	tests := []struct {
+		now  int
+		code byte
+		want int
+	}{
+		{now: 2026, code: 'A', want: 2010},
+		{now: 2026, code: 'R', want: 2024},
+		{now: 2026, code: 'T', want: 2026},
+		{now: 2026, code: 'V', want: 2027},
+		{now: 2026, code: 'W', want: 1998},
+		{now: 2026, code: 'Y', want: 2000},
+		{now: 2026, code: '1', want: 2001},
+		{now: 2026, code: '9', want: 2009},
+		{now: 2008, code: '9', want: 2009},
+		// The first cycle starts in 1980, so there is no earlier year to
+		// fall back to.
+		{now: 2000, code: '9', want: 2009},
+		{now: 2039, code: 'A', want: 2040},
+		{now: 2038, code: 'A', want: 2010},
+		// Position 10 is 0 or a letter outside the code table on some
+		// vehicles; the year is then unknown.
+		{now: 2026, code: '0', want: 0},
+		{now: 2026, code: 'Z', want: 0},
+		{now: 2026, code: 'U', want: 0},
+	}
+	for _, tt := range tests {
+		setNow(t, tt.now)
+		vin := "WVWZZZ1JZ" + string(tt.code) + "W000001"
+		info, err := Decode(vin)
+		if err != nil {
+			t.Errorf("Decode(%q): %v", vin, err)
+			continue
+		}
+		if info.ModelYear != tt.want {
+			t.Errorf("year code %c in %d = %d, want %d", tt.code, tt.now, info.ModelYear, tt.want)
+		}
+	}
+}
+
+func TestMatchesYear(t *testing.T) {
+	tests := []struct {
+		modelYear int
+		year      int
+		want      bool
+	}{
+		{modelYear: 2003, year: 2003, want: true},
+		{modelYear: 2003, year: 1973},
+		{modelYear: 2033, year: 2003, want: true},
+		{modelYear: 2003, year: 2033, want: true},
+		{modelYear: 2003, year: 2004},
+		{modelYear: 2003, year: 2002},
+		{modelYear: 1980, year: 1980, want: true},
+		{modelYear: 1980, year: 1950},
+		{modelYear: 0, year: 2003},
+		{modelYear: 0, year: 0},
+	}
+	for _, tt := range tests {
+		if got := (Info{ModelYear: tt.modelYear}).MatchesYear(tt.year); got != tt.want {
+			t.Errorf("Info{ModelYear: %d}.MatchesYear(%d) = %v, want %v", tt.modelYear, tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestLoadWMI(t *testing.T) {
+	table := loadWMI(wmiCSV)
+	if len(table) < 50 {
+		t.Fatalf("bundled table has %d manufacturers", len(table))
+	}
+	if _, ok := table["wmi"]; ok {
+		t.Error("header row was loaded as a manufacturer")
+	}
+	for wmi, m := range table {
+		if len(wmi) != 3 || m.name == "" || m.country == "" {
+			t.Errorf("bad row %q: %+v", wmi, m)
+		}
+		if _, ok := value(wmi[0]); !ok {
+			t.Errorf("wmi %q starts with an invalid character", wmi)
+		}
+	}
+	tests := map[string]manufacturer{
+		"1HG": {name: "Honda", country: "United States"},
+		"JHM": {name: "Honda", country: "Japan"},
+		"WVW": {name: "Volkswagen", country: "Germany"},
+		"XTA": {name: "Lada", country: "Russia"},
+	}
+	for wmi, want := range tests {
+		if got := table[wmi]; got != want {
+			t.Errorf("table[%q] = %+v, want %+v", wmi, got, want)
+		}
+	}
+
+	small := loadWMI("wmi,manufacturer,country\nAAA,\"Maker, Inc.\",Nowhere\n")
+	if got := small["AAA"]; got != (manufacturer{name: "Maker, Inc.", country: "Nowhere"}) || len(small) != 1 {
+		t.Errorf("loadWMI parsed %+v", small)
+	}
+}
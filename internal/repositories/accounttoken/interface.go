@@ -0,0 +1,17 @@
+package accounttokenrepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	Create(ctx context.Context, token *entities.AccountToken) error
+	// Consume marks the unused, unexpired token with the given hash as used
+	// and returns it, so a token can be redeemed only once.
+	Consume(ctx context.Context, purpose, tokenHash string, now time.Time) (*entities.AccountToken, error)
+	// Invalidate marks every unused token of the user for purpose as used.
+	Invalidate(ctx context.Context, userID int, purpose string, now time.Time) error
+}
@@ -0,0 +1,66 @@
+package accounttokenrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(ctx context.Context, token *entities.AccountToken) error {
+	query := `
+		INSERT INTO account_tokens (user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+	err := r.conn(ctx).QueryRow(ctx, query,
+		token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt.UTC(), token.CreatedAt.UTC(),
+	).Scan(&token.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create account token: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Consume(ctx context.Context, purpose, tokenHash string, now time.Time) (*entities.AccountToken, error) {
+	query := `
+		UPDATE account_tokens SET used_at = $3
+		WHERE token_hash = $2 AND purpose = $1 AND used_at IS NULL AND expires_at > $3
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+	var t entities.AccountToken
+	err := r.conn(ctx).QueryRow(ctx, query, purpose, tokenHash, now.UTC()).Scan(
+		&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &t.UsedAt, &t.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrInvalidAccountToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume account token: %w", err)
+	}
+	return &t, nil
+}
+
+func (r *repository) Invalidate(ctx context.Context, userID int, purpose string, now time.Time) error {
+	query := `UPDATE account_tokens SET used_at = $3 WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+	if _, err := r.conn(ctx).Exec(ctx, query, userID, purpose, now.UTC()); err != nil {
+		return fmt.Errorf("failed to invalidate account tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
@@ -0,0 +1,21 @@
+package auditrepo
+
+import (
+	"context"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	// LockHead returns the hash of the newest entry and locks the chain head
+	// until the surrounding transaction ends. It must run in a transaction.
+	LockHead(ctx context.Context) (string, error)
+	// Append stores the event and moves the chain head to its hash.
+	Append(ctx context.Context, event *entities.AuditEvent) (int64, error)
+	// Head returns the hash of the newest entry without locking.
+	Head(ctx context.Context) (string, error)
+
+	List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error)
+	// ListAfter returns up to limit entries with an ID above afterID, oldest first.
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]entities.AuditEvent, error)
+}
@@ -0,0 +1,150 @@
+package auditrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+const eventColumns = `id, actor_id, actor_role, action, entity_type, entity_id, changes, request_id, ip_address, created_at, prev_hash, hash`
+
+func (r *repository) LockHead(ctx context.Context) (string, error) {
+	var hash string
+	query := `SELECT last_hash FROM audit_chain_head WHERE id = 1 FOR UPDATE`
+	if err := r.conn(ctx).QueryRow(ctx, query).Scan(&hash); err != nil {
+		return "", fmt.Errorf("failed to lock audit chain head: %w", err)
+	}
+	return hash, nil
+}
+
+func (r *repository) Append(ctx context.Context, event *entities.AuditEvent) (int64, error) {
+	query := `
+		INSERT INTO audit_events (actor_id, actor_role, action, entity_type, entity_id, changes, request_id, ip_address, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`
+	var id int64
+	err := r.conn(ctx).QueryRow(ctx, query,
+		event.ActorID, event.ActorRole, event.Action, event.EntityType, event.EntityID, string(event.Changes),
+		event.RequestID, event.IPAddress, event.CreatedAt.UTC(), event.PrevHash, event.Hash,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to append audit event: %w", err)
+	}
+
+	query = `UPDATE audit_chain_head SET last_hash = $1 WHERE id = 1 AND last_hash = $2`
+	tag, err := r.conn(ctx).Exec(ctx, query, event.Hash, event.PrevHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move audit chain head: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, fmt.Errorf("failed to move audit chain head: head is no longer %s", event.PrevHash)
+	}
+	return id, nil
+}
+
+func (r *repository) Head(ctx context.Context) (string, error) {
+	var hash string
+	query := `SELECT last_hash FROM audit_chain_head WHERE id = 1`
+	if err := r.conn(ctx).QueryRow(ctx, query).Scan(&hash); err != nil {
+		return "", fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	return hash, nil
+}
+
+func (r *repository) List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error) {
+	var whereClauses []string
+	var args []interface{}
+	argPos := 1
+
+	if filter.EntityType != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("entity_type = $%d", argPos))
+		args = append(args, filter.EntityType)
+		argPos++
+	}
+	if filter.EntityID > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("entity_id = $%d", argPos))
+		args = append(args, filter.EntityID)
+		argPos++
+	}
+	if filter.ActorID > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("actor_id = $%d", argPos))
+		args = append(args, filter.ActorID)
+		argPos++
+	}
+	if !filter.From.IsZero() {
+		whereClauses = append(whereClauses, fmt.Sprintf("created_at >= $%d", argPos))
+		args = append(args, filter.From.UTC())
+		argPos++
+	}
+	if !filter.To.IsZero() {
+		whereClauses = append(whereClauses, fmt.Sprintf("created_at < $%d", argPos))
+		args = append(args, filter.To.UTC())
+		argPos++
+	}
+	if filter.BeforeID > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("id < $%d", argPos))
+		args = append(args, filter.BeforeID)
+		argPos++
+	}
+
+	query := `SELECT ` + eventColumns + ` FROM audit_events`
+	if len(whereClauses) > 0 {
+		query += " WHERE " + strings.Join(whereClauses, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", argPos)
+	args = append(args, filter.Limit)
+
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	return scanEvents(rows)
+}
+
+func (r *repository) ListAfter(ctx context.Context, afterID int64, limit int) ([]entities.AuditEvent, error) {
+	query := `SELECT ` + eventColumns + ` FROM audit_events WHERE id > $1 ORDER BY id LIMIT $2`
+	rows, err := r.conn(ctx).Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	return scanEvents(rows)
+}
+
+func scanEvents(rows pgx.Rows) ([]entities.AuditEvent, error) {
+	defer rows.Close()
+
+	events := make([]entities.AuditEvent, 0)
+	for rows.Next() {
+		var e entities.AuditEvent
+		var changes string
+		err := rows.Scan(&e.ID, &e.ActorID, &e.ActorRole, &e.Action, &e.EntityType, &e.EntityID, &changes,
+			&e.RequestID, &e.IPAddress, &e.CreatedAt, &e.PrevHash, &e.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		e.Changes = []byte(changes)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
@@ -0,0 +1,72 @@
+package carrepo
+
+import (
+	"context"
+	"fmt"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/listquery"
+)
+
+// carFacets are the catalog sidebar filters, in display order. A facet added
+// here is computed and returned without further changes.
+var carFacets = []listquery.Facet{
+	{Name: "brand", Expr: "brand"},
+	{Name: "model", Expr: "model"},
+	{Name: "color", Expr: "color"},
+	{Name: "status", Expr: "status"},
+	{Name: "body_type", Expr: "nullif(body_type, '')"},
+	{Name: "fuel_type", Expr: "nullif(fuel_type, '')"},
+	{Name: "transmission", Expr: "nullif(transmission, '')"},
+	{Name: "drivetrain", Expr: "nullif(drivetrain, '')"},
+	{Name: "condition", Expr: "condition"},
+	{Name: "year", Expr: "year", Interval: 5},
+	{Name: "price", Expr: "price", Ranges: []listquery.Range{
+		{To: "10000"},
+		{From: "10000", To: "20000"},
+		{From: "20000", To: "35000"},
+		{From: "35000", To: "50000"},
+		{From: "50000", To: "100000"},
+		{From: "100000"},
+	}},
+	{Name: "mileage", Expr: "mileage", Ranges: []listquery.Range{
+		{To: "10000"},
+		{From: "10000", To: "50000"},
+		{From: "50000", To: "100000"},
+		{From: "100000", To: "150000"},
+		{From: "150000"},
+	}},
+}
+
+func (r *postgresRepo) Facets(ctx context.Context, filter entities.CarFilter) (entities.FacetSummary, error) {
+	q, err := listquery.New(carSort, entities.PageRequest{})
+	if err != nil {
+		return entities.FacetSummary{}, err
+	}
+	from := "cars"
+	if terms := searchTerms(filter.Query); len(terms) > 0 {
+		from, _ = applySearch(q, terms)
+	}
+	applyFilter(q, filter)
+
+	query, args := q.Facets("cars.*", from, carFacets)
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return entities.FacetSummary{}, fmt.Errorf("failed to count car facets: %w", err)
+	}
+	defer rows.Close()
+
+	counts := listquery.NewFacetCounts(carFacets)
+	for rows.Next() {
+		var index, count int
+		var bucket *string
+		if err := rows.Scan(&index, &bucket, &count); err != nil {
+			return entities.FacetSummary{}, fmt.Errorf("failed to scan car facet: %w", err)
+		}
+		counts.Add(index, bucket, count)
+	}
+	if err := rows.Err(); err != nil {
+		return entities.FacetSummary{}, fmt.Errorf("failed to count car facets: %w", err)
+	}
+	return counts.Summary(), nil
+}
@@ -8,8 +8,15 @@ import (
 type Repository interface {
 	Create(ctx context.Context, car *entities.Car) (int, error)
 	GetByID(ctx context.Context, id int) (*entities.Car, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*entities.Car, error)
 	Update(ctx context.Context, id int, update entities.CarUpdate) error
 	Delete(ctx context.Context, id int) error
-	List(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, error)
+	List(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, entities.PageInfo, error)
+	// Facets counts the cars matching filter per brand, model, price range
+	// and the other catalog facets, ignoring its page.
+	Facets(ctx context.Context, filter entities.CarFilter) (entities.FacetSummary, error)
 	SetStatus(ctx context.Context, id int, status string) error
+	// Suggest returns brands and brand/model pairs completing prefix, for
+	// autocomplete.
+	Suggest(ctx context.Context, prefix string, limit int) ([]entities.CarSuggestion, error)
 }
@@ -2,13 +2,16 @@ package carrepo
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
 
 	"myproject/internal/entities"
+	"myproject/internal/repositories/listquery"
+	"myproject/internal/repositories/txmanager"
 
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -20,60 +23,128 @@ func NewPostgresRepo(db *pgxpool.Pool) Repository {
 	return &postgresRepo{db: db}
 }
 
+const uniqueViolation = "23505"
+
+const carColumns = `id, brand, model, year, price, mileage, color, description, status,
+	coalesce(vin, ''), body_type, fuel_type, transmission, drivetrain, engine_volume::float8,
+	power_hp, doors, seats, condition, features, created_at, updated_at`
+
+// carFields returns the scan destinations matching carColumns.
+func carFields(car *entities.Car) []any {
+	return []any{
+		&car.ID, &car.Brand, &car.Model, &car.Year,
+		&car.Price, &car.Mileage, &car.Color, &car.Description, &car.Status,
+		&car.VIN, &car.BodyType, &car.FuelType, &car.Transmission, &car.Drivetrain, &car.EngineVolume,
+		&car.PowerHP, &car.Doors, &car.Seats, &car.Condition, &car.Features,
+		&car.CreatedAt, &car.UpdatedAt,
+	}
+}
+
+// features keeps a missing feature set from being stored as JSON null.
+func features(f map[string]any) map[string]any {
+	if f == nil {
+		return map[string]any{}
+	}
+	return f
+}
+
 func (r *postgresRepo) Create(ctx context.Context, car *entities.Car) (int, error) {
 	query := `
-		INSERT INTO cars (brand, model, year, price, mileage, color, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		INSERT INTO cars (brand, model, year, price, mileage, color, description, status,
+			vin, body_type, fuel_type, transmission, drivetrain, engine_volume,
+			power_hp, doors, seats, condition, features,
+			search_text, search_vector, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+			nullif($9, ''), $10, $11, $12, $13, $14,
+			$15, $16, $17, $18, $19,
+			car_search_text($1, $2, $3, $6, $7), car_search_vector($1, $2, $3, $6, $7), NOW(), NOW())
 		RETURNING id`
 
 	var id int
-	err := r.db.QueryRow(ctx, query,
+	err := r.conn(ctx).QueryRow(ctx, query,
 		car.Brand,
 		car.Model,
 		car.Year,
 		car.Price,
 		car.Mileage,
 		car.Color,
+		car.Description,
 		car.Status,
+		car.VIN,
+		car.BodyType,
+		car.FuelType,
+		car.Transmission,
+		car.Drivetrain,
+		car.EngineVolume,
+		car.PowerHP,
+		car.Doors,
+		car.Seats,
+		car.Condition,
+		features(car.Features),
 	).Scan(&id)
+	if isVINConflict(err) {
+		return 0, entities.ErrVINTaken
+	}
 	return id, err
 }
 
+func isVINConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == "idx_cars_vin"
+}
+
 func (r *postgresRepo) GetByID(ctx context.Context, id int) (*entities.Car, error) {
-	var car entities.Car
-	query := `
-		SELECT id, brand, model, year, price, mileage, color, status, created_at, updated_at
-		FROM cars WHERE id = $1`
+	query := `SELECT ` + carColumns + ` FROM cars WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&car.ID, &car.Brand, &car.Model, &car.Year,
-		&car.Price, &car.Mileage, &car.Color, &car.Status,
-		&car.CreatedAt, &car.UpdatedAt,
-	)
-	if errors.Is(err, sql.ErrNoRows) {
+func (r *postgresRepo) GetByIDForUpdate(ctx context.Context, id int) (*entities.Car, error) {
+	query := `SELECT ` + carColumns + ` FROM cars WHERE id = $1 FOR UPDATE`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *postgresRepo) getOne(ctx context.Context, query string, id int) (*entities.Car, error) {
+	var car entities.Car
+	err := r.conn(ctx).QueryRow(ctx, query, id).Scan(carFields(&car)...)
+	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
-	return &car, err
+	if err != nil {
+		return nil, err
+	}
+	return &car, nil
 }
 
 func (r *postgresRepo) Update(ctx context.Context, id int, update entities.CarUpdate) error {
 	var sets []string
 	var args []interface{}
 	argPos := 1
+	// searchArgs holds the new value of each search input, or the column
+	// itself when it is not changing.
+	searchArgs := map[string]string{
+		"brand": "brand", "model": "model", "year": "year", "color": "color", "description": "description",
+	}
+	searchChanged := false
 
 	if update.Brand != nil {
 		sets = append(sets, fmt.Sprintf("brand = $%d", argPos))
 		args = append(args, *update.Brand)
+		searchArgs["brand"] = fmt.Sprintf("$%d", argPos)
+		searchChanged = true
 		argPos++
 	}
 	if update.Model != nil {
 		sets = append(sets, fmt.Sprintf("model = $%d", argPos))
 		args = append(args, *update.Model)
+		searchArgs["model"] = fmt.Sprintf("$%d", argPos)
+		searchChanged = true
 		argPos++
 	}
 	if update.Year != nil {
 		sets = append(sets, fmt.Sprintf("year = $%d", argPos))
 		args = append(args, *update.Year)
+		searchArgs["year"] = fmt.Sprintf("$%d", argPos)
+		searchChanged = true
 		argPos++
 	}
 	if update.Price != nil {
@@ -89,6 +160,15 @@ func (r *postgresRepo) Update(ctx context.Context, id int, update entities.CarUp
 	if update.Color != nil {
 		sets = append(sets, fmt.Sprintf("color = $%d", argPos))
 		args = append(args, *update.Color)
+		searchArgs["color"] = fmt.Sprintf("$%d", argPos)
+		searchChanged = true
+		argPos++
+	}
+	if update.Description != nil {
+		sets = append(sets, fmt.Sprintf("description = $%d", argPos))
+		args = append(args, *update.Description)
+		searchArgs["description"] = fmt.Sprintf("$%d", argPos)
+		searchChanged = true
 		argPos++
 	}
 	if update.Status != nil {
@@ -96,15 +176,78 @@ func (r *postgresRepo) Update(ctx context.Context, id int, update entities.CarUp
 		args = append(args, *update.Status)
 		argPos++
 	}
+	if update.VIN != nil {
+		sets = append(sets, fmt.Sprintf("vin = nullif($%d, '')", argPos))
+		args = append(args, *update.VIN)
+		argPos++
+	}
+	if update.BodyType != nil {
+		sets = append(sets, fmt.Sprintf("body_type = $%d", argPos))
+		args = append(args, *update.BodyType)
+		argPos++
+	}
+	if update.FuelType != nil {
+		sets = append(sets, fmt.Sprintf("fuel_type = $%d", argPos))
+		args = append(args, *update.FuelType)
+		argPos++
+	}
+	if update.Transmission != nil {
+		sets = append(sets, fmt.Sprintf("transmission = $%d", argPos))
+		args = append(args, *update.Transmission)
+		argPos++
+	}
+	if update.Drivetrain != nil {
+		sets = append(sets, fmt.Sprintf("drivetrain = $%d", argPos))
+		args = append(args, *update.Drivetrain)
+		argPos++
+	}
+	if update.EngineVolume != nil {
+		sets = append(sets, fmt.Sprintf("engine_volume = $%d", argPos))
+		args = append(args, *update.EngineVolume)
+		argPos++
+	}
+	if update.PowerHP != nil {
+		sets = append(sets, fmt.Sprintf("power_hp = $%d", argPos))
+		args = append(args, *update.PowerHP)
+		argPos++
+	}
+	if update.Doors != nil {
+		sets = append(sets, fmt.Sprintf("doors = $%d", argPos))
+		args = append(args, *update.Doors)
+		argPos++
+	}
+	if update.Seats != nil {
+		sets = append(sets, fmt.Sprintf("seats = $%d", argPos))
+		args = append(args, *update.Seats)
+		argPos++
+	}
+	if update.Condition != nil {
+		sets = append(sets, fmt.Sprintf("condition = $%d", argPos))
+		args = append(args, *update.Condition)
+		argPos++
+	}
+	if update.Features != nil {
+		sets = append(sets, fmt.Sprintf("features = $%d", argPos))
+		args = append(args, features(*update.Features))
+		argPos++
+	}
 
 	if len(sets) == 0 {
 		return nil // No fields to update
 	}
+	if searchChanged {
+		inputs := fmt.Sprintf("%s, %s, %s, %s, %s",
+			searchArgs["brand"], searchArgs["model"], searchArgs["year"], searchArgs["color"], searchArgs["description"])
+		sets = append(sets, "search_text = car_search_text("+inputs+")", "search_vector = car_search_vector("+inputs+")")
+	}
 
 	query := fmt.Sprintf("UPDATE cars SET %s, updated_at = NOW() WHERE id = $%d", strings.Join(sets, ", "), argPos)
 	args = append(args, id)
 
-	_, err := r.db.Exec(ctx, query, args...)
+	_, err := r.conn(ctx).Exec(ctx, query, args...)
+	if isVINConflict(err) {
+		return entities.ErrVINTaken
+	}
 	return err
 }
 
@@ -112,99 +255,138 @@ func (r *postgresRepo) Delete(ctx context.Context, id int) error {
 	query := `
 		DELETE FROM cars
 		WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+	_, err := r.conn(ctx).Exec(ctx, query, id)
 	return err
 }
 
-func (r *postgresRepo) List(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, error) {
-	var cars []*entities.Car
-	var whereClauses []string
-	var args []interface{}
-	argPos := 1
+// carSort lists the fields the catalog can be sorted by. Each has an index
+// ending in id so keyset pages stay cheap.
+var carSort = &listquery.Spec{
+	Columns: map[string]listquery.Column{
+		"id":         {Expr: "id", Type: "int"},
+		"price":      {Expr: "price", Type: "numeric"},
+		"year":       {Expr: "year", Type: "int"},
+		"mileage":    {Expr: "mileage", Type: "int"},
+		"brand":      {Expr: "brand", Type: "text"},
+		"created_at": {Expr: "created_at", Type: "timestamp"},
+	},
+	ID:           listquery.Column{Expr: "id", Type: "int"},
+	DefaultSort:  "-created_at",
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
 
+func (r *postgresRepo) List(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, entities.PageInfo, error) {
+	spec := carSort
+	terms := searchTerms(filter.Query)
+	if len(terms) > 0 {
+		spec = carSearchSort
+	}
+	q, err := listquery.New(spec, filter.Page)
+	if err != nil {
+		return nil, entities.PageInfo{}, err
+	}
+
+	from, columns := "cars", carColumns+", ''"
+	if len(terms) > 0 {
+		from, columns = applySearch(q, terms)
+	}
+	applyFilter(q, filter)
+
+	var total int
+	countQuery, countArgs := q.Count(from)
+	if err := r.conn(ctx).QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, entities.PageInfo{}, fmt.Errorf("failed to count cars: %w", err)
+	}
+
+	query, args := q.Select(columns, from)
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, entities.PageInfo{}, fmt.Errorf("failed to list cars: %w", err)
+	}
+	defer rows.Close()
+
+	cars := make([]*entities.Car, 0)
+	for rows.Next() {
+		var car entities.Car
+		if err := rows.Scan(q.Dest(append(carFields(&car), &car.Highlight)...)...); err != nil {
+			return nil, entities.PageInfo{}, fmt.Errorf("failed to scan car: %w", err)
+		}
+		cars = append(cars, &car)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, entities.PageInfo{}, fmt.Errorf("failed to list cars: %w", err)
+	}
+
+	n, page := q.Page(total)
+	return cars[:n], page, nil
+}
+
+// applyFilter adds the attribute filters shared by List and Facets.
+func applyFilter(q *listquery.Query, filter entities.CarFilter) {
 	if filter.Brand != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("brand = $%d", argPos))
-		args = append(args, *filter.Brand)
-		argPos++
+		q.Where("brand = " + q.Arg(*filter.Brand))
 	}
 	if filter.Model != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("model = $%d", argPos))
-		args = append(args, *filter.Model)
-		argPos++
+		q.Where("model = " + q.Arg(*filter.Model))
 	}
 	if filter.MinPrice != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("price >= $%d", argPos))
-		args = append(args, *filter.MinPrice)
-		argPos++
+		q.Where("price >= " + q.Arg(*filter.MinPrice))
 	}
 	if filter.MaxPrice != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("price <= $%d", argPos))
-		args = append(args, *filter.MaxPrice)
-		argPos++
+		q.Where("price <= " + q.Arg(*filter.MaxPrice))
 	}
 	if filter.YearFrom != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("year >= $%d", argPos))
-		args = append(args, *filter.YearFrom)
-		argPos++
+		q.Where("year >= " + q.Arg(*filter.YearFrom))
 	}
 	if filter.YearTo != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("year <= $%d", argPos))
-		args = append(args, *filter.YearTo)
-		argPos++
+		q.Where("year <= " + q.Arg(*filter.YearTo))
 	}
 	if filter.Status != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("status = $%d", argPos))
-		args = append(args, *filter.Status)
-		argPos++
+		q.Where("status = " + q.Arg(*filter.Status))
 	}
 	if filter.Color != nil {
-		whereClauses = append(whereClauses, fmt.Sprintf("color = $%d", argPos))
-		args = append(args, *filter.Color)
-		argPos++
+		q.Where("color = " + q.Arg(*filter.Color))
 	}
-
-	query := `
-		SELECT id, brand, model, year, price, mileage, color, status, created_at, updated_at
-		FROM cars`
-
-	if len(whereClauses) > 0 {
-		query += " WHERE " + strings.Join(whereClauses, " AND ")
+	if filter.BodyType != nil {
+		q.Where("body_type = " + q.Arg(*filter.BodyType))
 	}
-
-	if filter.SortBy != nil {
-		sortOrder := "ASC"
-		if filter.SortOrder != nil && strings.ToUpper(*filter.SortOrder) == "DESC" {
-			sortOrder = "DESC"
-		}
-		query += fmt.Sprintf(" ORDER BY %s %s", *filter.SortBy, sortOrder)
+	if filter.FuelType != nil {
+		q.Where("fuel_type = " + q.Arg(*filter.FuelType))
 	}
-
-	if filter.Limit != nil {
-		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	if filter.Transmission != nil {
+		q.Where("transmission = " + q.Arg(*filter.Transmission))
 	}
-	if filter.Offset != nil {
-		query += fmt.Sprintf(" OFFSET %d", filter.Offset)
+	if filter.Drivetrain != nil {
+		q.Where("drivetrain = " + q.Arg(*filter.Drivetrain))
 	}
-
-	rows, err := r.db.Query(ctx, query, args...)
-	if err != nil {
-		return nil, err
+	if filter.Condition != nil {
+		q.Where("condition = " + q.Arg(*filter.Condition))
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var car entities.Car
-		if err := rows.Scan(
-			&car.ID, &car.Brand, &car.Model, &car.Year,
-			&car.Price, &car.Mileage, &car.Color, &car.Status,
-			&car.CreatedAt, &car.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		cars = append(cars, &car)
+	if filter.MinEngineVolume != nil {
+		q.Where("engine_volume >= " + q.Arg(*filter.MinEngineVolume))
+	}
+	if filter.MaxEngineVolume != nil {
+		q.Where("engine_volume <= " + q.Arg(*filter.MaxEngineVolume))
+	}
+	if filter.MinPower != nil {
+		q.Where("power_hp >= " + q.Arg(*filter.MinPower))
+	}
+	if filter.MaxPower != nil {
+		q.Where("power_hp <= " + q.Arg(*filter.MaxPower))
+	}
+	if filter.MinSeats != nil {
+		q.Where("seats >= " + q.Arg(*filter.MinSeats))
+	}
+	if filter.Doors != nil {
+		q.Where("doors = " + q.Arg(*filter.Doors))
+	}
+	if filter.VIN != nil {
+		q.Where("vin = " + q.Arg(strings.ToUpper(strings.TrimSpace(*filter.VIN))))
+	}
+	if len(filter.Features) > 0 {
+		q.Where("features ?& " + q.Arg(filter.Features) + "::text[]")
 	}
-
-	return cars, nil
 }
 
 func (r *postgresRepo) SetStatus(ctx context.Context, id int, status string) error {
@@ -212,12 +394,16 @@ func (r *postgresRepo) SetStatus(ctx context.Context, id int, status string) err
 		UPDATE cars
 		SET status = $2, updated_at = NOW()
 		WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id, status)
+	_, err := r.conn(ctx).Exec(ctx, query, id, status)
 	return err
 }
 
 var (
-	ErrNotFound      = errors.New("car not found")
+	ErrNotFound      = entities.ErrNotFound
 	ErrInvalidID     = errors.New("invalid car ID")
 	ErrInvalidStatus = errors.New("invalid car status")
 )
+
+func (r *postgresRepo) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
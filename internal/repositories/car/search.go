@@ -0,0 +1,117 @@
+package carrepo
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/listquery"
+)
+
+// maxSearchTerms caps how many conditions one query string can add.
+const maxSearchTerms = 8
+
+// headlineOptions wraps matches in <mark> and keeps the snippet short enough
+// for a result card.
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=8, MaxFragments=2"
+
+// headlineSource is the text snippets are cut from, HTML-escaped so that the
+// <mark> tags ts_headline adds are the only markup in the result.
+const headlineSource = `replace(replace(replace(replace(replace(concat_ws(' ', brand, model, description), ` +
+	`'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+
+// relevanceExpr scores a row against the search subquery joined by
+// applySearch: full-text rank for exact and prefix matches plus trigram
+// similarity, which is what lifts misspelt queries.
+const relevanceExpr = "(ts_rank(search_vector, search.tsq) + word_similarity(search.q, search_text))"
+
+var termPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// carSearchSort is carSort plus relevance, the default order of searches.
+var carSearchSort = func() *listquery.Spec {
+	spec := *carSort
+	spec.Columns = maps.Clone(carSort.Columns)
+	spec.Columns["relevance"] = listquery.Column{Expr: relevanceExpr, Type: "real"}
+	spec.DefaultSort = "-relevance"
+	return &spec
+}()
+
+// searchTerms splits the query into lower-case words. Punctuation is dropped,
+// which also keeps the terms safe to use inside tsquery syntax.
+func searchTerms(query *string) []string {
+	if query == nil {
+		return nil
+	}
+	terms := termPattern.FindAllString(strings.ToLower(*query), -1)
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// applySearch requires every term to match, either as a word prefix or
+// fuzzily, and returns the FROM clause and columns that add ranking and the
+// highlight.
+func applySearch(q *listquery.Query, terms []string) (from, columns string) {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		arg := q.Arg(term)
+		q.Where("(search_vector @@ to_tsquery('simple', " + arg + "::text || ':*') OR " + arg + "::text <% search_text)")
+		prefixes[i] = term + ":*"
+	}
+
+	from = "cars, (SELECT " + q.Arg(strings.Join(terms, " ")) + "::text AS q, " +
+		"to_tsquery('simple', " + q.Arg(strings.Join(prefixes, " | ")) + ") AS tsq) search"
+	columns = carColumns + ", ts_headline('simple', " + headlineSource + ", search.tsq, '" + headlineOptions + "')"
+	return from, columns
+}
+
+func (r *postgresRepo) Suggest(ctx context.Context, prefix string, limit int) ([]entities.CarSuggestion, error) {
+	prefix = strings.ToLower(prefix)
+	pattern := escapeLike(prefix)
+
+	// A suggestion matches when it starts with the prefix, has a word that
+	// does ("cam" finds "Toyota Camry"), or is close enough to it to catch
+	// typos. Prefix matches come first.
+	query := `
+		WITH candidates AS (
+			SELECT brand AS text, $5::text AS kind FROM cars
+			UNION ALL
+			SELECT brand || ' ' || model, $6::text FROM cars
+		)
+		SELECT text, kind, count(*)
+		FROM candidates
+		WHERE lower(text) LIKE $2 OR lower(text) LIKE $3 OR $1 <% lower(text)
+		GROUP BY text, kind
+		ORDER BY lower(text) LIKE $2 DESC, word_similarity($1, lower(text)) DESC, count(*) DESC, text
+		LIMIT $4`
+	rows, err := r.conn(ctx).Query(ctx, query,
+		prefix, pattern+"%", "% "+pattern+"%", limit,
+		entities.CarSuggestionBrand, entities.CarSuggestionModel,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest cars: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := make([]entities.CarSuggestion, 0)
+	for rows.Next() {
+		var s entities.CarSuggestion
+		if err := rows.Scan(&s.Text, &s.Kind, &s.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan suggestion: %w", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to suggest cars: %w", err)
+	}
+	return suggestions, nil
+}
+
+// escapeLike makes s match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
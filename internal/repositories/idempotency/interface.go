@@ -0,0 +1,21 @@
+package idempotencyrepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	// Acquire inserts a processing record, replacing an expired one with the
+	// same key. It reports false if a live record already holds the key.
+	Acquire(ctx context.Context, record *entities.IdempotencyRecord) (bool, error)
+	Get(ctx context.Context, userID int, key string) (*entities.IdempotencyRecord, error)
+	// TakeOver claims a processing record last touched before staleBefore,
+	// which happens when the original request died mid-flight.
+	TakeOver(ctx context.Context, userID int, key string, now, staleBefore time.Time) (bool, error)
+	Complete(ctx context.Context, userID int, key string, status int, body []byte, contentType string) error
+	Delete(ctx context.Context, userID int, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
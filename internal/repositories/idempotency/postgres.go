@@ -0,0 +1,116 @@
+package idempotencyrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Acquire(ctx context.Context, record *entities.IdempotencyRecord) (bool, error) {
+	_, err := r.conn(ctx).Exec(ctx,
+		`DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND expires_at <= $3`,
+		record.UserID, record.Key, record.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to clear expired idempotency key: %w", err)
+	}
+
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, fingerprint, status, created_at, updated_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $5, $6)
+		ON CONFLICT (user_id, key) DO NOTHING`
+	tag, err := r.conn(ctx).Exec(ctx, query,
+		record.UserID, record.Key, record.Fingerprint, record.Status,
+		record.CreatedAt.UTC(), record.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *repository) Get(ctx context.Context, userID int, key string) (*entities.IdempotencyRecord, error) {
+	query := `
+		SELECT user_id, key, fingerprint, status, coalesce(response_status, 0), coalesce(response_body, ''::bytea),
+			content_type, created_at, updated_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`
+	var record entities.IdempotencyRecord
+	err := r.conn(ctx).QueryRow(ctx, query, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.Fingerprint,
+		&record.Status,
+		&record.ResponseStatus,
+		&record.ResponseBody,
+		&record.ContentType,
+		&record.CreatedAt,
+		&record.UpdatedAt,
+		&record.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &record, nil
+}
+
+func (r *repository) TakeOver(ctx context.Context, userID int, key string, now, staleBefore time.Time) (bool, error) {
+	query := `
+		UPDATE idempotency_keys SET updated_at = $5
+		WHERE user_id = $1 AND key = $2 AND status = $3 AND updated_at < $4`
+	tag, err := r.conn(ctx).Exec(ctx, query, userID, key, entities.IdempotencyStatusProcessing, staleBefore.UTC(), now.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to take over idempotency key: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *repository) Complete(ctx context.Context, userID int, key string, status int, body []byte, contentType string) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status = $1, response_status = $2, response_body = $3, content_type = $4, updated_at = $7
+		WHERE user_id = $5 AND key = $6`
+	_, err := r.conn(ctx).Exec(ctx, query, entities.IdempotencyStatusCompleted, status, body, contentType, userID, key, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, userID int, key string) error {
+	_, err := r.conn(ctx).Exec(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
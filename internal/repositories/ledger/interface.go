@@ -0,0 +1,34 @@
+package ledgerrepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
+)
+
+type Repository interface {
+	GetAccountByCode(ctx context.Context, code string) (*entities.Account, error)
+	// EnsureWallet returns the wallet account of the user, creating it on first use.
+	EnsureWallet(ctx context.Context, userID int) (*entities.Account, error)
+	// CreateEntry inserts the entry with its postings and returns the entry ID.
+	// It does not touch account balances.
+	CreateEntry(ctx context.Context, entry *entities.JournalEntry) (int, error)
+	// AddToBalance adds amount to the cached account balance and returns the
+	// updated account. The row stays locked until the transaction ends.
+	AddToBalance(ctx context.Context, accountID int, amount money.Money) (*entities.Account, error)
+	// SyncUserBalance copies a wallet balance into the users.balance projection.
+	SyncUserBalance(ctx context.Context, userID int, balance money.Money) error
+	// SumPostings returns the sum of the account's postings made before t.
+	SumPostings(ctx context.Context, accountID int, before time.Time) (money.Money, error)
+	// ListPostings returns the account's postings in [from, to) joined with
+	// their entries, oldest first. Line amounts are raw posting amounts.
+	ListPostings(ctx context.Context, accountID int, from, to time.Time) ([]entities.StatementLine, error)
+	// ListWalletDrift returns wallets whose users.balance or cached account
+	// balance disagrees with the sum of their postings.
+	ListWalletDrift(ctx context.Context) ([]entities.BalanceDrift, error)
+	// ListSystemDrift returns system accounts whose cached balance disagrees
+	// with the sum of their postings.
+	ListSystemDrift(ctx context.Context) ([]entities.BalanceDrift, error)
+}
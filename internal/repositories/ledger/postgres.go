@@ -0,0 +1,205 @@
+package ledgerrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+const accountColumns = `id, code, type, user_id, name, balance, created_at`
+
+func (r *repository) GetAccountByCode(ctx context.Context, code string) (*entities.Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM ledger_accounts WHERE code = $1`
+	account, err := scanAccount(r.conn(ctx).QueryRow(ctx, query, code))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger account: %w", err)
+	}
+	return account, nil
+}
+
+func (r *repository) EnsureWallet(ctx context.Context, userID int) (*entities.Account, error) {
+	code := entities.WalletAccountCode(userID)
+	query := `
+		INSERT INTO ledger_accounts (code, type, user_id, name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code) DO NOTHING`
+	_, err := r.conn(ctx).Exec(ctx, query, code, entities.AccountTypeLiability, userID, fmt.Sprintf("Customer wallet #%d", userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet account: %w", err)
+	}
+	return r.GetAccountByCode(ctx, code)
+}
+
+func (r *repository) CreateEntry(ctx context.Context, entry *entities.JournalEntry) (int, error) {
+	query := `
+		INSERT INTO journal_entries (type, reference, description, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+	var id int
+	err := r.conn(ctx).QueryRow(ctx, query, entry.Type, entry.Reference, entry.Description, entry.CreatedAt.UTC()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert journal entry: %w", err)
+	}
+
+	for i := range entry.Postings {
+		posting := &entry.Postings[i]
+		err := r.conn(ctx).QueryRow(ctx,
+			`INSERT INTO ledger_postings (entry_id, account_id, amount) VALUES ($1, $2, $3) RETURNING id`,
+			id, posting.AccountID, posting.Amount,
+		).Scan(&posting.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert posting: %w", err)
+		}
+		posting.EntryID = id
+	}
+	return id, nil
+}
+
+func (r *repository) AddToBalance(ctx context.Context, accountID int, amount money.Money) (*entities.Account, error) {
+	query := `UPDATE ledger_accounts SET balance = balance + $1 WHERE id = $2 RETURNING ` + accountColumns
+	account, err := scanAccount(r.conn(ctx).QueryRow(ctx, query, amount, accountID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account balance: %w", err)
+	}
+	return account, nil
+}
+
+func (r *repository) SyncUserBalance(ctx context.Context, userID int, balance money.Money) error {
+	_, err := r.conn(ctx).Exec(ctx, `UPDATE users SET balance = $1, updated_at = current_timestamp WHERE id = $2`, balance, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user balance: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) SumPostings(ctx context.Context, accountID int, before time.Time) (money.Money, error) {
+	query := `
+		SELECT coalesce(sum(p.amount), 0)
+		FROM ledger_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE p.account_id = $1 AND e.created_at < $2`
+	var sum money.Money
+	if err := r.conn(ctx).QueryRow(ctx, query, accountID, before.UTC()).Scan(&sum); err != nil {
+		return money.Money{}, fmt.Errorf("failed to sum postings: %w", err)
+	}
+	return sum, nil
+}
+
+func (r *repository) ListPostings(ctx context.Context, accountID int, from, to time.Time) ([]entities.StatementLine, error) {
+	query := `
+		SELECT e.id, e.type, e.reference, e.description, p.amount, e.created_at
+		FROM ledger_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE p.account_id = $1 AND e.created_at >= $2 AND e.created_at < $3
+		ORDER BY e.created_at, e.id`
+	rows, err := r.conn(ctx).Query(ctx, query, accountID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query postings: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []entities.StatementLine
+	for rows.Next() {
+		var line entities.StatementLine
+		if err := rows.Scan(&line.EntryID, &line.Type, &line.Reference, &line.Description, &line.Amount, &line.CreatedAt); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+func (r *repository) ListWalletDrift(ctx context.Context) ([]entities.BalanceDrift, error) {
+	query := `
+		SELECT a.id, a.code, a.user_id, u.balance, -coalesce(sum(p.amount), 0) AS ledger_balance
+		FROM ledger_accounts a
+		JOIN users u ON u.id = a.user_id
+		LEFT JOIN ledger_postings p ON p.account_id = a.id
+		GROUP BY a.id, a.user_id, u.balance, a.balance
+		HAVING u.balance <> -coalesce(sum(p.amount), 0) OR a.balance <> coalesce(sum(p.amount), 0)
+		ORDER BY a.user_id`
+	rows, err := r.conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wallet drift: %w", err)
+	}
+	defer rows.Close()
+
+	var drift []entities.BalanceDrift
+	for rows.Next() {
+		var d entities.BalanceDrift
+		if err := rows.Scan(&d.AccountID, &d.Code, &d.UserID, &d.CachedBalance, &d.LedgerBalance); err != nil {
+			return nil, err
+		}
+		drift = append(drift, d)
+	}
+	return drift, rows.Err()
+}
+
+func (r *repository) ListSystemDrift(ctx context.Context) ([]entities.BalanceDrift, error) {
+	query := `
+		SELECT a.id, a.code, a.balance, coalesce(sum(p.amount), 0) AS ledger_balance
+		FROM ledger_accounts a
+		LEFT JOIN ledger_postings p ON p.account_id = a.id
+		WHERE a.user_id IS NULL
+		GROUP BY a.id, a.code, a.balance
+		HAVING a.balance <> coalesce(sum(p.amount), 0)
+		ORDER BY a.id`
+	rows, err := r.conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query system account drift: %w", err)
+	}
+	defer rows.Close()
+
+	var drift []entities.BalanceDrift
+	for rows.Next() {
+		var d entities.BalanceDrift
+		if err := rows.Scan(&d.AccountID, &d.Code, &d.CachedBalance, &d.LedgerBalance); err != nil {
+			return nil, err
+		}
+		drift = append(drift, d)
+	}
+	return drift, rows.Err()
+}
+
+func scanAccount(row pgx.Row) (*entities.Account, error) {
+	var account entities.Account
+	err := row.Scan(
+		&account.ID,
+		&account.Code,
+		&account.Type,
+		&account.UserID,
+		&account.Name,
+		&account.Balance,
+		&account.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
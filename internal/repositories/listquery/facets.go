@@ -0,0 +1,190 @@
+package listquery
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"myproject/internal/entities"
+)
+
+// defaultTermsLimit caps the buckets of a terms facet without a Limit.
+const defaultTermsLimit = 50
+
+// Facet declares one breakdown of a list. Set Ranges for fixed intervals or
+// Interval for equal-width buckets of an integer expression; otherwise every
+// distinct value is a bucket. Expr and the bounds are written into the SQL,
+// so like Column they must come from code.
+type Facet struct {
+	Name     string
+	Expr     string
+	Ranges   []Range
+	Interval int
+	// Limit caps the buckets of a terms facet, most frequent first.
+	Limit int
+}
+
+// Range is a half-open interval; an empty bound is open.
+type Range struct {
+	From string
+	To   string
+}
+
+func (f Facet) kind() string {
+	switch {
+	case len(f.Ranges) > 0:
+		return entities.FacetRange
+	case f.Interval > 0:
+		return entities.FacetHistogram
+	default:
+		return entities.FacetTerms
+	}
+}
+
+// bucketExpr maps a row to its bucket key: the value itself, the index of
+// its range, or the lower bound of its histogram bucket.
+func (f Facet) bucketExpr() string {
+	switch f.kind() {
+	case entities.FacetRange:
+		var sql strings.Builder
+		sql.WriteString("CASE")
+		for i, r := range f.Ranges {
+			var conds []string
+			if r.From != "" {
+				conds = append(conds, fmt.Sprintf("(%s) >= %s", f.Expr, mustNumber(f.Name, r.From)))
+			}
+			if r.To != "" {
+				conds = append(conds, fmt.Sprintf("(%s) < %s", f.Expr, mustNumber(f.Name, r.To)))
+			}
+			if len(conds) == 0 {
+				conds = append(conds, "TRUE")
+			}
+			fmt.Fprintf(&sql, " WHEN %s THEN '%d'", strings.Join(conds, " AND "), i)
+		}
+		sql.WriteString(" END")
+		return sql.String()
+	case entities.FacetHistogram:
+		return fmt.Sprintf("(((%s) / %d) * %d)::text", f.Expr, f.Interval, f.Interval)
+	default:
+		return fmt.Sprintf("(%s)::text", f.Expr)
+	}
+}
+
+// mustNumber panics on a bound that is not a number; bounds are part of the
+// facet definitions, so that is a programming error.
+func mustNumber(facet, s string) string {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		panic(fmt.Sprintf("listquery: facet %s has non-numeric bound %q", facet, s))
+	}
+	return s
+}
+
+// Facets returns one statement that counts the filtered rows per bucket of
+// every facet, reading the matching rows once. columns must include what the
+// facet expressions refer to. Each result row is (facet index, bucket key,
+// count); index -1 carries the total. Pass the rows to FacetCounts.
+func (q *Query) Facets(columns, from string, facets []Facet) (string, []any) {
+	var sql strings.Builder
+	sql.WriteString("WITH filtered AS MATERIALIZED (SELECT " + columns + " FROM " + from + whereClause(q.where) + ")")
+	sql.WriteString(" SELECT -1, NULL, COUNT(*) FROM filtered")
+	for i, f := range facets {
+		limit := ""
+		if f.kind() == entities.FacetTerms {
+			n := f.Limit
+			if n <= 0 {
+				n = defaultTermsLimit
+			}
+			limit = fmt.Sprintf(" ORDER BY 3 DESC, 2 LIMIT %d", n)
+		}
+		fmt.Fprintf(&sql, " UNION ALL (SELECT %d, bucket, COUNT(*) FROM (SELECT %s AS bucket FROM filtered) b WHERE bucket IS NOT NULL GROUP BY bucket%s)",
+			i, f.bucketExpr(), limit)
+	}
+	return sql.String(), q.args
+}
+
+// FacetCounts assembles the rows of a Facets statement.
+type FacetCounts struct {
+	facets []Facet
+	total  int
+	counts []map[string]int
+}
+
+func NewFacetCounts(facets []Facet) *FacetCounts {
+	counts := make([]map[string]int, len(facets))
+	for i := range counts {
+		counts[i] = make(map[string]int)
+	}
+	return &FacetCounts{facets: facets, counts: counts}
+}
+
+// Add records one result row.
+func (c *FacetCounts) Add(index int, bucket *string, count int) {
+	if index < 0 || bucket == nil {
+		c.total = count
+		return
+	}
+	if index < len(c.counts) {
+		c.counts[index][*bucket] = count
+	}
+}
+
+// Summary lists terms buckets by count, histogram buckets in order, and every
+// range bucket in declaration order, including empty ones.
+func (c *FacetCounts) Summary() entities.FacetSummary {
+	summary := entities.FacetSummary{Total: c.total, Facets: make([]entities.Facet, 0, len(c.facets))}
+	for i, f := range c.facets {
+		facet := entities.Facet{Name: f.Name, Type: f.kind(), Buckets: make([]entities.FacetBucket, 0)}
+		counts := c.counts[i]
+		switch facet.Type {
+		case entities.FacetRange:
+			for j, r := range f.Ranges {
+				facet.Buckets = append(facet.Buckets, entities.FacetBucket{
+					Value: rangeLabel(r.From, r.To),
+					From:  r.From,
+					To:    r.To,
+					Count: counts[strconv.Itoa(j)],
+				})
+			}
+		case entities.FacetHistogram:
+			lows := make([]int, 0, len(counts))
+			for key := range counts {
+				if low, err := strconv.Atoi(key); err == nil {
+					lows = append(lows, low)
+				}
+			}
+			sort.Ints(lows)
+			for _, low := range lows {
+				from, to := strconv.Itoa(low), strconv.Itoa(low+f.Interval)
+				facet.Buckets = append(facet.Buckets, entities.FacetBucket{
+					Value: rangeLabel(from, to),
+					From:  from,
+					To:    to,
+					Count: counts[from],
+				})
+			}
+		default:
+			for value, count := range counts {
+				facet.Buckets = append(facet.Buckets, entities.FacetBucket{Value: value, Count: count})
+			}
+			sort.Slice(facet.Buckets, func(a, b int) bool {
+				if facet.Buckets[a].Count != facet.Buckets[b].Count {
+					return facet.Buckets[a].Count > facet.Buckets[b].Count
+				}
+				return facet.Buckets[a].Value < facet.Buckets[b].Value
+			})
+		}
+		summary.Facets = append(summary.Facets, facet)
+	}
+	return summary
+}
+
+func rangeLabel(from, to string) string {
+	if from == "" {
+		from = "*"
+	}
+	if to == "" {
+		to = "*"
+	}
+	return from + "-" + to
+}
@@ -0,0 +1,243 @@
+// Package listquery builds the SQL behind paginated list endpoints: filters
+// with numbered arguments, sorting restricted to whitelisted columns, and
+// keyset pagination with opaque cursors.
+package listquery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"myproject/internal/entities"
+)
+
+// Column is a sortable field. Expr is used verbatim in SQL, so it must come
+// from code, never from the request, and must not be NULL: keyset comparisons
+// do not order NULLs. Type is what cursor values are cast back to.
+type Column struct {
+	Expr string
+	Type string
+}
+
+// Spec describes the sortable fields of one list.
+type Spec struct {
+	// Columns maps the names accepted in PageRequest.Sort to columns.
+	Columns map[string]Column
+	// ID is a unique column appended to every sort so the order is total.
+	ID           Column
+	DefaultSort  string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+type sortKey struct {
+	column Column
+	desc   bool
+}
+
+// cursor is what the opaque cursor string encodes: the sort it was issued
+// for and the sort key values of the last item on the page.
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// Query accumulates the conditions and arguments of one list query.
+type Query struct {
+	sort   string
+	keys   []sortKey
+	after  []string
+	limit  int
+	offset int
+
+	where []string
+	args  []any
+	// rowKeys holds the sort key values of each scanned row.
+	rowKeys [][]string
+}
+
+// New validates page against spec. Errors wrap entities.ErrInvalidSort,
+// ErrInvalidCursor or ErrInvalidPage.
+func New(spec *Spec, page entities.PageRequest) (*Query, error) {
+	q := &Query{limit: page.Limit, offset: page.Offset}
+	if q.limit <= 0 {
+		q.limit = spec.DefaultLimit
+	}
+	if q.limit > spec.MaxLimit {
+		return nil, fmt.Errorf("%w: limit must not exceed %d", entities.ErrInvalidPage, spec.MaxLimit)
+	}
+	if q.offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", entities.ErrInvalidPage)
+	}
+
+	sort := page.Sort
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	seen := make(map[string]bool)
+	var canonical []string
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(field, "-"), "+")
+		column, ok := spec.Columns[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot sort by %q", entities.ErrInvalidSort, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %q listed twice", entities.ErrInvalidSort, name)
+		}
+		seen[name] = true
+		q.keys = append(q.keys, sortKey{column: column, desc: desc})
+		if desc {
+			name = "-" + name
+		}
+		canonical = append(canonical, name)
+	}
+	q.sort = strings.Join(canonical, ",")
+	// The tiebreaker follows the direction of the first key so an index on
+	// (key, id) can serve the whole sort.
+	if last := q.keys[len(q.keys)-1]; last.column != spec.ID {
+		q.keys = append(q.keys, sortKey{column: spec.ID, desc: q.keys[0].desc})
+	}
+
+	if page.Cursor != "" {
+		if q.offset > 0 {
+			return nil, fmt.Errorf("%w: cursor and offset cannot be combined", entities.ErrInvalidPage)
+		}
+		c, err := decodeCursor(page.Cursor)
+		if err != nil || c.Sort != q.sort || len(c.Values) != len(q.keys) {
+			return nil, fmt.Errorf("%w: it does not match this list or sort", entities.ErrInvalidCursor)
+		}
+		q.after = c.Values
+	}
+	return q, nil
+}
+
+// Limit returns the page size in effect.
+func (q *Query) Limit() int {
+	return q.limit
+}
+
+// Arg adds a query argument and returns its placeholder.
+func (q *Query) Arg(value any) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// Where adds a condition; build its placeholders with Arg.
+func (q *Query) Where(condition string) {
+	q.where = append(q.where, condition)
+}
+
+// Count returns a statement counting every row that matches the filters,
+// regardless of the page.
+func (q *Query) Count(from string) (string, []any) {
+	return "SELECT COUNT(*) FROM " + from + whereClause(q.where), q.args
+}
+
+// Select returns the page statement. It appends the sort key values to
+// columns, so rows must be scanned with the destinations returned by Dest,
+// and fetches one extra row to find out whether another page follows.
+func (q *Query) Select(columns, from string) (string, []any) {
+	args := append([]any(nil), q.args...)
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := append([]string(nil), q.where...)
+	if q.after != nil {
+		where = append(where, q.keysetCondition(arg))
+	}
+
+	var sql strings.Builder
+	sql.WriteString("SELECT " + columns)
+	for _, k := range q.keys {
+		sql.WriteString(", (" + k.column.Expr + ")::text")
+	}
+	sql.WriteString(" FROM " + from + whereClause(where) + " ORDER BY ")
+	for i, k := range q.keys {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(k.column.Expr)
+		if k.desc {
+			sql.WriteString(" DESC")
+		}
+	}
+	sql.WriteString(" LIMIT " + arg(q.limit+1))
+	if q.offset > 0 {
+		sql.WriteString(" OFFSET " + arg(q.offset))
+	}
+	return sql.String(), args
+}
+
+// keysetCondition matches the rows after the cursor:
+// (a > x) OR (a = x AND b > y) OR ..., with < for descending keys.
+func (q *Query) keysetCondition(arg func(any) string) string {
+	values := make([]string, len(q.keys))
+	for i, k := range q.keys {
+		values[i] = arg(q.after[i]) + "::" + k.column.Type
+	}
+
+	alternatives := make([]string, len(q.keys))
+	for i, k := range q.keys {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, q.keys[j].column.Expr+" = "+values[j])
+		}
+		op := " > "
+		if k.desc {
+			op = " < "
+		}
+		terms = append(terms, k.column.Expr+op+values[i])
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
+
+// Dest returns the scan destinations for one row: fields followed by the
+// sort key columns added by Select.
+func (q *Query) Dest(fields ...any) []any {
+	keys := make([]string, len(q.keys))
+	q.rowKeys = append(q.rowKeys, keys)
+	for i := range keys {
+		fields = append(fields, &keys[i])
+	}
+	return fields
+}
+
+// Page returns how many of the scanned rows belong to the page and the page
+// info; the caller trims its results to that length.
+func (q *Query) Page(total int) (int, entities.PageInfo) {
+	info := entities.PageInfo{Total: total, Limit: q.limit}
+	if len(q.rowKeys) <= q.limit {
+		return len(q.rowKeys), info
+	}
+	info.NextCursor = encodeCursor(cursor{Sort: q.sort, Values: q.rowKeys[q.limit-1]})
+	return q.limit, info
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
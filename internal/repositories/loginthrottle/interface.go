@@ -0,0 +1,31 @@
+package loginthrottlerepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	// Get returns the throttle for scope and subject, or nil when nothing
+	// failed for it recently.
+	Get(ctx context.Context, scope, subject string) (*entities.LoginThrottle, error)
+	// GetForUpdate is Get that also locks the row until the transaction ends.
+	GetForUpdate(ctx context.Context, scope, subject string) (*entities.LoginThrottle, error)
+	// RecordFailure counts a failure at now, restarting the count when the
+	// first counted failure happened before windowStart.
+	RecordFailure(ctx context.Context, scope, subject string, now, windowStart time.Time) (*entities.LoginThrottle, error)
+	// Uncount takes back one counted failure, if there is any left.
+	Uncount(ctx context.Context, scope, subject string) error
+	// Lock locks the subject until the given time, clears its failure count
+	// and increments its lockout count.
+	Lock(ctx context.Context, scope, subject string, until time.Time) error
+	Delete(ctx context.Context, scope, subject string) error
+	// DeleteStale removes throttles whose last failure and lock both ended
+	// before the given time.
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+
+	AddEvent(ctx context.Context, event *entities.LockoutEvent) error
+	ListEventsByUser(ctx context.Context, userID, limit int) ([]entities.LockoutEvent, error)
+}
@@ -0,0 +1,165 @@
+package loginthrottlerepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+const throttleColumns = `scope, subject, failures, lockouts, first_failure_at, last_failure_at, locked_until`
+
+func (r *repository) Get(ctx context.Context, scope, subject string) (*entities.LoginThrottle, error) {
+	query := `SELECT ` + throttleColumns + ` FROM login_throttles WHERE scope = $1 AND subject = $2`
+	t, err := scanThrottle(r.conn(ctx).QueryRow(ctx, query, scope, subject))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login throttle: %w", err)
+	}
+	return t, nil
+}
+
+func (r *repository) GetForUpdate(ctx context.Context, scope, subject string) (*entities.LoginThrottle, error) {
+	query := `SELECT ` + throttleColumns + ` FROM login_throttles WHERE scope = $1 AND subject = $2 FOR UPDATE`
+	t, err := scanThrottle(r.conn(ctx).QueryRow(ctx, query, scope, subject))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock login throttle: %w", err)
+	}
+	return t, nil
+}
+
+func (r *repository) RecordFailure(ctx context.Context, scope, subject string, now, windowStart time.Time) (*entities.LoginThrottle, error) {
+	query := `
+		INSERT INTO login_throttles (scope, subject, failures, first_failure_at, last_failure_at)
+		VALUES ($1, $2, 1, $3, $3)
+		ON CONFLICT (scope, subject) DO UPDATE SET
+			failures = CASE WHEN login_throttles.first_failure_at < $4 OR login_throttles.failures = 0
+				THEN 1 ELSE login_throttles.failures + 1 END,
+			first_failure_at = CASE WHEN login_throttles.first_failure_at < $4 OR login_throttles.failures = 0
+				THEN $3 ELSE login_throttles.first_failure_at END,
+			last_failure_at = $3
+		RETURNING ` + throttleColumns
+	t, err := scanThrottle(r.conn(ctx).QueryRow(ctx, query, scope, subject, now.UTC(), windowStart.UTC()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return t, nil
+}
+
+func (r *repository) Uncount(ctx context.Context, scope, subject string) error {
+	query := `
+		UPDATE login_throttles SET failures = failures - 1
+		WHERE scope = $1 AND subject = $2 AND failures > 0`
+	if _, err := r.conn(ctx).Exec(ctx, query, scope, subject); err != nil {
+		return fmt.Errorf("failed to uncount login failure: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Lock(ctx context.Context, scope, subject string, until time.Time) error {
+	query := `
+		UPDATE login_throttles SET locked_until = $3, failures = 0, lockouts = lockouts + 1
+		WHERE scope = $1 AND subject = $2`
+	if _, err := r.conn(ctx).Exec(ctx, query, scope, subject, until.UTC()); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", scope, err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, scope, subject string) error {
+	query := `DELETE FROM login_throttles WHERE scope = $1 AND subject = $2`
+	if _, err := r.conn(ctx).Exec(ctx, query, scope, subject); err != nil {
+		return fmt.Errorf("failed to delete login throttle: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1)`
+	tag, err := r.conn(ctx).Exec(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale login throttles: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repository) AddEvent(ctx context.Context, event *entities.LockoutEvent) error {
+	query := `
+		INSERT INTO lockout_events (event, scope, subject, user_id, ip_address, failures, locked_until, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`
+	var lockedUntil *time.Time
+	if event.LockedUntil != nil {
+		t := event.LockedUntil.UTC()
+		lockedUntil = &t
+	}
+	err := r.conn(ctx).QueryRow(ctx, query,
+		event.Event, event.Scope, event.Subject, event.UserID, event.IPAddress, event.Failures,
+		lockedUntil, event.ActorID, event.CreatedAt.UTC(),
+	).Scan(&event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to record lockout event: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListEventsByUser(ctx context.Context, userID, limit int) ([]entities.LockoutEvent, error) {
+	query := `
+		SELECT id, event, scope, subject, user_id, ip_address, failures, locked_until, actor_id, created_at
+		FROM lockout_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+	rows, err := r.conn(ctx).Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lockout events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]entities.LockoutEvent, 0)
+	for rows.Next() {
+		var e entities.LockoutEvent
+		if err := rows.Scan(
+			&e.ID, &e.Event, &e.Scope, &e.Subject, &e.UserID, &e.IPAddress, &e.Failures,
+			&e.LockedUntil, &e.ActorID, &e.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan lockout event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func scanThrottle(row pgx.Row) (*entities.LoginThrottle, error) {
+	var t entities.LoginThrottle
+	err := row.Scan(&t.Scope, &t.Subject, &t.Failures, &t.Lockouts, &t.FirstFailureAt, &t.LastFailureAt, &t.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
@@ -0,0 +1,32 @@
+package mediarepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	// Create appends the media after the car's existing files.
+	Create(ctx context.Context, media *entities.CarMedia) (int, error)
+	GetByID(ctx context.Context, carID, id int) (*entities.CarMedia, error)
+	// ListByCar returns the car's media in display order.
+	ListByCar(ctx context.Context, carID int) ([]*entities.CarMedia, error)
+	CountByCar(ctx context.Context, carID int) (int, error)
+	// SetPrimary makes the photo the car's only primary photo.
+	SetPrimary(ctx context.Context, carID, id int) error
+	// PromotePrimary makes the first photo primary if the car has none.
+	PromotePrimary(ctx context.Context, carID int) error
+	// Reorder sets each media's position to its index in ids.
+	Reorder(ctx context.Context, carID int, ids []int) error
+	// Delete removes the media; its blob keys are queued as orphans.
+	Delete(ctx context.Context, carID, id int) error
+
+	// QueueOrphans marks blobs for deletion once deleteAfter has passed.
+	QueueOrphans(ctx context.Context, keys []string, deleteAfter time.Time) error
+	// DequeueOrphans forgets blobs that are in use or already deleted.
+	DequeueOrphans(ctx context.Context, keys []string) error
+	// ListOrphans returns up to limit blob keys due for deletion at now.
+	ListOrphans(ctx context.Context, now time.Time, limit int) ([]string, error)
+}
@@ -0,0 +1,202 @@
+package mediarepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+const mediaColumns = `id, car_id, kind, storage_key, thumbnail_keys, filename, content_type, size_bytes,
+	width, height, position, is_primary, created_at`
+
+func mediaFields(m *entities.CarMedia) []any {
+	return []any{
+		&m.ID, &m.CarID, &m.Kind, &m.StorageKey, &m.ThumbnailKeys, &m.Filename, &m.ContentType, &m.Size,
+		&m.Width, &m.Height, &m.Position, &m.IsPrimary, &m.CreatedAt,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, media *entities.CarMedia) (int, error) {
+	query := `
+		INSERT INTO car_media (car_id, kind, storage_key, thumbnail_keys, filename, content_type, size_bytes,
+			width, height, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9,
+			(SELECT coalesce(max(position), -1) + 1 FROM car_media WHERE car_id = $1))
+		RETURNING id`
+	thumbnails := media.ThumbnailKeys
+	if thumbnails == nil {
+		thumbnails = map[string]string{}
+	}
+	var id int
+	err := r.conn(ctx).QueryRow(ctx, query,
+		media.CarID,
+		media.Kind,
+		media.StorageKey,
+		thumbnails,
+		media.Filename,
+		media.ContentType,
+		media.Size,
+		media.Width,
+		media.Height,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert media: %w", err)
+	}
+	return id, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, carID, id int) (*entities.CarMedia, error) {
+	query := `SELECT ` + mediaColumns + ` FROM car_media WHERE car_id = $1 AND id = $2`
+	var media entities.CarMedia
+	err := r.conn(ctx).QueryRow(ctx, query, carID, id).Scan(mediaFields(&media)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media: %w", err)
+	}
+	return &media, nil
+}
+
+func (r *repository) ListByCar(ctx context.Context, carID int) ([]*entities.CarMedia, error) {
+	query := `SELECT ` + mediaColumns + ` FROM car_media WHERE car_id = $1 ORDER BY position, id`
+	rows, err := r.conn(ctx).Query(ctx, query, carID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list media: %w", err)
+	}
+	defer rows.Close()
+
+	media := make([]*entities.CarMedia, 0)
+	for rows.Next() {
+		var m entities.CarMedia
+		if err := rows.Scan(mediaFields(&m)...); err != nil {
+			return nil, fmt.Errorf("failed to scan media: %w", err)
+		}
+		media = append(media, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list media: %w", err)
+	}
+	return media, nil
+}
+
+func (r *repository) CountByCar(ctx context.Context, carID int) (int, error) {
+	var count int
+	err := r.conn(ctx).QueryRow(ctx, `SELECT count(*) FROM car_media WHERE car_id = $1`, carID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count media: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repository) SetPrimary(ctx context.Context, carID, id int) error {
+	// Clearing first keeps the one-primary-per-car index satisfied.
+	if _, err := r.conn(ctx).Exec(ctx,
+		`UPDATE car_media SET is_primary = false WHERE car_id = $1 AND is_primary AND id <> $2`, carID, id); err != nil {
+		return fmt.Errorf("failed to clear primary photo: %w", err)
+	}
+	if _, err := r.conn(ctx).Exec(ctx,
+		`UPDATE car_media SET is_primary = true WHERE car_id = $1 AND id = $2`, carID, id); err != nil {
+		return fmt.Errorf("failed to set primary photo: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) PromotePrimary(ctx context.Context, carID int) error {
+	query := `
+		UPDATE car_media SET is_primary = true
+		WHERE id = (
+			SELECT id FROM car_media
+			WHERE car_id = $1 AND kind = $2
+			ORDER BY position, id
+			LIMIT 1
+		)
+		AND NOT EXISTS (SELECT 1 FROM car_media WHERE car_id = $1 AND is_primary)`
+	if _, err := r.conn(ctx).Exec(ctx, query, carID, entities.MediaPhoto); err != nil {
+		return fmt.Errorf("failed to promote primary photo: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Reorder(ctx context.Context, carID int, ids []int) error {
+	query := `
+		UPDATE car_media m SET position = o.position - 1
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(id, position)
+		WHERE m.car_id = $1 AND m.id = o.id`
+	if _, err := r.conn(ctx).Exec(ctx, query, carID, ids); err != nil {
+		return fmt.Errorf("failed to reorder media: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, carID, id int) error {
+	tag, err := r.conn(ctx).Exec(ctx, `DELETE FROM car_media WHERE car_id = $1 AND id = $2`, carID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete media: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.ErrMediaNotFound
+	}
+	return nil
+}
+
+func (r *repository) QueueOrphans(ctx context.Context, keys []string, deleteAfter time.Time) error {
+	query := `
+		INSERT INTO media_orphans (storage_key, delete_after)
+		SELECT unnest($1::text[]), $2
+		ON CONFLICT (storage_key) DO UPDATE SET delete_after = excluded.delete_after`
+	if _, err := r.conn(ctx).Exec(ctx, query, keys, deleteAfter.UTC()); err != nil {
+		return fmt.Errorf("failed to queue orphaned blobs: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DequeueOrphans(ctx context.Context, keys []string) error {
+	query := `DELETE FROM media_orphans WHERE storage_key = ANY($1::text[])`
+	if _, err := r.conn(ctx).Exec(ctx, query, keys); err != nil {
+		return fmt.Errorf("failed to dequeue orphaned blobs: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListOrphans(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	query := `
+		SELECT storage_key FROM media_orphans
+		WHERE delete_after <= $1
+		ORDER BY delete_after, storage_key
+		LIMIT $2`
+	rows, err := r.conn(ctx).Query(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orphaned blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan orphaned blob: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
@@ -0,0 +1,37 @@
+package mfarepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	// GetFactor returns entities.ErrMFANotEnrolled when the user has no
+	// factor, confirmed or pending.
+	GetFactor(ctx context.Context, userID int) (*entities.MFAFactor, error)
+	GetFactorForUpdate(ctx context.Context, userID int) (*entities.MFAFactor, error)
+	// SaveFactor stores a new pending factor, replacing an earlier pending
+	// one.
+	SaveFactor(ctx context.Context, factor *entities.MFAFactor) error
+	ConfirmFactor(ctx context.Context, userID int, step int64, at time.Time) error
+	SetLastUsedStep(ctx context.Context, userID int, step int64) error
+	DeleteFactor(ctx context.Context, userID int) error
+
+	// ReplaceRecoveryCodes drops every recovery code of the user and stores
+	// the given hashes instead.
+	ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string, at time.Time) error
+	// UseRecoveryCode marks the unused code with the given hash as used and
+	// returns entities.ErrInvalidMFACode when there is none.
+	UseRecoveryCode(ctx context.Context, userID int, hash string, at time.Time) error
+	CountRecoveryCodes(ctx context.Context, userID int) (int, error)
+
+	CreateChallenge(ctx context.Context, challenge *entities.MFAChallenge) error
+	// GetChallengeForUpdate returns entities.ErrInvalidMFAChallenge when there
+	// is no challenge with the hash.
+	GetChallengeForUpdate(ctx context.Context, tokenHash string) (*entities.MFAChallenge, error)
+	IncrementChallengeAttempts(ctx context.Context, tokenHash string) error
+	UseChallenge(ctx context.Context, tokenHash string, at time.Time) error
+	DeleteExpiredChallenges(ctx context.Context, now time.Time) (int64, error)
+}
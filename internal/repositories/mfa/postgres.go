@@ -0,0 +1,180 @@
+package mfarepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) GetFactor(ctx context.Context, userID int) (*entities.MFAFactor, error) {
+	return r.getFactor(ctx, `SELECT user_id, secret, confirmed_at, last_used_step, created_at FROM mfa_factors WHERE user_id = $1`, userID)
+}
+
+func (r *repository) GetFactorForUpdate(ctx context.Context, userID int) (*entities.MFAFactor, error) {
+	return r.getFactor(ctx, `SELECT user_id, secret, confirmed_at, last_used_step, created_at FROM mfa_factors WHERE user_id = $1 FOR UPDATE`, userID)
+}
+
+func (r *repository) getFactor(ctx context.Context, query string, userID int) (*entities.MFAFactor, error) {
+	var f entities.MFAFactor
+	err := r.conn(ctx).QueryRow(ctx, query, userID).Scan(&f.UserID, &f.Secret, &f.ConfirmedAt, &f.LastUsedStep, &f.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa factor: %w", err)
+	}
+	return &f, nil
+}
+
+func (r *repository) SaveFactor(ctx context.Context, factor *entities.MFAFactor) error {
+	query := `
+		INSERT INTO mfa_factors (user_id, secret, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret = $2, created_at = $3, last_used_step = 0
+		WHERE mfa_factors.confirmed_at IS NULL`
+	tag, err := r.conn(ctx).Exec(ctx, query, factor.UserID, factor.Secret, factor.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save mfa factor: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.ErrMFAAlreadyEnrolled
+	}
+	return nil
+}
+
+func (r *repository) ConfirmFactor(ctx context.Context, userID int, step int64, at time.Time) error {
+	query := `UPDATE mfa_factors SET confirmed_at = $3, last_used_step = $2 WHERE user_id = $1`
+	if _, err := r.conn(ctx).Exec(ctx, query, userID, step, at.UTC()); err != nil {
+		return fmt.Errorf("failed to confirm mfa factor: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) SetLastUsedStep(ctx context.Context, userID int, step int64) error {
+	query := `UPDATE mfa_factors SET last_used_step = $2 WHERE user_id = $1`
+	if _, err := r.conn(ctx).Exec(ctx, query, userID, step); err != nil {
+		return fmt.Errorf("failed to update mfa factor: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteFactor(ctx context.Context, userID int) error {
+	if _, err := r.conn(ctx).Exec(ctx, `DELETE FROM mfa_factors WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete mfa factor: %w", err)
+	}
+	if _, err := r.conn(ctx).Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string, at time.Time) error {
+	if _, err := r.conn(ctx).Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	query := `
+		INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at)
+		SELECT $1, hash, $3 FROM unnest($2::text[]) AS hash`
+	if _, err := r.conn(ctx).Exec(ctx, query, userID, hashes, at.UTC()); err != nil {
+		return fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UseRecoveryCode(ctx context.Context, userID int, hash string, at time.Time) error {
+	query := `UPDATE mfa_recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	tag, err := r.conn(ctx).Exec(ctx, query, userID, hash, at.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (r *repository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var count int
+	query := `SELECT count(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	if err := r.conn(ctx).QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func (r *repository) CreateChallenge(ctx context.Context, c *entities.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (token_hash, user_id, purpose, user_agent, ip_address, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.conn(ctx).Exec(ctx, query,
+		c.TokenHash, c.UserID, c.Purpose, c.UserAgent, c.IPAddress, c.ExpiresAt.UTC(), c.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetChallengeForUpdate(ctx context.Context, tokenHash string) (*entities.MFAChallenge, error) {
+	query := `
+		SELECT token_hash, user_id, purpose, attempts, user_agent, ip_address, expires_at, used_at, created_at
+		FROM mfa_challenges WHERE token_hash = $1 FOR UPDATE`
+	var c entities.MFAChallenge
+	err := r.conn(ctx).QueryRow(ctx, query, tokenHash).Scan(
+		&c.TokenHash, &c.UserID, &c.Purpose, &c.Attempts, &c.UserAgent, &c.IPAddress, &c.ExpiresAt, &c.UsedAt, &c.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+	return &c, nil
+}
+
+func (r *repository) IncrementChallengeAttempts(ctx context.Context, tokenHash string) error {
+	query := `UPDATE mfa_challenges SET attempts = attempts + 1 WHERE token_hash = $1`
+	if _, err := r.conn(ctx).Exec(ctx, query, tokenHash); err != nil {
+		return fmt.Errorf("failed to update mfa challenge: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UseChallenge(ctx context.Context, tokenHash string, at time.Time) error {
+	query := `UPDATE mfa_challenges SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL`
+	tag, err := r.conn(ctx).Exec(ctx, query, tokenHash, at.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to use mfa challenge: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.ErrInvalidMFAChallenge
+	}
+	return nil
+}
+
+func (r *repository) DeleteExpiredChallenges(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `DELETE FROM mfa_challenges WHERE expires_at < $1`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired mfa challenges: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
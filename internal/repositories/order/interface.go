@@ -3,13 +3,20 @@ package orderrepo
 import (
 	"context"
 	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
 )
 
 type Repository interface {
 	Create(ctx context.Context, order *entities.Order) (int, error)
 	GetByID(ctx context.Context, id int) (*entities.Order, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*entities.Order, error)
 	GetByUserID(ctx context.Context, userID int) ([]entities.Order, error)
 	UpdateStatus(ctx context.Context, id int, status string) error
+	// AddPaidAmount adds amount, which may be negative, to the order's paid amount.
+	AddPaidAmount(ctx context.Context, id int, amount money.Money) error
 	Delete(ctx context.Context, id int) error
-	ListAll(ctx context.Context) ([]entities.Order, error)
+	ListAll(ctx context.Context, page entities.PageRequest) ([]entities.Order, entities.PageInfo, error)
+	AddStatusChange(ctx context.Context, change *entities.OrderStatusChange) error
+	// ListStatusHistory returns the status changes of the order, oldest first.
+	ListStatusHistory(ctx context.Context, orderID int) ([]entities.OrderStatusChange, error)
 }
@@ -2,11 +2,19 @@ package orderrepo
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
+	"myproject/internal/repositories/listquery"
+	"myproject/internal/repositories/txmanager"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+const orderColumns = `id, user_id, car_id, status, deposit, total_price, paid_amount, total_price - paid_amount, created_at, updated_at`
+
 type repository struct {
 	db *pgxpool.Pool
 }
@@ -17,20 +25,32 @@ func NewPostgresRepository(db *pgxpool.Pool) Repository {
 
 func (r *repository) Create(ctx context.Context, order *entities.Order) (int, error) {
 	query := `
-		INSERT INTO orders (user_id, car_id, status, deposit, total_price)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO orders (user_id, car_id, status, deposit, total_price, paid_amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
 	var id int
-	err := r.db.QueryRow(ctx, query, order.UserID, order.CarID, order.Status, order.Deposit, order.TotalPrice).Scan(&id)
+	err := r.conn(ctx).QueryRow(ctx, query, order.UserID, order.CarID, order.Status, order.Deposit, order.TotalPrice, order.PaidAmount).Scan(&id)
 	return id, err
 }
 
 func (r *repository) GetByID(ctx context.Context, id int) (*entities.Order, error) {
-	query := `SELECT id, user_id, car_id, status, deposit, total_price, created_at, updated_at FROM orders WHERE id = $1`
-	row := r.db.QueryRow(ctx, query, id)
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *repository) GetByIDForUpdate(ctx context.Context, id int) (*entities.Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1 FOR UPDATE`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *repository) getOne(ctx context.Context, query string, id int) (*entities.Order, error) {
+	row := r.conn(ctx).QueryRow(ctx, query, id)
 
 	var order entities.Order
-	err := row.Scan(&order.ID, &order.UserID, &order.CarID, &order.Status, &order.Deposit, &order.TotalPrice, &order.CreatedAt, &order.UpdatedAt)
+	err := row.Scan(&order.ID, &order.UserID, &order.CarID, &order.Status, &order.Deposit, &order.TotalPrice, &order.PaidAmount, &order.Outstanding, &order.CreatedAt, &order.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrOrderNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -38,8 +58,8 @@ func (r *repository) GetByID(ctx context.Context, id int) (*entities.Order, erro
 }
 
 func (r *repository) GetByUserID(ctx context.Context, userID int) ([]entities.Order, error) {
-	query := `SELECT id, user_id, car_id, status, deposit, total_price, created_at, updated_at FROM orders WHERE user_id = $1`
-	rows, err := r.db.Query(ctx, query, userID)
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE user_id = $1`
+	rows, err := r.conn(ctx).Query(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +68,7 @@ func (r *repository) GetByUserID(ctx context.Context, userID int) ([]entities.Or
 	var orders []entities.Order
 	for rows.Next() {
 		var order entities.Order
-		err := rows.Scan(&order.ID, &order.UserID, &order.CarID, &order.Status, &order.Deposit, &order.TotalPrice, &order.CreatedAt, &order.UpdatedAt)
+		err := rows.Scan(&order.ID, &order.UserID, &order.CarID, &order.Status, &order.Deposit, &order.TotalPrice, &order.PaidAmount, &order.Outstanding, &order.CreatedAt, &order.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -59,32 +79,112 @@ func (r *repository) GetByUserID(ctx context.Context, userID int) ([]entities.Or
 
 func (r *repository) UpdateStatus(ctx context.Context, id int, status string) error {
 	query := `UPDATE orders SET status = $1, updated_at = current_timestamp WHERE id = $2`
-	_, err := r.db.Exec(ctx, query, status, id)
+	_, err := r.conn(ctx).Exec(ctx, query, status, id)
 	return err
 }
 
+func (r *repository) AddPaidAmount(ctx context.Context, id int, amount money.Money) error {
+	query := `UPDATE orders SET paid_amount = paid_amount + $1, updated_at = current_timestamp WHERE id = $2`
+	tag, err := r.conn(ctx).Exec(ctx, query, amount, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.ErrOrderNotFound
+	}
+	return nil
+}
+
 func (r *repository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM orders WHERE id = $1`
-	_, err := r.db.Exec(ctx, query, id)
+	_, err := r.conn(ctx).Exec(ctx, query, id)
 	return err
 }
 
-func (r *repository) ListAll(ctx context.Context) ([]entities.Order, error) {
-	query := `SELECT id, user_id, car_id, status, deposit, total_price, created_at, updated_at FROM orders`
-	rows, err := r.db.Query(ctx, query)
+var orderSort = &listquery.Spec{
+	Columns: map[string]listquery.Column{
+		"id":          {Expr: "id", Type: "int"},
+		"status":      {Expr: "status", Type: "text"},
+		"total_price": {Expr: "total_price", Type: "numeric"},
+		"created_at":  {Expr: "created_at", Type: "timestamp"},
+		"updated_at":  {Expr: "updated_at", Type: "timestamp"},
+	},
+	ID:           listquery.Column{Expr: "id", Type: "int"},
+	DefaultSort:  "-created_at",
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+func (r *repository) ListAll(ctx context.Context, page entities.PageRequest) ([]entities.Order, entities.PageInfo, error) {
+	q, err := listquery.New(orderSort, page)
 	if err != nil {
-		return nil, err
+		return nil, entities.PageInfo{}, err
+	}
+
+	var total int
+	countQuery, countArgs := q.Count("orders")
+	if err := r.conn(ctx).QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, entities.PageInfo{}, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	query, args := q.Select(orderColumns, "orders")
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, entities.PageInfo{}, fmt.Errorf("failed to list orders: %w", err)
 	}
 	defer rows.Close()
 
-	var orders []entities.Order
+	orders := make([]entities.Order, 0)
 	for rows.Next() {
 		var order entities.Order
-		err := rows.Scan(&order.ID, &order.UserID, &order.CarID, &order.Status, &order.Deposit, &order.TotalPrice, &order.CreatedAt, &order.UpdatedAt)
+		err := rows.Scan(q.Dest(&order.ID, &order.UserID, &order.CarID, &order.Status, &order.Deposit, &order.TotalPrice, &order.PaidAmount, &order.Outstanding, &order.CreatedAt, &order.UpdatedAt)...)
 		if err != nil {
-			return nil, err
+			return nil, entities.PageInfo{}, fmt.Errorf("failed to scan order: %w", err)
 		}
 		orders = append(orders, order)
 	}
-	return orders, nil
+	if err := rows.Err(); err != nil {
+		return nil, entities.PageInfo{}, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	n, info := q.Page(total)
+	return orders[:n], info, nil
+}
+
+func (r *repository) AddStatusChange(ctx context.Context, change *entities.OrderStatusChange) error {
+	query := `
+		INSERT INTO order_status_history (order_id, from_status, to_status, actor_id, reason, created_at)
+		VALUES ($1, nullif($2, ''), $3, $4, $5, $6)
+		RETURNING id`
+	return r.conn(ctx).QueryRow(ctx, query,
+		change.OrderID, change.FromStatus, change.ToStatus, change.ActorID, change.Reason, change.CreatedAt.UTC(),
+	).Scan(&change.ID)
+}
+
+func (r *repository) ListStatusHistory(ctx context.Context, orderID int) ([]entities.OrderStatusChange, error) {
+	query := `
+		SELECT id, order_id, coalesce(from_status, ''), to_status, actor_id, reason, created_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY created_at, id`
+	rows, err := r.conn(ctx).Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []entities.OrderStatusChange{}
+	for rows.Next() {
+		var change entities.OrderStatusChange
+		err := rows.Scan(&change.ID, &change.OrderID, &change.FromStatus, &change.ToStatus, &change.ActorID, &change.Reason, &change.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
 }
@@ -2,13 +2,23 @@ package paymentrepo
 
 import (
 	"context"
+	"time"
+
 	"myproject/internal/entities"
 )
 
 type Repository interface {
-	ProcessPayment(ctx context.Context, payment *entities.Payment) error
+	Create(ctx context.Context, payment *entities.Payment) (int, error)
 	GetPaymentByID(ctx context.Context, paymentID int) (*entities.Payment, error)
-	Deposit(ctx context.Context, userID int, amount float64) error // Add this method
+	GetByIDForUpdate(ctx context.Context, paymentID int) (*entities.Payment, error)
+	GetByProviderIDForUpdate(ctx context.Context, provider, providerID string) (*entities.Payment, error)
+	GetByUserID(ctx context.Context, userID int) ([]entities.Payment, error)
+	ListPendingBefore(ctx context.Context, before time.Time) ([]entities.Payment, error)
+	SetProviderID(ctx context.Context, paymentID int, providerID string) error
+	// UpdateStatus moves a payment from one status to another and returns
+	// entities.ErrPaymentStateConflict if it is no longer in the from status.
+	UpdateStatus(ctx context.Context, paymentID int, from, to, failureReason string) error
+	SetTransactionID(ctx context.Context, paymentID, entryID int) error
 	CreateTransaction(ctx context.Context, tx *entities.Transaction) error
 	GetTransactionsByUserID(ctx context.Context, userID int) ([]entities.Transaction, error)
 }
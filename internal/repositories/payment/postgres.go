@@ -2,9 +2,14 @@ package paymentrepo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
 	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -16,69 +21,133 @@ func NewPaymentRepository(db *pgxpool.Pool) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) ProcessPayment(ctx context.Context, payment *entities.Payment) error {
-	tx, err := r.db.Begin(ctx)
+const paymentColumns = `id, user_id, amount, payment_method, status, provider, provider_id, failure_reason, transaction_id, created_at, updated_at`
+
+func (r *repository) Create(ctx context.Context, payment *entities.Payment) (int, error) {
+	query := `
+		INSERT INTO payments (user_id, amount, payment_method, status, provider, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		RETURNING id`
+	var id int
+	err := r.conn(ctx).QueryRow(ctx, query,
+		payment.UserID, payment.Amount, payment.PaymentMethod, payment.Status, payment.Provider, payment.CreatedAt.UTC(),
+	).Scan(&id)
 	if err != nil {
-		return fmt.Errorf("begin tx: %w", err)
+		return 0, fmt.Errorf("failed to insert payment: %w", err)
 	}
-	defer tx.Rollback(ctx)
+	return id, nil
+}
 
-	_, err = tx.Exec(ctx, `UPDATE users SET balance = balance + $1 WHERE id = $2`, payment.Amount, payment.UserID)
-	if err != nil {
-		return fmt.Errorf("update balance: %w", err)
-	}
+func (r *repository) GetPaymentByID(ctx context.Context, paymentID int) (*entities.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = $1`
+	return r.getOne(ctx, query, paymentID)
+}
 
-	transaction := &entities.Transaction{
-		UserID:      payment.UserID,
-		Amount:      payment.Amount,
-		Type:        payment.PaymentMethod,
-		Description: fmt.Sprintf("Payment via %s", payment.PaymentMethod),
-		CreatedAt:   payment.CreatedAt,
-	}
-	err = r.CreateTransaction(ctx, transaction)
-	if err != nil {
-		return fmt.Errorf("create transaction: %w", err)
+func (r *repository) GetByIDForUpdate(ctx context.Context, paymentID int) (*entities.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = $1 FOR UPDATE`
+	return r.getOne(ctx, query, paymentID)
+}
+
+func (r *repository) GetByProviderIDForUpdate(ctx context.Context, provider, providerID string) (*entities.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE provider = $1 AND provider_id = $2 FOR UPDATE`
+	return r.getOne(ctx, query, provider, providerID)
+}
+
+func (r *repository) GetByUserID(ctx context.Context, userID int) ([]entities.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE user_id = $1 ORDER BY created_at DESC`
+	return r.queryPayments(ctx, query, userID)
+}
+
+func (r *repository) ListPendingBefore(ctx context.Context, before time.Time) ([]entities.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE status = $1 AND created_at < $2 ORDER BY created_at`
+	return r.queryPayments(ctx, query, entities.PaymentStatusPending, before.UTC())
+}
+
+func (r *repository) SetProviderID(ctx context.Context, paymentID int, providerID string) error {
+	query := `UPDATE payments SET provider_id = $1, updated_at = current_timestamp WHERE id = $2`
+	if _, err := r.conn(ctx).Exec(ctx, query, providerID, paymentID); err != nil {
+		return fmt.Errorf("failed to set payment provider id: %w", err)
 	}
+	return nil
+}
 
-	_, err = tx.Exec(ctx, `UPDATE payments SET status = $1, transaction_id = $2 WHERE id = $3`, payment.Status, transaction.ID, payment.ID)
+func (r *repository) UpdateStatus(ctx context.Context, paymentID int, from, to, failureReason string) error {
+	query := `UPDATE payments SET status = $1, failure_reason = $2, updated_at = current_timestamp WHERE id = $3 AND status = $4`
+	tag, err := r.conn(ctx).Exec(ctx, query, to, failureReason, paymentID, from)
 	if err != nil {
-		return fmt.Errorf("update payment status: %w", err)
+		return fmt.Errorf("failed to update payment status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.ErrPaymentStateConflict
 	}
+	return nil
+}
 
-	return tx.Commit(ctx)
+func (r *repository) SetTransactionID(ctx context.Context, paymentID, entryID int) error {
+	query := `UPDATE payments SET transaction_id = $1, updated_at = current_timestamp WHERE id = $2`
+	if _, err := r.conn(ctx).Exec(ctx, query, entryID, paymentID); err != nil {
+		return fmt.Errorf("failed to link payment to ledger entry: %w", err)
+	}
+	return nil
 }
 
-func (r *repository) Deposit(ctx context.Context, userID int, amount float64) error {
-	_, err := r.db.Exec(ctx, `UPDATE users SET balance = balance + $1 WHERE id = $2`, amount, userID)
+func (r *repository) getOne(ctx context.Context, query string, args ...interface{}) (*entities.Payment, error) {
+	payment, err := scanPayment(r.conn(ctx).QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrPaymentNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to deposit: %w", err)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
 	}
-	return nil
+	return payment, nil
 }
 
-func (r *repository) GetPaymentByID(ctx context.Context, paymentID int) (*entities.Payment, error) {
-	row := r.db.QueryRow(ctx, "SELECT id, user_id, amount, payment_method, status, transaction_id, created_at, provider_id FROM payments WHERE id = $1", paymentID)
+func (r *repository) queryPayments(ctx context.Context, query string, args ...interface{}) ([]entities.Payment, error) {
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []entities.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+	return payments, rows.Err()
+}
 
+func scanPayment(row pgx.Row) (*entities.Payment, error) {
 	var payment entities.Payment
+	var providerID *string
 	err := row.Scan(
 		&payment.ID,
 		&payment.UserID,
 		&payment.Amount,
 		&payment.PaymentMethod,
 		&payment.Status,
+		&payment.Provider,
+		&providerID,
+		&payment.FailureReason,
 		&payment.TransactionID,
 		&payment.CreatedAt,
-		&payment.ProviderID,
+		&payment.UpdatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get payment: %w", err)
+		return nil, err
+	}
+	if providerID != nil {
+		payment.ProviderID = *providerID
 	}
 	return &payment, nil
 }
 
 func (r *repository) CreateTransaction(ctx context.Context, tx *entities.Transaction) error {
-	query := `INSERT INTO transactions (user_id, amount, type, description, created_at) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(ctx, query, tx.UserID, tx.Amount, tx.Type, tx.Description, tx.CreatedAt)
+	query := `INSERT INTO transactions (user_id, amount, type, description, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	err := r.conn(ctx).QueryRow(ctx, query, tx.UserID, tx.Amount, tx.Type, tx.Description, tx.CreatedAt).Scan(&tx.ID)
 	if err != nil {
 		return fmt.Errorf("failed to insert transaction: %w", err)
 	}
@@ -88,7 +157,7 @@ func (r *repository) CreateTransaction(ctx context.Context, tx *entities.Transac
 func (r *repository) GetTransactionsByUserID(ctx context.Context, userID int) ([]entities.Transaction, error) {
 	query := `SELECT id, user_id, amount, type, description, created_at FROM transactions WHERE user_id = $1 ORDER BY created_at DESC`
 
-	rows, err := r.db.Query(ctx, query, userID)
+	rows, err := r.conn(ctx).Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
@@ -106,3 +175,7 @@ func (r *repository) GetTransactionsByUserID(ctx context.Context, userID int) ([
 
 	return transactions, nil
 }
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
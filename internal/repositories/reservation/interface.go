@@ -0,0 +1,22 @@
+package reservationrepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	Create(ctx context.Context, reservation *entities.Reservation) (int, error)
+	GetByID(ctx context.Context, id int) (*entities.Reservation, error)
+	GetByUserID(ctx context.Context, userID int) ([]entities.Reservation, error)
+	ListAll(ctx context.Context) ([]entities.Reservation, error)
+	// UpdateStatus moves a reservation from one status to another and returns
+	// entities.ErrReservationNotActive if it is no longer in the from status.
+	UpdateStatus(ctx context.Context, id int, from, to string) error
+	SetOrderID(ctx context.Context, id, orderID int) error
+	ListExpired(ctx context.Context, now time.Time) ([]entities.Reservation, error)
+	// HasActiveForCar reports whether an active hold on the car overlaps [from, to].
+	HasActiveForCar(ctx context.Context, carID int, from, to time.Time) (bool, error)
+}
@@ -0,0 +1,145 @@
+package reservationrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+const uniqueViolation = "23505"
+
+const reservationColumns = `id, user_id, car_id, order_id, status, created_at, updated_at, expires_at`
+
+func (r *repository) Create(ctx context.Context, reservation *entities.Reservation) (int, error) {
+	query := `
+		INSERT INTO reservations (user_id, car_id, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING id`
+	var id int
+	err := r.conn(ctx).QueryRow(ctx, query,
+		reservation.UserID, reservation.CarID, reservation.Status, reservation.ExpiresAt.UTC(), reservation.CreatedAt.UTC(),
+	).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return 0, entities.ErrCarNotAvailable
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert reservation: %w", err)
+	}
+	return id, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (*entities.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE id = $1`
+	reservation, err := scanReservation(r.conn(ctx).QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	return reservation, nil
+}
+
+func (r *repository) GetByUserID(ctx context.Context, userID int) ([]entities.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE user_id = $1 ORDER BY created_at DESC`
+	return r.query(ctx, query, userID)
+}
+
+func (r *repository) ListAll(ctx context.Context) ([]entities.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations ORDER BY created_at DESC`
+	return r.query(ctx, query)
+}
+
+func (r *repository) UpdateStatus(ctx context.Context, id int, from, to string) error {
+	query := `UPDATE reservations SET status = $1, updated_at = $4 WHERE id = $2 AND status = $3`
+	tag, err := r.conn(ctx).Exec(ctx, query, to, id, from, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to update reservation status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.ErrReservationNotActive
+	}
+	return nil
+}
+
+func (r *repository) SetOrderID(ctx context.Context, id, orderID int) error {
+	query := `UPDATE reservations SET order_id = $1, updated_at = $3 WHERE id = $2`
+	_, err := r.conn(ctx).Exec(ctx, query, orderID, id, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to link reservation to order: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) ListExpired(ctx context.Context, now time.Time) ([]entities.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE status = $1 AND expires_at <= $2 ORDER BY expires_at`
+	return r.query(ctx, query, entities.ReservationStatusActive, now.UTC())
+}
+
+func (r *repository) HasActiveForCar(ctx context.Context, carID int, from, to time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM reservations
+			WHERE car_id = $1 AND status = $2 AND created_at <= $4 AND expires_at > $3
+		)`
+	var exists bool
+	err := r.conn(ctx).QueryRow(ctx, query, carID, entities.ReservationStatusActive, from.UTC(), to.UTC()).Scan(&exists)
+	return exists, err
+}
+
+func (r *repository) query(ctx context.Context, query string, args ...interface{}) ([]entities.Reservation, error) {
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []entities.Reservation
+	for rows.Next() {
+		reservation, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, *reservation)
+	}
+	return reservations, rows.Err()
+}
+
+func scanReservation(row pgx.Row) (*entities.Reservation, error) {
+	var reservation entities.Reservation
+	err := row.Scan(
+		&reservation.ID,
+		&reservation.UserID,
+		&reservation.CarID,
+		&reservation.OrderID,
+		&reservation.Status,
+		&reservation.CreatedAt,
+		&reservation.UpdatedAt,
+		&reservation.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
@@ -0,0 +1,28 @@
+package sessionrepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	Create(ctx context.Context, session *entities.Session) error
+	GetByID(ctx context.Context, id string) (*entities.Session, error)
+	GetByIDForUpdate(ctx context.Context, id string) (*entities.Session, error)
+	// Rotate stores the hash of the next refresh token, bumps the generation
+	// and extends the session to expiresAt. The replaced hash is kept so its
+	// reuse can be recognized.
+	Rotate(ctx context.Context, id, refreshTokenHash string, now, expiresAt time.Time) error
+	// WasRotated reports whether the session once had refreshTokenHash and
+	// rotated it away.
+	WasRotated(ctx context.Context, id, refreshTokenHash string) (bool, error)
+	// Revoke revokes the session unless it is already revoked.
+	Revoke(ctx context.Context, id, reason string, now time.Time) error
+	// RevokeAllForUser revokes every active session of the user except
+	// exceptID, which may be empty, and returns how many were revoked.
+	RevokeAllForUser(ctx context.Context, userID int, exceptID, reason string, now time.Time) (int64, error)
+	// DeleteExpired removes sessions that expired before now.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,122 @@
+package sessionrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+const sessionColumns = `id, user_id, refresh_token_hash, generation, user_agent, ip_address,
+	created_at, last_used_at, expires_at, revoked_at, revoke_reason`
+
+func (r *repository) Create(ctx context.Context, session *entities.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, refresh_token_hash, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7)`
+	_, err := r.conn(ctx).Exec(ctx, query,
+		session.ID, session.UserID, session.RefreshTokenHash, session.UserAgent, session.IPAddress,
+		session.CreatedAt.UTC(), session.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id string) (*entities.Session, error) {
+	return r.getOne(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, id)
+}
+
+func (r *repository) GetByIDForUpdate(ctx context.Context, id string) (*entities.Session, error) {
+	return r.getOne(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (r *repository) getOne(ctx context.Context, query, id string) (*entities.Session, error) {
+	var s entities.Session
+	err := r.conn(ctx).QueryRow(ctx, query, id).Scan(
+		&s.ID, &s.UserID, &s.RefreshTokenHash, &s.Generation, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt, &s.RevokeReason,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *repository) Rotate(ctx context.Context, id, refreshTokenHash string, now, expiresAt time.Time) error {
+	query := `
+		WITH rotated AS (
+			INSERT INTO session_rotated_tokens (session_id, token_hash)
+			SELECT id, refresh_token_hash FROM sessions WHERE id = $1 AND revoked_at IS NULL
+			ON CONFLICT DO NOTHING
+		)
+		UPDATE sessions
+		SET refresh_token_hash = $2, generation = generation + 1, last_used_at = $3, expires_at = $4
+		WHERE id = $1 AND revoked_at IS NULL`
+	tag, err := r.conn(ctx).Exec(ctx, query, id, refreshTokenHash, now.UTC(), expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return entities.ErrSessionRevoked
+	}
+	return nil
+}
+
+func (r *repository) WasRotated(ctx context.Context, id, refreshTokenHash string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM session_rotated_tokens WHERE session_id = $1 AND token_hash = $2)`
+	var rotated bool
+	if err := r.conn(ctx).QueryRow(ctx, query, id, refreshTokenHash).Scan(&rotated); err != nil {
+		return false, fmt.Errorf("failed to check rotated refresh token: %w", err)
+	}
+	return rotated, nil
+}
+
+func (r *repository) Revoke(ctx context.Context, id, reason string, now time.Time) error {
+	query := `UPDATE sessions SET revoked_at = $3, revoke_reason = $2 WHERE id = $1 AND revoked_at IS NULL`
+	if _, err := r.conn(ctx).Exec(ctx, query, id, reason, now.UTC()); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) RevokeAllForUser(ctx context.Context, userID int, exceptID, reason string, now time.Time) (int64, error) {
+	query := `
+		UPDATE sessions SET revoked_at = $4, revoke_reason = $3
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+	tag, err := r.conn(ctx).Exec(ctx, query, userID, exceptID, reason, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.conn(ctx).Exec(ctx, `DELETE FROM sessions WHERE expires_at < $1`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
@@ -0,0 +1,23 @@
+package statsrepo
+
+import (
+	"context"
+
+	"myproject/internal/pkg/money"
+)
+
+// DepositTotal sums card deposits in one payment status.
+type DepositTotal struct {
+	Status string
+	Count  int64
+	Amount money.Money
+}
+
+// Repository reads aggregate counts for monitoring.
+type Repository interface {
+	CarsByStatus(ctx context.Context) (map[string]int64, error)
+	// OrderTransitions counts every status an order has entered, so
+	// "pending" counts orders placed and "cancelled" orders cancelled.
+	OrderTransitions(ctx context.Context) (map[string]int64, error)
+	Deposits(ctx context.Context) ([]DepositTotal, error)
+}
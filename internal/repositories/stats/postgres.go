@@ -0,0 +1,74 @@
+package statsrepo
+
+import (
+	"context"
+	"fmt"
+
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CarsByStatus(ctx context.Context) (map[string]int64, error) {
+	return r.countBy(ctx, `SELECT status, count(*) FROM cars GROUP BY status`, "cars")
+}
+
+func (r *repository) OrderTransitions(ctx context.Context) (map[string]int64, error) {
+	return r.countBy(ctx, `SELECT to_status, count(*) FROM order_status_history GROUP BY to_status`, "order transitions")
+}
+
+func (r *repository) countBy(ctx context.Context, query, what string) (map[string]int64, error) {
+	rows, err := r.conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var key string
+		var n int64
+		if err := rows.Scan(&key, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan %s count: %w", what, err)
+		}
+		counts[key] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count %s: %w", what, err)
+	}
+	return counts, nil
+}
+
+func (r *repository) Deposits(ctx context.Context) ([]DepositTotal, error) {
+	query := `SELECT status, count(*), coalesce(sum(amount), 0) FROM payments GROUP BY status`
+	rows, err := r.conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum deposits: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make([]DepositTotal, 0)
+	for rows.Next() {
+		var t DepositTotal
+		if err := rows.Scan(&t.Status, &t.Count, &t.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan deposit totals: %w", err)
+		}
+		totals = append(totals, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sum deposits: %w", err)
+	}
+	return totals, nil
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
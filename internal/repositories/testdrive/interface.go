@@ -0,0 +1,21 @@
+package testdriverepo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type Repository interface {
+	Create(ctx context.Context, testDrive *entities.TestDrive) (int, error)
+	GetByID(ctx context.Context, id int) (*entities.TestDrive, error)
+	GetByUserID(ctx context.Context, userID int) ([]entities.TestDrive, error)
+	ListAll(ctx context.Context) ([]entities.TestDrive, error)
+	// ListScheduledByCar returns scheduled test drives of a car overlapping [from, to).
+	ListScheduledByCar(ctx context.Context, carID int, from, to time.Time) ([]entities.TestDrive, error)
+	Reschedule(ctx context.Context, id int, startsAt, endsAt time.Time) error
+	UpdateStatus(ctx context.Context, id int, status string) error
+	HasCarConflict(ctx context.Context, carID int, from, to time.Time, excludeID int) (bool, error)
+	HasSalespersonConflict(ctx context.Context, salespersonID int, from, to time.Time, excludeID int) (bool, error)
+}
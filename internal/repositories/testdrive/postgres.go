@@ -0,0 +1,169 @@
+package testdriverepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/repositories/txmanager"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresRepository(db *pgxpool.Pool) Repository {
+	return &repository{db: db}
+}
+
+const exclusionViolation = "23P01"
+
+const testDriveColumns = `id, user_id, car_id, salesperson_id, date, ends_at, status, created_at, updated_at`
+
+func (r *repository) Create(ctx context.Context, testDrive *entities.TestDrive) (int, error) {
+	query := `
+		INSERT INTO test_drives (user_id, car_id, salesperson_id, date, ends_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+	var id int
+	err := r.conn(ctx).QueryRow(ctx, query,
+		testDrive.UserID,
+		testDrive.CarID,
+		testDrive.SalespersonID,
+		testDrive.Date.UTC(),
+		testDrive.EndsAt.UTC(),
+		testDrive.Status,
+	).Scan(&id)
+	if isExclusionViolation(err) {
+		return 0, entities.ErrTestDriveConflict
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert test drive: %w", err)
+	}
+	return id, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (*entities.TestDrive, error) {
+	query := `SELECT ` + testDriveColumns + ` FROM test_drives WHERE id = $1`
+	testDrive, err := scanTestDrive(r.conn(ctx).QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, entities.ErrTestDriveNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test drive: %w", err)
+	}
+	return testDrive, nil
+}
+
+func (r *repository) GetByUserID(ctx context.Context, userID int) ([]entities.TestDrive, error) {
+	query := `SELECT ` + testDriveColumns + ` FROM test_drives WHERE user_id = $1 ORDER BY date DESC`
+	return r.query(ctx, query, userID)
+}
+
+func (r *repository) ListAll(ctx context.Context) ([]entities.TestDrive, error) {
+	query := `SELECT ` + testDriveColumns + ` FROM test_drives ORDER BY date DESC`
+	return r.query(ctx, query)
+}
+
+func (r *repository) ListScheduledByCar(ctx context.Context, carID int, from, to time.Time) ([]entities.TestDrive, error) {
+	query := `
+		SELECT ` + testDriveColumns + ` FROM test_drives
+		WHERE car_id = $1 AND status = $2 AND date < $4 AND ends_at > $3
+		ORDER BY date`
+	return r.query(ctx, query, carID, entities.TestDriveStatusScheduled, from.UTC(), to.UTC())
+}
+
+func (r *repository) Reschedule(ctx context.Context, id int, startsAt, endsAt time.Time) error {
+	query := `UPDATE test_drives SET date = $1, ends_at = $2, updated_at = current_timestamp WHERE id = $3`
+	_, err := r.conn(ctx).Exec(ctx, query, startsAt.UTC(), endsAt.UTC(), id)
+	if isExclusionViolation(err) {
+		return entities.ErrTestDriveConflict
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reschedule test drive: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) UpdateStatus(ctx context.Context, id int, status string) error {
+	query := `UPDATE test_drives SET status = $1, updated_at = current_timestamp WHERE id = $2`
+	_, err := r.conn(ctx).Exec(ctx, query, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update test drive status: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) HasCarConflict(ctx context.Context, carID int, from, to time.Time, excludeID int) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM test_drives
+			WHERE car_id = $1 AND status = $2 AND date < $4 AND ends_at > $3 AND id <> $5
+		)`
+	var exists bool
+	err := r.conn(ctx).QueryRow(ctx, query, carID, entities.TestDriveStatusScheduled, from.UTC(), to.UTC(), excludeID).Scan(&exists)
+	return exists, err
+}
+
+func (r *repository) HasSalespersonConflict(ctx context.Context, salespersonID int, from, to time.Time, excludeID int) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM test_drives
+			WHERE salesperson_id = $1 AND status = $2 AND date < $4 AND ends_at > $3 AND id <> $5
+		)`
+	var exists bool
+	err := r.conn(ctx).QueryRow(ctx, query, salespersonID, entities.TestDriveStatusScheduled, from.UTC(), to.UTC(), excludeID).Scan(&exists)
+	return exists, err
+}
+
+func (r *repository) query(ctx context.Context, query string, args ...interface{}) ([]entities.TestDrive, error) {
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query test drives: %w", err)
+	}
+	defer rows.Close()
+
+	var testDrives []entities.TestDrive
+	for rows.Next() {
+		testDrive, err := scanTestDrive(rows)
+		if err != nil {
+			return nil, err
+		}
+		testDrives = append(testDrives, *testDrive)
+	}
+	return testDrives, rows.Err()
+}
+
+func scanTestDrive(row pgx.Row) (*entities.TestDrive, error) {
+	var testDrive entities.TestDrive
+	err := row.Scan(
+		&testDrive.ID,
+		&testDrive.UserID,
+		&testDrive.CarID,
+		&testDrive.SalespersonID,
+		&testDrive.Date,
+		&testDrive.EndsAt,
+		&testDrive.Status,
+		&testDrive.CreatedAt,
+		&testDrive.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &testDrive, nil
+}
+
+func isExclusionViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == exclusionViolation
+}
+
+func (r *repository) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
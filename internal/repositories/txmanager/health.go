@@ -0,0 +1,50 @@
+package txmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/pkg/health"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// RegisterHealthChecks adds readiness checks for the database connection and
+// the schema version recorded by golang-migrate. minMigration is the lowest
+// version the code works with; zero only checks that no migration is dirty.
+func RegisterHealthChecks(registry *health.Registry, db *pgxpool.Pool, minMigration int64, cacheFor time.Duration) {
+	registry.Register(health.Check{
+		Name:     "database",
+		CacheFor: cacheFor,
+		Func:     db.Ping,
+	})
+	registry.Register(health.Check{
+		Name:     "migrations",
+		CacheFor: cacheFor,
+		Func: func(ctx context.Context) error {
+			return checkMigrations(ctx, db, minMigration)
+		},
+	})
+}
+
+func checkMigrations(ctx context.Context, db *pgxpool.Pool, minVersion int64) error {
+	var version int64
+	var dirty bool
+	err := db.QueryRow(ctx, `select version, dirty from schema_migrations limit 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("no migrations applied")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	if version < minVersion {
+		return fmt.Errorf("schema is at version %d, need at least %d", version, minVersion)
+	}
+	return nil
+}
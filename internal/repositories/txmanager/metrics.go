@@ -0,0 +1,36 @@
+package txmanager
+
+import (
+	"context"
+
+	"myproject/internal/pkg/metrics"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// RegisterPoolMetrics exposes the connection pool statistics of db.
+func RegisterPoolMetrics(registry *metrics.Registry, db *pgxpool.Pool) {
+	gauge := func(name, help string, read func(*pgxpool.Stat) float64) {
+		registry.NewGaugeFunc(name, help, func() float64 { return read(db.Stat()) })
+	}
+	counter := func(name, help string, read func(*pgxpool.Stat) float64) {
+		registry.NewCollector(name, help, metrics.TypeCounter, nil, func(context.Context) ([]metrics.Sample, error) {
+			return []metrics.Sample{{Value: read(db.Stat())}}, nil
+		})
+	}
+
+	gauge("db_pool_acquired_connections", "Connections currently checked out of the pool.",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) })
+	gauge("db_pool_idle_connections", "Idle connections in the pool.",
+		func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) })
+	gauge("db_pool_total_connections", "Open connections, including ones being established.",
+		func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) })
+	gauge("db_pool_max_connections", "Maximum size of the pool.",
+		func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) })
+	counter("db_pool_acquires_total", "Connections acquired from the pool.",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) })
+	counter("db_pool_empty_acquires_total", "Acquires that had to wait because no connection was idle.",
+		func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) })
+	counter("db_pool_acquire_wait_seconds_total", "Total time spent waiting to acquire a connection.",
+		func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() })
+}
@@ -0,0 +1,128 @@
+package txmanager
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+
+	"myproject/internal/pkg/tracing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+const maxStatementLength = 2048
+
+// tracedQuerier starts a client span for every statement. The span is named
+// after the repository method that issued it, e.g. order.GetByIDForUpdate.
+type tracedQuerier struct {
+	Querier
+}
+
+func (q tracedQuerier) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startQuery(ctx, sql)
+	tag, err := q.Querier.Exec(ctx, sql, args...)
+	if err == nil {
+		span.SetAttributes(tracing.Attr("db.rows_affected", tag.RowsAffected()))
+	}
+	span.RecordError(err)
+	span.End()
+	return tag, err
+}
+
+func (q tracedQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := startQuery(ctx, sql)
+	rows, err := q.Querier.Query(ctx, sql, args...)
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (q tracedQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := startQuery(ctx, sql)
+	return &tracedRow{row: q.Querier.QueryRow(ctx, sql, args...), span: span}
+}
+
+// tracedRows ends the span once the result is read or closed.
+type tracedRows struct {
+	pgx.Rows
+	span *tracing.Span
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.finish()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *tracedRows) finish() {
+	r.span.RecordError(r.Rows.Err())
+	r.span.End()
+}
+
+type tracedRow struct {
+	row  pgx.Row
+	span *tracing.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		r.span.RecordError(err)
+	}
+	r.span.End()
+	return err
+}
+
+// startQuery must be called directly from the Querier method so the caller
+// two frames up is the repository.
+func startQuery(ctx context.Context, sql string) (context.Context, *tracing.Span) {
+	name := statementName(3)
+	statement := strings.Join(strings.Fields(sql), " ")
+	if len(statement) > maxStatementLength {
+		statement = statement[:maxStatementLength]
+	}
+	operation, _, _ := strings.Cut(statement, " ")
+
+	return tracing.StartSpan(ctx, name, tracing.SpanKindClient,
+		tracing.Attr("db.system", "postgresql"),
+		tracing.Attr("db.statement.name", name),
+		tracing.Attr("db.operation", strings.ToUpper(operation)),
+		tracing.Attr("db.statement", statement),
+	)
+}
+
+// statementName turns the caller's function, such as
+// myproject/internal/repositories/order.(*repository).Create, into order.Create.
+func statementName(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return "db.query"
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "db.query"
+	}
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	pkg, rest, _ := strings.Cut(name, ".")
+	if strings.HasPrefix(rest, "(") {
+		if _, method, ok := strings.Cut(rest, ")."); ok {
+			rest = method
+		}
+	}
+	return pkg + "." + rest
+}
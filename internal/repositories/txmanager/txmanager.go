@@ -0,0 +1,103 @@
+package txmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"myproject/internal/pkg/tracing"
+	"myproject/pkg/logger"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	maxAttempts          = 3
+)
+
+// Querier is the subset of pgxpool.Pool and pgx.Tx used by repositories.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type Manager interface {
+	// WithinTransaction runs fn in a serializable transaction carried by the
+	// context passed to fn. Nested calls join the outer transaction.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type manager struct {
+	db *pgxpool.Pool
+}
+
+func New(db *pgxpool.Pool) Manager {
+	return &manager{db: db}
+}
+
+type txKey struct{}
+
+// Conn returns the transaction stored in ctx, or db when there is none.
+// Statements are traced when ctx carries a span.
+func Conn(ctx context.Context, db *pgxpool.Pool) Querier {
+	var q Querier = db
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		q = tx
+	}
+	if tracing.SpanFromContext(ctx) != nil {
+		return tracedQuerier{q}
+	}
+	return q
+}
+
+func (m *manager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = m.run(ctx, fn)
+		if !isRetryable(err) {
+			return err
+		}
+		logger.FromContext(ctx).Debug("transaction conflict, retrying", "attempt", attempt, "error", err)
+	}
+	return fmt.Errorf("transaction failed after %d attempts: %w", maxAttempts, err)
+}
+
+func (m *manager) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	ctx, span := tracing.Start(ctx, "db.transaction", tracing.Attr("db.system", "postgresql"))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	tx, err := m.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+}
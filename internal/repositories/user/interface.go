@@ -2,6 +2,8 @@ package userrepo
 
 import (
 	"context"
+	"time"
+
 	"myproject/internal/entities"
 )
 
@@ -9,10 +11,14 @@ type Repository interface {
 	Create(ctx context.Context, user *entities.User) error
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
 	GetByID(ctx context.Context, id int) (*entities.User, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*entities.User, error)
 	Update(ctx context.Context, user *entities.User) error
-	UpdateBalance(ctx context.Context, id int, amount float64) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	// MarkEmailVerified returns entities.ErrEmailAlreadyVerified when the
+	// address was confirmed before.
+	MarkEmailVerified(ctx context.Context, id int, at time.Time) error
 	Delete(ctx context.Context, id int) error
 	IsEmailExists(ctx context.Context, email string) (bool, error)
-	List(ctx context.Context, limit, offset int) ([]*entities.User, error) // Добавьте этот метод
-	Count(ctx context.Context) (int, error)                                // Добавьте этот метод
+	List(ctx context.Context, page entities.PageRequest) ([]*entities.User, entities.PageInfo, error)
+	Count(ctx context.Context) (int, error)
 }
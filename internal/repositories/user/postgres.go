@@ -3,8 +3,14 @@ package userrepo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
+
 	entity "myproject/internal/entities"
+	"myproject/internal/repositories/listquery"
+	"myproject/internal/repositories/txmanager"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -16,35 +22,37 @@ func NewPostgresRepo(db *pgxpool.Pool) Repository {
 	return &postgresRepo{db: db}
 }
 
+const userColumns = `id, name, email, password_hash, balance, role, email_verified_at`
+
 func (r *postgresRepo) Create(ctx context.Context, user *entity.User) error {
-	query := `INSERT INTO users (name, email, password_hash, balance, role) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(ctx, query, user.Name, user.Email, user.PasswordHash, user.Balance, user.Role)
-	return err
+	query := `INSERT INTO users (name, email, password_hash, balance, role) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	return r.conn(ctx).QueryRow(ctx, query, user.Name, user.Email, user.PasswordHash, user.Balance, user.Role).
+		Scan(&user.ID)
 }
 
 func (r *postgresRepo) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
-	var user entity.User
-	query := `SELECT id, name, email, password_hash, balance, role FROM users WHERE email = $1`
-	err := r.db.QueryRow(ctx, query, email).Scan(
-		&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.Balance, &user.Role,
-	)
-	if err != nil {
-		if errors.Is(err, errors.New("pgx: no rows in result")) {
-			return nil, entity.ErrNotFound
-		}
-		return nil, err
-	}
-	return &user, nil
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+	return r.getOne(ctx, query, email)
 }
 
 func (r *postgresRepo) GetByID(ctx context.Context, id int) (*entity.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *postgresRepo) GetByIDForUpdate(ctx context.Context, id int) (*entity.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1 FOR UPDATE`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *postgresRepo) getOne(ctx context.Context, query string, arg interface{}) (*entity.User, error) {
 	var user entity.User
-	query := `SELECT id, name, email, password_hash, balance, role FROM users WHERE id = $1`
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := r.conn(ctx).QueryRow(ctx, query, arg).Scan(
 		&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.Balance, &user.Role,
+		&user.EmailVerifiedAt,
 	)
 	if err != nil {
-		if errors.Is(err, errors.New("pgx: no rows in result")) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, entity.ErrNotFound
 		}
 		return nil, err
@@ -52,58 +60,112 @@ func (r *postgresRepo) GetByID(ctx context.Context, id int) (*entity.User, error
 	return &user, nil
 }
 
+// Update clears email_verified_at when the email changes; the new address has
+// to be confirmed again.
 func (r *postgresRepo) Update(ctx context.Context, user *entity.User) error {
-	query := `UPDATE users SET name=$1, email=$2, balance=$3, role=$4 WHERE id=$5`
-	_, err := r.db.Exec(ctx, query, user.Name, user.Email, user.Balance, user.Role, user.ID)
+	query := `
+		UPDATE users SET name=$1, email=$2, role=$3,
+			email_verified_at = CASE WHEN email = $2 THEN email_verified_at END
+		WHERE id=$4`
+	_, err := r.conn(ctx).Exec(ctx, query, user.Name, user.Email, user.Role, user.ID)
 	return err
 }
 
-func (r *postgresRepo) UpdateBalance(ctx context.Context, id int, amount float64) error {
-	query := `UPDATE users SET balance=$1 WHERE id=$2`
-	_, err := r.db.Exec(ctx, query, amount, id)
-	return err
+func (r *postgresRepo) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2, updated_at = $3 WHERE id = $1`
+	tag, err := r.conn(ctx).Exec(ctx, query, id, passwordHash, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *postgresRepo) MarkEmailVerified(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE users SET email_verified_at = $2 WHERE id = $1 AND email_verified_at IS NULL`
+	tag, err := r.conn(ctx).Exec(ctx, query, id, at.UTC())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.ErrEmailAlreadyVerified
+	}
+	return nil
 }
 
 func (r *postgresRepo) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM users WHERE id=$1`
-	_, err := r.db.Exec(ctx, query, id)
+	_, err := r.conn(ctx).Exec(ctx, query, id)
 	return err
 }
 
 func (r *postgresRepo) IsEmailExists(ctx context.Context, email string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
-	err := r.db.QueryRow(ctx, query, email).Scan(&exists)
+	err := r.conn(ctx).QueryRow(ctx, query, email).Scan(&exists)
 	return exists, err
 }
 
-func (r *postgresRepo) List(ctx context.Context, limit, offset int) ([]*entity.User, error) {
-	var users []*entity.User
-	query := `SELECT id, name, email, password_hash, balance, role FROM users LIMIT $1 OFFSET $2`
-	rows, err := r.db.Query(ctx, query, limit, offset)
+var userSort = &listquery.Spec{
+	Columns: map[string]listquery.Column{
+		"id":         {Expr: "id", Type: "int"},
+		"name":       {Expr: "name", Type: "text"},
+		"email":      {Expr: "email", Type: "text"},
+		"created_at": {Expr: "created_at", Type: "timestamp"},
+	},
+	ID:           listquery.Column{Expr: "id", Type: "int"},
+	DefaultSort:  "id",
+	DefaultLimit: 10,
+	MaxLimit:     100,
+}
+
+func (r *postgresRepo) List(ctx context.Context, page entity.PageRequest) ([]*entity.User, entity.PageInfo, error) {
+	q, err := listquery.New(userSort, page)
 	if err != nil {
-		return nil, err
+		return nil, entity.PageInfo{}, err
+	}
+
+	var total int
+	countQuery, countArgs := q.Count("users")
+	if err := r.conn(ctx).QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, entity.PageInfo{}, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query, args := q.Select(userColumns, "users")
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, entity.PageInfo{}, fmt.Errorf("failed to list users: %w", err)
 	}
 	defer rows.Close()
 
+	users := make([]*entity.User, 0)
 	for rows.Next() {
 		var user entity.User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.Balance, &user.Role); err != nil {
-			return nil, err
+		if err := rows.Scan(q.Dest(
+			&user.ID, &user.Name, &user.Email, &user.PasswordHash, &user.Balance, &user.Role,
+			&user.EmailVerifiedAt,
+		)...); err != nil {
+			return nil, entity.PageInfo{}, fmt.Errorf("failed to scan user: %w", err)
 		}
 		users = append(users, &user)
 	}
-
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, entity.PageInfo{}, fmt.Errorf("failed to list users: %w", err)
 	}
 
-	return users, nil
+	n, info := q.Page(total)
+	return users[:n], info, nil
 }
 
 func (r *postgresRepo) Count(ctx context.Context) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM users`
-	err := r.db.QueryRow(ctx, query).Scan(&count)
+	err := r.conn(ctx).QueryRow(ctx, query).Scan(&count)
 	return count, err
 }
+
+func (r *postgresRepo) conn(ctx context.Context) txmanager.Querier {
+	return txmanager.Conn(ctx, r.db)
+}
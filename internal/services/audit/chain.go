@@ -0,0 +1,130 @@
+package auditservice
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+const redacted = "[redacted]"
+
+// ignoredFields change on every write and say nothing about the action.
+var ignoredFields = map[string]bool{"updated_at": true}
+
+// change is one field of an audit diff. From is absent for creations and To
+// for deletions.
+type change struct {
+	From json.RawMessage `json:"from,omitempty"`
+	To   json.RawMessage `json:"to,omitempty"`
+}
+
+// diff compares the JSON forms of before and after field by field. Values
+// of secret fields are replaced so only the fact that they changed is kept.
+func diff(before, after any) (map[string]change, error) {
+	from, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]change)
+	for name, value := range from {
+		if ignoredFields[name] {
+			continue
+		}
+		if other, ok := to[name]; ok && bytes.Equal(value, other) {
+			continue
+		}
+		changes[name] = change{From: value, To: to[name]}
+	}
+	for name, value := range to {
+		if _, ok := from[name]; ok || ignoredFields[name] {
+			continue
+		}
+		changes[name] = change{To: value}
+	}
+
+	secret, _ := json.Marshal(redacted)
+	for name, c := range changes {
+		if !isSecret(name) {
+			continue
+		}
+		if c.From != nil {
+			c.From = secret
+		}
+		if c.To != nil {
+			c.To = secret
+		}
+		changes[name] = c
+	}
+	return changes, nil
+}
+
+func fields(v any) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &out); err != nil {
+		return nil, fmt.Errorf("audit values must encode as JSON objects: %w", err)
+	}
+	return out, nil
+}
+
+func isSecret(field string) bool {
+	field = strings.ToLower(field)
+	for _, word := range []string{"password", "secret", "token", "hash"} {
+		if strings.Contains(field, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// chainedEvent fixes the field order the hash is computed over.
+type chainedEvent struct {
+	PrevHash   string          `json:"prev_hash"`
+	ActorID    *int            `json:"actor_id"`
+	ActorRole  string          `json:"actor_role"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int             `json:"entity_id"`
+	Changes    json.RawMessage `json:"changes"`
+	RequestID  string          `json:"request_id"`
+	IPAddress  string          `json:"ip_address"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// hashEvent returns the hex SHA-256 of the event and its predecessor's hash.
+func hashEvent(e *entities.AuditEvent) (string, error) {
+	encoded, err := json.Marshal(chainedEvent{
+		PrevHash:   e.PrevHash,
+		ActorID:    e.ActorID,
+		ActorRole:  e.ActorRole,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Changes:    e.Changes,
+		RequestID:  e.RequestID,
+		IPAddress:  e.IPAddress,
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit event: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
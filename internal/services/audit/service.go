@@ -0,0 +1,151 @@
+package auditservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/identity"
+	"myproject/internal/pkg/requestinfo"
+	auditrepo "myproject/internal/repositories/audit"
+	"myproject/internal/repositories/txmanager"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+
+	verifyBatch = 500
+)
+
+// Service appends entries to the hash-chained audit trail. Callers record
+// inside their own transaction so an entry exists exactly when the change
+// it describes was committed.
+type Service struct {
+	repo auditrepo.Repository
+	tx   txmanager.Manager
+}
+
+func NewService(repo auditrepo.Repository, tx txmanager.Manager) *Service {
+	return &Service{repo: repo, tx: tx}
+}
+
+// Record stores who did action to the entity and what changed between
+// before and after, either of which may be nil. The actor and request are
+// taken from ctx. Updates that change nothing are not recorded.
+func (s *Service) Record(ctx context.Context, action, entityType string, entityID int, before, after any) error {
+	changes, err := diff(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s %d: %w", entityType, entityID, err)
+	}
+	if before != nil && after != nil && len(changes) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	event := &entities.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    encoded,
+		// Postgres keeps microseconds; the hash must cover what is stored.
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if caller, ok := identity.FromContext(ctx); ok {
+		event.ActorID = &caller.UserID
+		event.ActorRole = caller.Role
+	}
+	if info, ok := requestinfo.FromContext(ctx); ok {
+		event.RequestID = info.RequestID
+		event.IPAddress = info.IPAddress
+	}
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		prev, err := s.repo.LockHead(ctx)
+		if err != nil {
+			return err
+		}
+		event.PrevHash = prev
+		if event.Hash, err = hashEvent(event); err != nil {
+			return err
+		}
+		event.ID, err = s.repo.Append(ctx, event)
+		return err
+	})
+}
+
+func (s *Service) List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error) {
+	if filter.Limit < 0 || filter.EntityID < 0 || filter.ActorID < 0 || filter.BeforeID < 0 {
+		return nil, entities.ErrInvalidAuditFilter
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", entities.ErrInvalidAuditFilter)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+	return s.repo.List(ctx, filter)
+}
+
+// Verify recomputes every hash from the first entry on and checks that each
+// entry links to the one before it and that the chain ends at the head.
+func (s *Service) Verify(ctx context.Context) (*entities.AuditVerification, error) {
+	// The head is read first so entries appended during the walk are
+	// simply checked too.
+	head, err := s.repo.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entities.AuditVerification{Valid: true, LastHash: entities.AuditGenesisHash}
+	reachedHead := head == entities.AuditGenesisHash
+	var afterID int64
+	for {
+		events, err := s.repo.ListAfter(ctx, afterID, verifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range events {
+			e := &events[i]
+			hash, err := hashEvent(e)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case e.PrevHash != result.LastHash:
+				return brokenAt(result, e.ID, "entry does not link to the previous entry"), nil
+			case hash != e.Hash:
+				return brokenAt(result, e.ID, "entry hash does not match its contents"), nil
+			}
+			result.Checked++
+			result.LastHash = e.Hash
+			if e.Hash == head {
+				reachedHead = true
+			}
+			afterID = e.ID
+		}
+		if len(events) < verifyBatch {
+			break
+		}
+	}
+	if !reachedHead {
+		result.Valid = false
+		result.Reason = "chain does not reach the recorded head"
+	}
+	return result, nil
+}
+
+func brokenAt(r *entities.AuditVerification, id int64, reason string) *entities.AuditVerification {
+	r.Valid = false
+	r.BrokenAt = &id
+	r.Reason = reason
+	return r
+}
@@ -5,7 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"myproject/internal/entities"
+	"myproject/internal/pkg/tracing"
+	"myproject/internal/pkg/vin"
 	carrepo "myproject/internal/repositories/car"
+	"myproject/internal/repositories/txmanager"
+	"strings"
 	"time"
 )
 
@@ -14,95 +18,279 @@ type CarService interface {
 	GetCar(ctx context.Context, id int) (*entities.Car, error)
 	UpdateCar(ctx context.Context, id int, input entities.CarUpdate) (*entities.Car, error)
 	DeleteCar(ctx context.Context, id int) error
-	ListCars(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, int, error)
+	ListCars(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, entities.PageInfo, error)
+	SuggestCars(ctx context.Context, prefix string, limit int) ([]entities.CarSuggestion, error)
+	CarFacets(ctx context.Context, filter entities.CarFilter) (entities.FacetSummary, error)
 	ChangeCarStatus(ctx context.Context, id int, status entities.CarStatus) (*entities.Car, error)
+	DecodeVIN(ctx context.Context, number string) (vin.Info, error)
 	CheckAvailability(ctx context.Context, carID int, startDate, endDate time.Time) (bool, error)
 	UpdateStatus(ctx context.Context, carID int, status string) error
+	LockCar(ctx context.Context, carID int) (*entities.Car, error)
+}
+
+const maxSuggestions = 10
+
+type TestDriveRepository interface {
+	HasCarConflict(ctx context.Context, carID int, from, to time.Time, excludeID int) (bool, error)
+}
+
+type ReservationRepository interface {
+	HasActiveForCar(ctx context.Context, carID int, from, to time.Time) (bool, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, action, entityType string, entityID int, before, after any) error
+}
+
+// MediaLister returns a car's photos and documents with signed URLs.
+type MediaLister interface {
+	MediaForCar(ctx context.Context, carID int) ([]*entities.CarMedia, error)
 }
 
 type service struct {
-	repo carrepo.Repository
+	repo         carrepo.Repository
+	tx           txmanager.Manager
+	testDrives   TestDriveRepository
+	reservations ReservationRepository
+	audit        Auditor
+	media        MediaLister
 }
 
-func NewService(repo carrepo.Repository) CarService {
-	return &service{repo: repo}
+func NewService(
+	repo carrepo.Repository,
+	tx txmanager.Manager,
+	testDrives TestDriveRepository,
+	reservations ReservationRepository,
+	audit Auditor,
+	media MediaLister,
+) CarService {
+	return &service{repo: repo, tx: tx, testDrives: testDrives, reservations: reservations, audit: audit, media: media}
 }
 
 func (s *service) CreateCar(ctx context.Context, input *entities.Car) (*entities.Car, error) {
+	ctx, span := tracing.Start(ctx, "carservice.CreateCar")
+	defer span.End()
+
+	input.VIN = vin.Normalize(input.VIN)
+	if input.Condition == "" {
+		input.Condition = entities.ConditionUsed
+	}
 	if err := validateCar(input); err != nil {
-		return nil, fmt.Errorf("validation error: %w", err)
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidCar, err)
 	}
 
 	input.Status = entities.CarStatusAvailable
-	id, err := s.repo.Create(ctx, input)
+	var car *entities.Car
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		id, err := s.repo.Create(ctx, input)
+		if err != nil {
+			return fmt.Errorf("repository error: %w", err)
+		}
+		if car, err = s.repo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditCarCreate, entities.AuditEntityCar, id, nil, car)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("repository error: %w", err)
+		return nil, err
 	}
-
-	return s.repo.GetByID(ctx, id)
+	return car, nil
 }
 
 func (s *service) GetCar(ctx context.Context, id int) (*entities.Car, error) {
+	ctx, span := tracing.Start(ctx, "carservice.GetCar", tracing.Attr("car.id", id))
+	defer span.End()
+
 	if id <= 0 {
 		return nil, errors.New("invalid car ID")
 	}
-	return s.repo.GetByID(ctx, id)
+	car, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if car.Media, err = s.media.MediaForCar(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to list car media: %w", err)
+	}
+	return car, nil
 }
 
 func (s *service) UpdateCar(ctx context.Context, id int, input entities.CarUpdate) (*entities.Car, error) {
+	ctx, span := tracing.Start(ctx, "carservice.UpdateCar", tracing.Attr("car.id", id))
+	defer span.End()
+
 	if id <= 0 {
 		return nil, errors.New("invalid car ID")
 	}
 
-	if err := s.repo.Update(ctx, id, input); err != nil {
-		return nil, err
+	if input.VIN != nil {
+		normalized := vin.Normalize(*input.VIN)
+		input.VIN = &normalized
 	}
 
-	return s.repo.GetByID(ctx, id)
+	return s.change(ctx, id, entities.AuditCarUpdate, func(ctx context.Context, before *entities.Car) error {
+		after := applyUpdate(*before, input)
+		if err := validateCar(&after); err != nil {
+			return fmt.Errorf("%w: %v", entities.ErrInvalidCar, err)
+		}
+		return s.repo.Update(ctx, id, input)
+	})
 }
 
 func (s *service) DeleteCar(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "carservice.DeleteCar", tracing.Attr("car.id", id))
+	defer span.End()
+
 	if id <= 0 {
 		return errors.New("invalid car ID")
 	}
-	return s.repo.Delete(ctx, id)
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditCarDelete, entities.AuditEntityCar, id, before, nil)
+	})
 }
 
-func (s *service) ListCars(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, int, error) {
-	cars, err := s.repo.List(ctx, filter)
-	if err != nil {
-		return nil, 0, err
+func (s *service) ListCars(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, entities.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "carservice.ListCars")
+	defer span.End()
+
+	return s.repo.List(ctx, filter)
+}
+
+func (s *service) CarFacets(ctx context.Context, filter entities.CarFilter) (entities.FacetSummary, error) {
+	ctx, span := tracing.Start(ctx, "carservice.CarFacets")
+	defer span.End()
+
+	return s.repo.Facets(ctx, filter)
+}
+
+// SuggestCars completes a brand or model prefix for the search box. Blank
+// prefixes return nothing rather than the whole catalog.
+func (s *service) SuggestCars(ctx context.Context, prefix string, limit int) ([]entities.CarSuggestion, error) {
+	ctx, span := tracing.Start(ctx, "carservice.SuggestCars")
+	defer span.End()
+
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return []entities.CarSuggestion{}, nil
 	}
-	return cars, len(cars), nil
+	if limit <= 0 || limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+	return s.repo.Suggest(ctx, prefix, limit)
 }
 
 func (s *service) ChangeCarStatus(ctx context.Context, id int, status entities.CarStatus) (*entities.Car, error) {
+	ctx, span := tracing.Start(ctx, "carservice.ChangeCarStatus", tracing.Attr("car.id", id), tracing.Attr("car.status", string(status)))
+	defer span.End()
+
 	if id <= 0 {
 		return nil, errors.New("invalid car ID")
 	}
 
-	if err := s.repo.SetStatus(ctx, id, string(status)); err != nil {
+	return s.change(ctx, id, entities.AuditCarStatusChange, func(ctx context.Context, _ *entities.Car) error {
+		return s.repo.SetStatus(ctx, id, string(status))
+	})
+}
+
+// change applies update to the locked car and audits the difference. update
+// receives the car as it was before the change.
+func (s *service) change(ctx context.Context, id int, action string, update func(ctx context.Context, before *entities.Car) error) (*entities.Car, error) {
+	var after *entities.Car
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := update(ctx, before); err != nil {
+			return err
+		}
+		if after, err = s.repo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, action, entities.AuditEntityCar, id, before, after)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return after, nil
+}
+
+// DecodeVIN validates a VIN and reads the manufacturer, model year and plant
+// from it without calling out to any service.
+func (s *service) DecodeVIN(ctx context.Context, number string) (vin.Info, error) {
+	_, span := tracing.Start(ctx, "carservice.DecodeVIN")
+	defer span.End()
 
-	return s.repo.GetByID(ctx, id)
+	info, err := vin.Decode(number)
+	if err != nil {
+		return vin.Info{}, fmt.Errorf("%w: %v", entities.ErrInvalidVIN, err)
+	}
+	return info, nil
 }
 
+// CheckAvailability reports whether the car is free for the whole [startDate, endDate]
+// window. Zero dates only check the current car status.
 func (s *service) CheckAvailability(ctx context.Context, carID int, startDate, endDate time.Time) (bool, error) {
+	ctx, span := tracing.Start(ctx, "carservice.CheckAvailability", tracing.Attr("car.id", carID))
+	defer span.End()
+
+	if endDate.Before(startDate) {
+		return false, errors.New("end date is before start date")
+	}
+
 	car, err := s.repo.GetByID(ctx, carID)
 	if err != nil {
 		return false, fmt.Errorf("failed to get car: %w", err)
 	}
-	return car.Status == entities.CarStatusAvailable, nil
+	if car.Status != entities.CarStatusAvailable {
+		return false, nil
+	}
+	if startDate.IsZero() {
+		return true, nil
+	}
+
+	reserved, err := s.reservations.HasActiveForCar(ctx, carID, startDate, endDate)
+	if err != nil {
+		return false, fmt.Errorf("failed to check reservations: %w", err)
+	}
+	if reserved {
+		return false, nil
+	}
+
+	busy, err := s.testDrives.HasCarConflict(ctx, carID, startDate, endDate, 0)
+	if err != nil {
+		return false, fmt.Errorf("failed to check test drives: %w", err)
+	}
+	return !busy, nil
 }
 
 func (s *service) UpdateStatus(ctx context.Context, carID int, status string) error {
+	ctx, span := tracing.Start(ctx, "carservice.UpdateStatus", tracing.Attr("car.id", carID), tracing.Attr("car.status", status))
+	defer span.End()
+
 	if carID <= 0 {
 		return errors.New("invalid car ID")
 	}
 	return s.repo.SetStatus(ctx, carID, status)
 }
 
+// LockCar locks the car row until the surrounding transaction ends.
+func (s *service) LockCar(ctx context.Context, carID int) (*entities.Car, error) {
+	ctx, span := tracing.Start(ctx, "carservice.LockCar", tracing.Attr("car.id", carID))
+	defer span.End()
+
+	if carID <= 0 {
+		return nil, errors.New("invalid car ID")
+	}
+	return s.repo.GetByIDForUpdate(ctx, carID)
+}
+
 func validateCar(car *entities.Car) error {
 	if car.Brand == "" {
 		return errors.New("brand is required")
@@ -113,11 +301,11 @@ func validateCar(car *entities.Car) error {
 	if car.Year < 1900 || car.Year > 2100 {
 		return errors.New("invalid year")
 	}
-	if car.Price <= 0 {
+	if !car.Price.IsPositive() {
 		return errors.New("price must be positive")
 	}
 	if car.Mileage < 0 {
 		return errors.New("mileage cannot be negative")
 	}
-	return nil
+	return validateSpec(&car.CarSpec, car.Year)
 }
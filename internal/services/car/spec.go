@@ -0,0 +1,166 @@
+package carservice
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/vin"
+)
+
+const (
+	maxFeatures     = 50
+	maxEngineVolume = 10.0
+	maxPowerHP      = 2000
+	maxDoors        = 9
+	maxSeats        = 99
+)
+
+var featureKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+var (
+	bodyTypes = map[entities.BodyType]bool{
+		entities.BodySedan: true, entities.BodyHatchback: true, entities.BodyWagon: true,
+		entities.BodyCoupe: true, entities.BodyConvertible: true, entities.BodySUV: true,
+		entities.BodyCrossover: true, entities.BodyMinivan: true, entities.BodyVan: true,
+		entities.BodyPickup: true,
+	}
+	fuelTypes = map[entities.FuelType]bool{
+		entities.FuelPetrol: true, entities.FuelDiesel: true, entities.FuelHybrid: true,
+		entities.FuelPluginHybrid: true, entities.FuelElectric: true, entities.FuelLPG: true,
+	}
+	transmissions = map[entities.Transmission]bool{
+		entities.TransmissionManual: true, entities.TransmissionAutomatic: true,
+		entities.TransmissionCVT: true, entities.TransmissionDCT: true,
+	}
+	drivetrains = map[entities.Drivetrain]bool{
+		entities.DrivetrainFWD: true, entities.DrivetrainRWD: true,
+		entities.DrivetrainAWD: true, entities.Drivetrain4WD: true,
+	}
+)
+
+// validateSpec checks the specification of a car built in year. Empty enums
+// and zero numbers are allowed and mean "unknown".
+func validateSpec(spec *entities.CarSpec, year int) error {
+	if spec.BodyType != "" && !bodyTypes[spec.BodyType] {
+		return fmt.Errorf("unknown body type %q", spec.BodyType)
+	}
+	if spec.FuelType != "" && !fuelTypes[spec.FuelType] {
+		return fmt.Errorf("unknown fuel type %q", spec.FuelType)
+	}
+	if spec.Transmission != "" && !transmissions[spec.Transmission] {
+		return fmt.Errorf("unknown transmission %q", spec.Transmission)
+	}
+	if spec.Drivetrain != "" && !drivetrains[spec.Drivetrain] {
+		return fmt.Errorf("unknown drivetrain %q", spec.Drivetrain)
+	}
+	if spec.Condition != entities.ConditionNew && spec.Condition != entities.ConditionUsed {
+		return fmt.Errorf("condition must be %q or %q", entities.ConditionNew, entities.ConditionUsed)
+	}
+
+	if spec.EngineVolume < 0 || spec.EngineVolume > maxEngineVolume {
+		return fmt.Errorf("engine volume must be between 0 and %.0f litres", maxEngineVolume)
+	}
+	if spec.FuelType == entities.FuelElectric && spec.EngineVolume != 0 {
+		return errors.New("electric cars have no engine volume")
+	}
+	if spec.PowerHP < 0 || spec.PowerHP > maxPowerHP {
+		return fmt.Errorf("power must be between 0 and %d hp", maxPowerHP)
+	}
+	if spec.Doors < 0 || spec.Doors > maxDoors {
+		return fmt.Errorf("doors must be between 0 and %d", maxDoors)
+	}
+	if spec.Seats < 0 || spec.Seats > maxSeats {
+		return fmt.Errorf("seats must be between 0 and %d", maxSeats)
+	}
+
+	if spec.VIN != "" {
+		info, err := vin.Decode(spec.VIN)
+		if err != nil {
+			return err
+		}
+		if !info.MatchesYear(year) {
+			return fmt.Errorf("vin encodes model year %d, not %d", info.ModelYear, year)
+		}
+	}
+	return validateFeatures(spec.Features)
+}
+
+// validateFeatures keeps the feature bag flat so every key can be filtered on.
+func validateFeatures(features map[string]any) error {
+	if len(features) > maxFeatures {
+		return fmt.Errorf("at most %d features are allowed", maxFeatures)
+	}
+	for key, value := range features {
+		if !featureKeyPattern.MatchString(key) {
+			return fmt.Errorf("feature %q must be snake_case and at most 40 characters", key)
+		}
+		switch value.(type) {
+		case bool, string, float64:
+		default:
+			return fmt.Errorf("feature %q must be a boolean, number or string", key)
+		}
+	}
+	return nil
+}
+
+// applyUpdate returns car with the fields set in update, so an update can be
+// validated as a whole car.
+func applyUpdate(car entities.Car, update entities.CarUpdate) entities.Car {
+	if update.Brand != nil {
+		car.Brand = *update.Brand
+	}
+	if update.Model != nil {
+		car.Model = *update.Model
+	}
+	if update.Year != nil {
+		car.Year = *update.Year
+	}
+	if update.Price != nil {
+		car.Price = *update.Price
+	}
+	if update.Mileage != nil {
+		car.Mileage = *update.Mileage
+	}
+	if update.Color != nil {
+		car.Color = *update.Color
+	}
+	if update.Description != nil {
+		car.Description = *update.Description
+	}
+	if update.VIN != nil {
+		car.VIN = *update.VIN
+	}
+	if update.BodyType != nil {
+		car.BodyType = *update.BodyType
+	}
+	if update.FuelType != nil {
+		car.FuelType = *update.FuelType
+	}
+	if update.Transmission != nil {
+		car.Transmission = *update.Transmission
+	}
+	if update.Drivetrain != nil {
+		car.Drivetrain = *update.Drivetrain
+	}
+	if update.EngineVolume != nil {
+		car.EngineVolume = *update.EngineVolume
+	}
+	if update.PowerHP != nil {
+		car.PowerHP = *update.PowerHP
+	}
+	if update.Doors != nil {
+		car.Doors = *update.Doors
+	}
+	if update.Seats != nil {
+		car.Seats = *update.Seats
+	}
+	if update.Condition != nil {
+		car.Condition = *update.Condition
+	}
+	if update.Features != nil {
+		car.Features = *update.Features
+	}
+	return car
+}
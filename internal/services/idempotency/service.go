@@ -0,0 +1,83 @@
+package idempotencyservice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/internal/entities"
+	idempotencyrepo "myproject/internal/repositories/idempotency"
+)
+
+// staleAfter is how long a key may stay processing before another request
+// with the same key is allowed to take it over.
+const staleAfter = time.Minute
+
+type Service struct {
+	repo idempotencyrepo.Repository
+	ttl  time.Duration
+}
+
+func NewService(repo idempotencyrepo.Repository, ttl time.Duration) *Service {
+	return &Service{repo: repo, ttl: ttl}
+}
+
+// Begin claims key for the caller. It returns a nil record when the request
+// should run, the stored record when a completed response can be replayed,
+// and ErrIdempotencyKeyMismatch or ErrIdempotencyKeyInProgress otherwise.
+func (s *Service) Begin(ctx context.Context, userID int, key, fingerprint string) (*entities.IdempotencyRecord, error) {
+	now := time.Now()
+	acquired, err := s.repo.Acquire(ctx, &entities.IdempotencyRecord{
+		Key:         key,
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		Status:      entities.IdempotencyStatusProcessing,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if acquired {
+		return nil, nil
+	}
+
+	record, err := s.repo.Get(ctx, userID, key)
+	if errors.Is(err, entities.ErrIdempotencyKeyNotFound) {
+		// Expired and purged between the two statements; the client may retry.
+		return nil, entities.ErrIdempotencyKeyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, entities.ErrIdempotencyKeyMismatch
+	}
+	if record.Status == entities.IdempotencyStatusCompleted {
+		return record, nil
+	}
+
+	tookOver, err := s.repo.TakeOver(ctx, userID, key, now, now.Add(-staleAfter))
+	if err != nil {
+		return nil, err
+	}
+	if tookOver {
+		return nil, nil
+	}
+	return nil, entities.ErrIdempotencyKeyInProgress
+}
+
+// Complete stores the response so later requests with the key replay it.
+func (s *Service) Complete(ctx context.Context, userID int, key string, status int, body []byte, contentType string) error {
+	return s.repo.Complete(ctx, userID, key, status, body, contentType)
+}
+
+// Release forgets the key so the request can be retried, e.g. after a 5xx.
+func (s *Service) Release(ctx context.Context, userID int, key string) error {
+	return s.repo.Delete(ctx, userID, key)
+}
+
+func (s *Service) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx, time.Now())
+}
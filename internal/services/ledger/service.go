@@ -0,0 +1,252 @@
+package ledgerservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
+	ledgerrepo "myproject/internal/repositories/ledger"
+	"myproject/internal/repositories/txmanager"
+)
+
+type Service struct {
+	repo ledgerrepo.Repository
+	tx   txmanager.Manager
+}
+
+func NewService(repo ledgerrepo.Repository, tx txmanager.Manager) *Service {
+	return &Service{repo: repo, tx: tx}
+}
+
+// leg is one side of a transfer, addressed by account code so callers never
+// deal with account IDs.
+type leg struct {
+	code   string
+	userID int
+	amount money.Money
+}
+
+// RecordDeposit credits money received from the customer to their wallet and
+// returns the journal entry ID.
+func (s *Service) RecordDeposit(ctx context.Context, userID int, amount money.Money, reference string) (int, error) {
+	return s.transferEntry(ctx, entities.JournalEntryDeposit, reference, "Wallet deposit", amount,
+		leg{code: entities.AccountCodeCash},
+		leg{userID: userID},
+	)
+}
+
+// RecordDepositRefund reverses a deposit whose card charge was refunded. It
+// fails with entities.ErrInsufficientFunds if the money was already spent.
+func (s *Service) RecordDepositRefund(ctx context.Context, userID int, amount money.Money, reference string) (int, error) {
+	return s.transferEntry(ctx, entities.JournalEntryDepositRefund, reference, "Card refund", amount,
+		leg{userID: userID},
+		leg{code: entities.AccountCodeCash},
+	)
+}
+
+// RecordOrderPayment moves amount from the wallet into funds held against the
+// order. It fails with entities.ErrInsufficientFunds if the wallet does not
+// cover it.
+func (s *Service) RecordOrderPayment(ctx context.Context, order *entities.Order, amount money.Money) error {
+	return s.transfer(ctx, entities.JournalEntryOrderPayment, orderReference(order.ID),
+		fmt.Sprintf("Payment for order #%d", order.ID), amount,
+		leg{userID: order.UserID},
+		leg{code: entities.AccountCodeDepositsHeld},
+	)
+}
+
+// RecordOrderRefund returns everything paid for the order to the wallet
+// through the refunds clearing account, so refunded volume stays visible on
+// that account.
+func (s *Service) RecordOrderRefund(ctx context.Context, order *entities.Order) error {
+	amount := order.PaidAmount
+	_, err := s.post(ctx, entities.JournalEntryOrderRefund, orderReference(order.ID),
+		fmt.Sprintf("Refund for order #%d", order.ID),
+		leg{code: entities.AccountCodeDepositsHeld, amount: amount},
+		leg{code: entities.AccountCodeRefunds, amount: amount.Neg()},
+		leg{code: entities.AccountCodeRefunds, amount: amount},
+		leg{userID: order.UserID, amount: amount.Neg()},
+	)
+	return err
+}
+
+// RecordOrderCompletion recognises the held funds as dealership revenue.
+func (s *Service) RecordOrderCompletion(ctx context.Context, order *entities.Order) error {
+	return s.transfer(ctx, entities.JournalEntryOrderCompletion, orderReference(order.ID),
+		fmt.Sprintf("Sale completed for order #%d", order.ID), order.TotalPrice,
+		leg{code: entities.AccountCodeDepositsHeld},
+		leg{code: entities.AccountCodeRevenue},
+	)
+}
+
+// GetStatement returns the wallet movements of the user in [from, to).
+func (s *Service) GetStatement(ctx context.Context, userID int, from, to time.Time) (*entities.Statement, error) {
+	if userID <= 0 {
+		return nil, entities.ErrInvalidID
+	}
+	if !from.Before(to) {
+		return nil, entities.ErrInvalidDateRange
+	}
+
+	statement := &entities.Statement{
+		UserID:         userID,
+		From:           from,
+		To:             to,
+		OpeningBalance: money.Zero(),
+		ClosingBalance: money.Zero(),
+		Lines:          []entities.StatementLine{},
+	}
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		wallet, err := s.repo.GetAccountByCode(ctx, entities.WalletAccountCode(userID))
+		if errors.Is(err, entities.ErrAccountNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		opening, err := s.repo.SumPostings(ctx, wallet.ID, from)
+		if err != nil {
+			return err
+		}
+		lines, err := s.repo.ListPostings(ctx, wallet.ID, from, to)
+		if err != nil {
+			return err
+		}
+
+		// Wallets are liabilities, so the customer's view flips the sign.
+		balance := opening.Neg()
+		statement.OpeningBalance = balance
+		statement.Lines = make([]entities.StatementLine, 0, len(lines))
+		for _, line := range lines {
+			line.Amount = line.Amount.Neg()
+			if balance, err = balance.Add(line.Amount); err != nil {
+				return err
+			}
+			line.Balance = balance
+			statement.Lines = append(statement.Lines, line)
+		}
+		statement.ClosingBalance = balance
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// Reconcile lists system accounts and wallets whose cached balances drifted
+// from their postings.
+func (s *Service) Reconcile(ctx context.Context) ([]entities.BalanceDrift, error) {
+	drift, err := s.repo.ListSystemDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wallets, err := s.repo.ListWalletDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(drift, wallets...), nil
+}
+
+// transfer debits the first leg and credits the second by amount.
+func (s *Service) transfer(ctx context.Context, entryType, reference, description string, amount money.Money, debit, credit leg) error {
+	_, err := s.transferEntry(ctx, entryType, reference, description, amount, debit, credit)
+	return err
+}
+
+func (s *Service) transferEntry(ctx context.Context, entryType, reference, description string, amount money.Money, debit, credit leg) (int, error) {
+	if !amount.IsPositive() {
+		return 0, fmt.Errorf("%w: amount must be positive", entities.ErrInvalidPosting)
+	}
+	debit.amount = amount
+	credit.amount = amount.Neg()
+	return s.post(ctx, entryType, reference, description, debit, credit)
+}
+
+// post writes a balanced journal entry and applies it to the cached balances
+// in one transaction. It returns the entry ID.
+func (s *Service) post(ctx context.Context, entryType, reference, description string, legs ...leg) (int, error) {
+	var sum money.Money
+	for _, l := range legs {
+		if l.amount.IsZero() {
+			return 0, fmt.Errorf("%w: zero amount", entities.ErrInvalidPosting)
+		}
+		var err error
+		if sum, err = sum.Add(l.amount); err != nil {
+			return 0, err
+		}
+	}
+	if len(legs) < 2 || !sum.IsZero() {
+		return 0, entities.ErrUnbalancedEntry
+	}
+
+	var entryID int
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		entry := &entities.JournalEntry{
+			Type:        entryType,
+			Reference:   reference,
+			Description: description,
+			CreatedAt:   time.Now(),
+		}
+		deltas := make(map[int]money.Money)
+		for _, l := range legs {
+			account, err := s.resolve(ctx, l)
+			if err != nil {
+				return err
+			}
+			entry.Postings = append(entry.Postings, entities.Posting{AccountID: account.ID, Amount: l.amount})
+			if deltas[account.ID], err = deltas[account.ID].Add(l.amount); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if entryID, err = s.repo.CreateEntry(ctx, entry); err != nil {
+			return err
+		}
+
+		// Update balances in ID order so concurrent postings lock rows consistently.
+		ids := make([]int, 0, len(deltas))
+		for id := range deltas {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			if deltas[id].IsZero() {
+				continue
+			}
+			account, err := s.repo.AddToBalance(ctx, id, deltas[id])
+			if err != nil {
+				return err
+			}
+			if account.UserID == nil {
+				continue
+			}
+			walletBalance := account.Balance.Neg()
+			if walletBalance.IsNegative() {
+				return entities.ErrInsufficientFunds
+			}
+			if err := s.repo.SyncUserBalance(ctx, *account.UserID, walletBalance); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return entryID, err
+}
+
+func (s *Service) resolve(ctx context.Context, l leg) (*entities.Account, error) {
+	if l.userID > 0 {
+		return s.repo.EnsureWallet(ctx, l.userID)
+	}
+	return s.repo.GetAccountByCode(ctx, l.code)
+}
+
+func orderReference(orderID int) string {
+	return fmt.Sprintf("order:%d", orderID)
+}
@@ -0,0 +1,58 @@
+package loginguardservice
+
+import (
+	"time"
+
+	"myproject/internal/entities"
+)
+
+// Policy throttles one scope. The first FreeAttempts failures in Window cost
+// nothing; after that every attempt has to wait BaseDelay after the latest
+// failure, doubling per failure up to MaxDelay. Reaching MaxFailures locks the
+// subject for Lockout, doubling per repeated lockout up to MaxLockout.
+// A zero MaxFailures never locks.
+type Policy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	MaxFailures  int
+	Window       time.Duration
+	Lockout      time.Duration
+	MaxLockout   time.Duration
+}
+
+func (p Policy) delay(failures int) time.Duration {
+	extra := failures - p.FreeAttempts
+	if extra <= 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+	return doubled(p.BaseDelay, extra-1, p.MaxDelay)
+}
+
+// exhausted reports whether the failures counted within the window reached
+// the limit.
+func (p Policy) exhausted(t *entities.LoginThrottle, now time.Time) bool {
+	if p.MaxFailures <= 0 || t.Failures < p.MaxFailures {
+		return false
+	}
+	return !t.FirstFailureAt.Before(now.Add(-p.Window))
+}
+
+func (p Policy) lockoutFor(previousLockouts int) time.Duration {
+	return doubled(p.Lockout, previousLockouts, p.MaxLockout)
+}
+
+// doubled returns base * 2^times, capped at limit when limit is set.
+func doubled(base time.Duration, times int, limit time.Duration) time.Duration {
+	d := base
+	for i := 0; i < times; i++ {
+		if limit > 0 && d >= limit {
+			break
+		}
+		d *= 2
+	}
+	if limit > 0 && d > limit {
+		return limit
+	}
+	return d
+}
@@ -0,0 +1,230 @@
+package loginguardservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/identity"
+	loginthrottlerepo "myproject/internal/repositories/loginthrottle"
+	"myproject/internal/repositories/txmanager"
+)
+
+// staleAfter is how long a throttle is kept after its last failure and lock,
+// so that repeated lockouts keep growing within a day.
+const staleAfter = 24 * time.Hour
+
+const maxEvents = 100
+
+// Service tracks failed sign-ins per account and per client IP. Accounts are
+// keyed by the normalized email whether or not it is registered, so throttled
+// responses do not reveal which addresses exist.
+type Service struct {
+	repo    loginthrottlerepo.Repository
+	tx      txmanager.Manager
+	account Policy
+	ip      Policy
+}
+
+func NewService(repo loginthrottlerepo.Repository, tx txmanager.Manager, account, ip Policy) *Service {
+	return &Service{repo: repo, tx: tx, account: account, ip: ip}
+}
+
+type subject struct {
+	scope  string
+	key    string
+	policy Policy
+}
+
+func (s *Service) subjects(email, ip string) []subject {
+	subjects := []subject{{entities.LockoutScopeAccount, normalizeEmail(email), s.account}}
+	if ip != "" {
+		subjects = append(subjects, subject{entities.LockoutScopeIP, ip, s.ip})
+	}
+	return subjects
+}
+
+// Check returns a *entities.LoginThrottledError when the account or the IP
+// has to wait before trying again.
+func (s *Service) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	var wait time.Duration
+	for _, sub := range s.subjects(email, ip) {
+		t, err := s.repo.Get(ctx, sub.scope, sub.key)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			continue
+		}
+		if w := waitFor(t, sub.policy, now); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		return &entities.LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+func waitFor(t *entities.LoginThrottle, p Policy, now time.Time) time.Duration {
+	if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+		return t.LockedUntil.Sub(now)
+	}
+	if t.FirstFailureAt.Before(now.Add(-p.Window)) {
+		return 0
+	}
+	next := t.LastFailureAt.Add(p.delay(t.Failures))
+	if now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+// RecordFailure counts a failed sign-in and locks the account or IP once it
+// reaches its policy's limit. userID is nil when the email is not registered.
+func (s *Service) RecordFailure(ctx context.Context, email, ip string, userID *int) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		for _, sub := range s.subjects(email, ip) {
+			t, err := s.repo.RecordFailure(ctx, sub.scope, sub.key, now, now.Add(-sub.policy.Window))
+			if err != nil {
+				return err
+			}
+			if !sub.policy.exhausted(t, now) {
+				continue
+			}
+			if err := s.lock(ctx, sub, t, ip, userID, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Reserve counts a sign-in attempt as failed before its password is checked,
+// so concurrent guesses cannot all pass the same check. It returns a
+// *entities.LoginThrottledError instead when the account or the IP has to
+// wait, locking it first when the failures counted so far reached the limit.
+// A correct password hands the attempt back with Release.
+func (s *Service) Reserve(ctx context.Context, email, ip string, userID *int) error {
+	var throttled error
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		subjects := s.subjects(email, ip)
+		var wait time.Duration
+		for _, sub := range subjects {
+			t, err := s.repo.GetForUpdate(ctx, sub.scope, sub.key)
+			if err != nil {
+				return err
+			}
+			if t == nil {
+				continue
+			}
+			w := waitFor(t, sub.policy, now)
+			if w == 0 && sub.policy.exhausted(t, now) {
+				if err := s.lock(ctx, sub, t, ip, userID, now); err != nil {
+					return err
+				}
+				w = sub.policy.lockoutFor(t.Lockouts)
+			}
+			if w > wait {
+				wait = w
+			}
+		}
+		if wait > 0 {
+			throttled = &entities.LoginThrottledError{RetryAfter: wait}
+			return nil
+		}
+
+		for _, sub := range subjects {
+			if _, err := s.repo.RecordFailure(ctx, sub.scope, sub.key, now, now.Add(-sub.policy.Window)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return throttled
+}
+
+// Release takes back an attempt counted by Reserve once the password turned
+// out to be right.
+func (s *Service) Release(ctx context.Context, email, ip string) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, sub := range s.subjects(email, ip) {
+			if err := s.repo.Uncount(ctx, sub.scope, sub.key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) lock(ctx context.Context, sub subject, t *entities.LoginThrottle, ip string, userID *int, now time.Time) error {
+	until := now.Add(sub.policy.lockoutFor(t.Lockouts))
+	if err := s.repo.Lock(ctx, sub.scope, sub.key, until); err != nil {
+		return err
+	}
+	event := &entities.LockoutEvent{
+		Event:       entities.LockoutEventLocked,
+		Scope:       sub.scope,
+		Subject:     sub.key,
+		IPAddress:   ip,
+		Failures:    t.Failures,
+		LockedUntil: &until,
+		CreatedAt:   now,
+	}
+	if sub.scope == entities.LockoutScopeAccount {
+		event.UserID = userID
+	}
+	return s.repo.AddEvent(ctx, event)
+}
+
+// RecordSuccess clears the account's failures. The IP keeps its count, so one
+// valid account does not reset guessing against others from the same client.
+func (s *Service) RecordSuccess(ctx context.Context, email string) error {
+	return s.repo.Delete(ctx, entities.LockoutScopeAccount, normalizeEmail(email))
+}
+
+// Unlock lifts the lockout and clears the failures of the user's account.
+func (s *Service) Unlock(ctx context.Context, userID int, email string) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		key := normalizeEmail(email)
+		if err := s.repo.Delete(ctx, entities.LockoutScopeAccount, key); err != nil {
+			return err
+		}
+		event := &entities.LockoutEvent{
+			Event:     entities.LockoutEventUnlocked,
+			Scope:     entities.LockoutScopeAccount,
+			Subject:   key,
+			UserID:    &userID,
+			CreatedAt: time.Now(),
+		}
+		if caller, ok := identity.FromContext(ctx); ok {
+			event.ActorID = &caller.UserID
+		}
+		return s.repo.AddEvent(ctx, event)
+	})
+}
+
+// ListEvents returns the latest lockout events of a user, newest first.
+func (s *Service) ListEvents(ctx context.Context, userID int) ([]entities.LockoutEvent, error) {
+	events, err := s.repo.ListEventsByUser(ctx, userID, maxEvents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lockout events: %w", err)
+	}
+	return events, nil
+}
+
+func (s *Service) PurgeStale(ctx context.Context) (int64, error) {
+	return s.repo.DeleteStale(ctx, time.Now().Add(-staleAfter))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
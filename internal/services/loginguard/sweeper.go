@@ -0,0 +1,53 @@
+package loginguardservice
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/pkg/health"
+	"myproject/pkg/logger"
+)
+
+type Sweeper struct {
+	service   *Service
+	interval  time.Duration
+	logger    logger.Interface
+	heartbeat *health.Heartbeat
+}
+
+func NewSweeper(service *Service, interval time.Duration, logger logger.Interface) *Sweeper {
+	return &Sweeper{
+		service:   service,
+		interval:  interval,
+		logger:    logger,
+		heartbeat: health.NewHeartbeat(3 * interval),
+	}
+}
+
+// Run deletes stale sign-in throttles every interval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.service.PurgeStale(ctx)
+			s.heartbeat.Beat(err)
+			if err != nil {
+				s.logger.Error("login throttle sweeper failed", "error", err)
+				continue
+			}
+			if purged > 0 {
+				s.logger.Info("stale login throttles purged", "count", purged)
+			}
+		}
+	}
+}
+
+// Check fails when the throttle sweeper has stalled.
+func (s *Sweeper) Check(ctx context.Context) error {
+	return s.heartbeat.Check(ctx)
+}
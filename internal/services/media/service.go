@@ -0,0 +1,457 @@
+package mediaservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/storage"
+	"myproject/internal/pkg/thumbnail"
+	"myproject/internal/pkg/tracing"
+	mediarepo "myproject/internal/repositories/media"
+	"myproject/internal/repositories/txmanager"
+)
+
+// extensions lists the accepted content types, as sniffed from the file
+// itself rather than taken from the client, with the extension stored blobs
+// get.
+var extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+}
+
+const maxFilenameLength = 255
+
+// uploadGrace is how long blobs stored by an upload may stay unreferenced
+// before the sweeper treats them as left behind by a crashed upload.
+const uploadGrace = time.Hour
+
+// sweepBatch bounds how many orphaned blobs one sweep deletes.
+const sweepBatch = 500
+
+type CarRepository interface {
+	GetByID(ctx context.Context, id int) (*entities.Car, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*entities.Car, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, action, entityType string, entityID int, before, after any) error
+}
+
+type Options struct {
+	MaxPhotoSize    int64
+	MaxDocumentSize int64
+	// MaxPixels bounds the decoded size of a photo.
+	MaxPixels int
+	MaxPerCar int
+	// ThumbnailSizes maps a size name, which becomes part of the blob key, to
+	// the longest side in pixels.
+	ThumbnailSizes map[string]int
+	// URLTTL is how long signed media URLs stay valid.
+	URLTTL time.Duration
+}
+
+type Service struct {
+	repo  mediarepo.Repository
+	cars  CarRepository
+	tx    txmanager.Manager
+	store storage.BlobStore
+	audit Auditor
+	opts  Options
+}
+
+func NewService(
+	repo mediarepo.Repository,
+	cars CarRepository,
+	tx txmanager.Manager,
+	store storage.BlobStore,
+	audit Auditor,
+	opts Options,
+) *Service {
+	return &Service{repo: repo, cars: cars, tx: tx, store: store, audit: audit, opts: opts}
+}
+
+// UploadMedia stores a photo or document for the car. Photos get a thumbnail
+// per configured size; the first photo of a car becomes its primary photo.
+func (s *Service) UploadMedia(ctx context.Context, carID int, upload entities.MediaUpload) (*entities.CarMedia, error) {
+	ctx, span := tracing.Start(ctx, "mediaservice.UploadMedia", tracing.Attr("car.id", carID))
+	defer span.End()
+
+	if carID <= 0 {
+		return nil, entities.ErrInvalidID
+	}
+	if _, err := s.cars.GetByID(ctx, carID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(upload.Body, max(s.opts.MaxPhotoSize, s.opts.MaxDocumentSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", entities.ErrMediaUnsupported)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", entities.ErrMediaUnsupported, contentType)
+	}
+
+	media := &entities.CarMedia{
+		CarID:       carID,
+		Kind:        entities.MediaDocument,
+		Filename:    cleanFilename(upload.Filename, ext),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+	limit := s.opts.MaxDocumentSize
+	if strings.HasPrefix(contentType, "image/") {
+		media.Kind, limit = entities.MediaPhoto, s.opts.MaxPhotoSize
+	}
+	if media.Size > limit {
+		return nil, fmt.Errorf("%w: %s files may be at most %d bytes", entities.ErrMediaTooLarge, media.Kind, limit)
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	base := fmt.Sprintf("cars/%d/%s", carID, name)
+	media.StorageKey = base + ext
+	blobs := map[string][]byte{media.StorageKey: data}
+	if media.Kind == entities.MediaPhoto {
+		thumbnails, err := s.thumbnails(media, data, base)
+		if err != nil {
+			return nil, err
+		}
+		for key, thumb := range thumbnails {
+			blobs[key] = thumb
+		}
+	}
+
+	keys := make([]string, 0, len(blobs))
+	for key := range blobs {
+		keys = append(keys, key)
+	}
+	if err := s.repo.QueueOrphans(ctx, keys, time.Now().Add(uploadGrace)); err != nil {
+		return nil, err
+	}
+	for key, blob := range blobs {
+		blobType := "image/jpeg"
+		if key == media.StorageKey {
+			blobType = contentType
+		}
+		if err := s.store.Put(ctx, key, bytes.NewReader(blob), int64(len(blob)), blobType); err != nil {
+			return nil, errors.Join(err, s.deleteBlobs(ctx, keys))
+		}
+	}
+
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.cars.GetByIDForUpdate(ctx, carID); err != nil {
+			return err
+		}
+		count, err := s.repo.CountByCar(ctx, carID)
+		if err != nil {
+			return err
+		}
+		if count >= s.opts.MaxPerCar {
+			return fmt.Errorf("%w: the limit is %d", entities.ErrMediaLimit, s.opts.MaxPerCar)
+		}
+		if media.ID, err = s.repo.Create(ctx, media); err != nil {
+			return err
+		}
+		if err := s.repo.DequeueOrphans(ctx, keys); err != nil {
+			return err
+		}
+		if upload.Primary && media.Kind == entities.MediaPhoto {
+			err = s.repo.SetPrimary(ctx, carID, media.ID)
+		} else {
+			err = s.repo.PromotePrimary(ctx, carID)
+		}
+		if err != nil {
+			return err
+		}
+		if media, err = s.repo.GetByID(ctx, carID, media.ID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditCarMediaAdd, entities.AuditEntityCar, carID, nil, media)
+	})
+	if err != nil {
+		return nil, errors.Join(err, s.deleteBlobs(ctx, keys))
+	}
+	if err := s.sign(ctx, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// thumbnails decodes the photo, records its dimensions and returns the
+// encoded thumbnails by blob key. Each size is scaled from the next larger
+// one, which is much cheaper than going back to the original every time.
+func (s *Service) thumbnails(media *entities.CarMedia, data []byte, base string) (map[string][]byte, error) {
+	img, err := thumbnail.Decode(data, s.opts.MaxPixels)
+	if errors.Is(err, thumbnail.ErrTooLarge) {
+		return nil, fmt.Errorf("%w: %v", entities.ErrMediaTooLarge, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrMediaUnsupported, err)
+	}
+	media.Width, media.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	names := make([]string, 0, len(s.opts.ThumbnailSizes))
+	for name := range s.opts.ThumbnailSizes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return s.opts.ThumbnailSizes[names[i]] > s.opts.ThumbnailSizes[names[j]]
+	})
+
+	media.ThumbnailKeys = make(map[string]string, len(names))
+	blobs := make(map[string][]byte, len(names))
+	var source image.Image = img
+	for _, name := range names {
+		resized := thumbnail.Resize(source, s.opts.ThumbnailSizes[name])
+		encoded, err := thumbnail.EncodeJPEG(resized)
+		if err != nil {
+			return nil, err
+		}
+		key := base + "_" + name + ".jpg"
+		media.ThumbnailKeys[name] = key
+		blobs[key] = encoded
+		source = resized
+	}
+	return blobs, nil
+}
+
+// ListMedia returns the car's media in display order with signed URLs.
+func (s *Service) ListMedia(ctx context.Context, carID int) ([]*entities.CarMedia, error) {
+	ctx, span := tracing.Start(ctx, "mediaservice.ListMedia", tracing.Attr("car.id", carID))
+	defer span.End()
+
+	if _, err := s.cars.GetByID(ctx, carID); err != nil {
+		return nil, err
+	}
+	return s.MediaForCar(ctx, carID)
+}
+
+// MediaForCar is ListMedia for callers that have already loaded the car.
+func (s *Service) MediaForCar(ctx context.Context, carID int) ([]*entities.CarMedia, error) {
+	media, err := s.repo.ListByCar(ctx, carID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range media {
+		if err := s.sign(ctx, m); err != nil {
+			return nil, err
+		}
+	}
+	return media, nil
+}
+
+func (s *Service) SetPrimaryMedia(ctx context.Context, carID, mediaID int) (*entities.CarMedia, error) {
+	ctx, span := tracing.Start(ctx, "mediaservice.SetPrimaryMedia", tracing.Attr("car.id", carID), tracing.Attr("media.id", mediaID))
+	defer span.End()
+
+	var media *entities.CarMedia
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.cars.GetByIDForUpdate(ctx, carID); err != nil {
+			return err
+		}
+		current, err := s.repo.GetByID(ctx, carID, mediaID)
+		if err != nil {
+			return err
+		}
+		if current.Kind != entities.MediaPhoto {
+			return entities.ErrMediaNotPhoto
+		}
+		if err := s.repo.SetPrimary(ctx, carID, mediaID); err != nil {
+			return err
+		}
+		media, err = s.repo.GetByID(ctx, carID, mediaID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sign(ctx, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// ReorderMedia puts the car's media in the order of ids, which must list each
+// of them exactly once.
+func (s *Service) ReorderMedia(ctx context.Context, carID int, ids []int) ([]*entities.CarMedia, error) {
+	ctx, span := tracing.Start(ctx, "mediaservice.ReorderMedia", tracing.Attr("car.id", carID))
+	defer span.End()
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.cars.GetByIDForUpdate(ctx, carID); err != nil {
+			return err
+		}
+		current, err := s.repo.ListByCar(ctx, carID)
+		if err != nil {
+			return err
+		}
+		if len(ids) != len(current) {
+			return entities.ErrInvalidMediaOrder
+		}
+		listed := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			listed[id] = true
+		}
+		for _, m := range current {
+			if !listed[m.ID] {
+				return entities.ErrInvalidMediaOrder
+			}
+		}
+		return s.repo.Reorder(ctx, carID, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.MediaForCar(ctx, carID)
+}
+
+// DeleteMedia removes the file and its thumbnails. Deleting the row queues
+// its blobs as orphans, and they are only deleted from storage after the
+// commit, so a rollback never leaves media without its files. Blobs that fail
+// to delete now are left to SweepOrphans.
+func (s *Service) DeleteMedia(ctx context.Context, carID, mediaID int) error {
+	ctx, span := tracing.Start(ctx, "mediaservice.DeleteMedia", tracing.Attr("car.id", carID), tracing.Attr("media.id", mediaID))
+	defer span.End()
+
+	var media *entities.CarMedia
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.cars.GetByIDForUpdate(ctx, carID); err != nil {
+			return err
+		}
+		var err error
+		if media, err = s.repo.GetByID(ctx, carID, mediaID); err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, carID, mediaID); err != nil {
+			return err
+		}
+		if err := s.repo.PromotePrimary(ctx, carID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditCarMediaDelete, entities.AuditEntityCar, carID, media, nil)
+	})
+	if err != nil {
+		return err
+	}
+	keys := []string{media.StorageKey}
+	for _, key := range media.ThumbnailKeys {
+		keys = append(keys, key)
+	}
+	_ = s.deleteBlobs(ctx, keys)
+	return nil
+}
+
+// SweepOrphans deletes queued blobs that are due: those of deleted media
+// and of uploads that never committed. It returns how many were deleted.
+func (s *Service) SweepOrphans(ctx context.Context) (int, error) {
+	keys, err := s.repo.ListOrphans(ctx, time.Now(), sweepBatch)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	deleted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted = append(deleted, key)
+	}
+	if len(deleted) > 0 {
+		if err := s.repo.DequeueOrphans(ctx, deleted); err != nil {
+			return 0, err
+		}
+	}
+	return len(deleted), errors.Join(errs...)
+}
+
+// sign fills in the URLs of media.
+func (s *Service) sign(ctx context.Context, media *entities.CarMedia) error {
+	expires := time.Now().Add(s.opts.URLTTL).UTC()
+	url, err := s.store.SignedURL(ctx, media.StorageKey, s.opts.URLTTL)
+	if err != nil {
+		return fmt.Errorf("failed to sign media url: %w", err)
+	}
+	media.URL, media.ExpiresAt = url, &expires
+	media.Thumbnails = make(map[string]string, len(media.ThumbnailKeys))
+	for name, key := range media.ThumbnailKeys {
+		if media.Thumbnails[name], err = s.store.SignedURL(ctx, key, s.opts.URLTTL); err != nil {
+			return fmt.Errorf("failed to sign thumbnail url: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteBlobs deletes the blobs from storage and dequeues the ones that are
+// gone; the rest stay queued for the sweeper.
+func (s *Service) deleteBlobs(ctx context.Context, keys []string) error {
+	var errs []error
+	deleted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted = append(deleted, key)
+	}
+	if len(deleted) > 0 {
+		if err := s.repo.DequeueOrphans(ctx, deleted); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cleanFilename keeps the base name the client sent, for display and
+// downloads, and makes sure it ends in the extension of the sniffed type.
+func cleanFilename(name, ext string) string {
+	name = strings.ToValidUTF8(name, "")
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	if !strings.EqualFold(filepath.Ext(name), ext) && !(ext == ".jpg" && strings.EqualFold(filepath.Ext(name), ".jpeg")) {
+		name += ext
+	}
+	if len(name) > maxFilenameLength {
+		name = strings.ToValidUTF8(name[:maxFilenameLength-len(ext)], "") + ext
+	}
+	return name
+}
+
+// randomName makes blob keys unguessable, so knowing one media URL does not
+// lead to others.
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate media name: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
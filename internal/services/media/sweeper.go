@@ -0,0 +1,53 @@
+package mediaservice
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/pkg/health"
+	"myproject/pkg/logger"
+)
+
+type Sweeper struct {
+	service   *Service
+	interval  time.Duration
+	logger    logger.Interface
+	heartbeat *health.Heartbeat
+}
+
+func NewSweeper(service *Service, interval time.Duration, logger logger.Interface) *Sweeper {
+	return &Sweeper{
+		service:   service,
+		interval:  interval,
+		logger:    logger,
+		heartbeat: health.NewHeartbeat(3 * interval),
+	}
+}
+
+// Run deletes orphaned media blobs every interval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.service.SweepOrphans(ctx)
+			s.heartbeat.Beat(err)
+			if err != nil {
+				s.logger.Error("media sweeper failed", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				s.logger.Info("orphaned media blobs deleted", "count", deleted)
+			}
+		}
+	}
+}
+
+// Check fails when orphaned media blobs have not been swept for three intervals.
+func (s *Sweeper) Check(ctx context.Context) error {
+	return s.heartbeat.Check(ctx)
+}
@@ -0,0 +1,480 @@
+package mfaservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/totp"
+	mfarepo "myproject/internal/repositories/mfa"
+	"myproject/internal/repositories/txmanager"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryAlphabet  = "abcdefghjkmnpqrstuvwxyz23456789"
+	// totpSkew accepts the previous and the next code as well, for clocks
+	// that drift a little.
+	totpSkew = 1
+)
+
+type Options struct {
+	Issuer        string
+	RequiredRoles []string
+	ChallengeTTL  time.Duration
+	MaxAttempts   int
+}
+
+type Users interface {
+	GetByID(ctx context.Context, id int) (*entities.User, error)
+}
+
+type Sessions interface {
+	Start(ctx context.Context, user *entities.User, meta entities.SessionMeta) (*entities.TokenPair, error)
+}
+
+// Guard is the sign-in throttle; wrong codes count as failed sign-ins.
+type Guard interface {
+	Check(ctx context.Context, email, ip string) error
+	RecordFailure(ctx context.Context, email, ip string, userID *int) error
+	RecordSuccess(ctx context.Context, email string) error
+}
+
+type Service struct {
+	repo     mfarepo.Repository
+	tx       txmanager.Manager
+	users    Users
+	sessions Sessions
+	guard    Guard
+	opts     Options
+}
+
+func NewService(
+	repo mfarepo.Repository,
+	tx txmanager.Manager,
+	users Users,
+	sessions Sessions,
+	guard Guard,
+	opts Options,
+) *Service {
+	return &Service{
+		repo:     repo,
+		tx:       tx,
+		users:    users,
+		sessions: sessions,
+		guard:    guard,
+		opts:     opts,
+	}
+}
+
+// IsRequired reports whether users with role must use two-factor
+// authentication.
+func (s *Service) IsRequired(role string) bool {
+	return slices.Contains(s.opts.RequiredRoles, role)
+}
+
+// Challenge is called once the password was accepted. It returns nil when the
+// user can have a session right away, otherwise the challenge to complete:
+// "verify" for users with an authenticator, "enroll" for users whose role
+// requires one they have not set up yet.
+func (s *Service) Challenge(ctx context.Context, user *entities.User, meta entities.SessionMeta) (*entities.MFAChallengeToken, error) {
+	factor, err := s.repo.GetFactor(ctx, user.ID)
+	if err != nil && !errors.Is(err, entities.ErrMFANotEnrolled) {
+		return nil, err
+	}
+
+	purpose := entities.MFAChallengeVerify
+	if factor == nil || factor.ConfirmedAt == nil {
+		if !s.IsRequired(user.Role) {
+			return nil, nil
+		}
+		purpose = entities.MFAChallengeEnroll
+	}
+
+	token, hash, err := newChallengeToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	challenge := &entities.MFAChallenge{
+		TokenHash: hash,
+		UserID:    user.ID,
+		Purpose:   purpose,
+		UserAgent: meta.UserAgent,
+		IPAddress: meta.IPAddress,
+		ExpiresAt: now.Add(s.opts.ChallengeTTL),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return &entities.MFAChallengeToken{Token: token, Purpose: purpose, ExpiresAt: challenge.ExpiresAt}, nil
+}
+
+// VerifyChallenge completes a "verify" challenge with a TOTP or recovery code
+// and opens the session.
+func (s *Service) VerifyChallenge(ctx context.Context, challengeToken, code string, meta entities.SessionMeta) (*entities.SignIn, error) {
+	var user *entities.User
+	err := s.withChallenge(ctx, challengeToken, entities.MFAChallengeVerify, meta, func(ctx context.Context, u *entities.User) error {
+		user = u
+		return s.checkCode(ctx, u.ID, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, user, meta)
+}
+
+// BeginChallengeEnrollment starts enrollment for a user whose role requires
+// two-factor authentication, while they are signing in.
+func (s *Service) BeginChallengeEnrollment(ctx context.Context, challengeToken string) (*entities.MFAEnrollment, error) {
+	var userID int
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		c, err := s.openChallenge(ctx, challengeToken, entities.MFAChallengeEnroll)
+		if err != nil {
+			return err
+		}
+		userID = c.UserID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.BeginEnrollment(ctx, userID)
+}
+
+// ConfirmChallengeEnrollment confirms the authenticator with its first code
+// and opens the session. The recovery codes are returned only this once.
+func (s *Service) ConfirmChallengeEnrollment(ctx context.Context, challengeToken, code string, meta entities.SessionMeta) (*entities.SignIn, error) {
+	var user *entities.User
+	var codes []string
+	err := s.withChallenge(ctx, challengeToken, entities.MFAChallengeEnroll, meta, func(ctx context.Context, u *entities.User) error {
+		user = u
+		var err error
+		codes, err = s.confirmFactor(ctx, u.ID, code)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	signIn, err := s.startSession(ctx, user, meta)
+	if err != nil {
+		return nil, err
+	}
+	signIn.RecoveryCodes = codes
+	return signIn, nil
+}
+
+// withChallenge runs check for the challenge's user and uses the challenge up
+// when it passes. A wrong code still counts against the challenge and the
+// sign-in throttle, so those updates are committed before the error returns.
+func (s *Service) withChallenge(
+	ctx context.Context,
+	challengeToken, purpose string,
+	meta entities.SessionMeta,
+	check func(ctx context.Context, user *entities.User) error,
+) error {
+	var user *entities.User
+	var codeErr error
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		c, err := s.openChallenge(ctx, challengeToken, purpose)
+		if err != nil {
+			return err
+		}
+		if user, err = s.users.GetByID(ctx, c.UserID); err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if err := s.guard.Check(ctx, user.Email, meta.IPAddress); err != nil {
+			return err
+		}
+
+		if err := check(ctx, user); err != nil {
+			if !errors.Is(err, entities.ErrInvalidMFACode) {
+				return err
+			}
+			codeErr = err
+			return s.repo.IncrementChallengeAttempts(ctx, c.TokenHash)
+		}
+		return s.repo.UseChallenge(ctx, c.TokenHash, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+	if codeErr != nil {
+		if err := s.guard.RecordFailure(ctx, user.Email, meta.IPAddress, &user.ID); err != nil {
+			return fmt.Errorf("failed to record sign-in failure: %w", err)
+		}
+		return codeErr
+	}
+	return nil
+}
+
+func (s *Service) openChallenge(ctx context.Context, challengeToken, purpose string) (*entities.MFAChallenge, error) {
+	c, err := s.repo.GetChallengeForUpdate(ctx, hashToken(challengeToken))
+	if err != nil {
+		return nil, err
+	}
+	if c.UsedAt != nil || !time.Now().Before(c.ExpiresAt) || c.Attempts >= s.opts.MaxAttempts {
+		return nil, entities.ErrInvalidMFAChallenge
+	}
+	if c.Purpose != purpose {
+		return nil, entities.ErrMFAChallengeMismatch
+	}
+	return c, nil
+}
+
+func (s *Service) startSession(ctx context.Context, user *entities.User, meta entities.SessionMeta) (*entities.SignIn, error) {
+	if err := s.guard.RecordSuccess(ctx, user.Email); err != nil {
+		return nil, fmt.Errorf("failed to reset sign-in failures: %w", err)
+	}
+	tokens, err := s.sessions.Start(ctx, user, meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	return &entities.SignIn{Tokens: tokens, User: user}, nil
+}
+
+// BeginEnrollment creates a new pending authenticator for the user, replacing
+// an unconfirmed one.
+func (s *Service) BeginEnrollment(ctx context.Context, userID int) (*entities.MFAEnrollment, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	factor := &entities.MFAFactor{UserID: userID, Secret: secret, CreatedAt: time.Now()}
+	if err := s.repo.SaveFactor(ctx, factor); err != nil {
+		return nil, err
+	}
+	return &entities.MFAEnrollment{Secret: secret, URI: totp.URI(s.opts.Issuer, user.Email, secret)}, nil
+}
+
+// ConfirmEnrollment enables the pending authenticator once the user proves it
+// works, and returns the recovery codes.
+func (s *Service) ConfirmEnrollment(ctx context.Context, userID int, code string) ([]string, error) {
+	var codes []string
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		codes, err = s.confirmFactor(ctx, userID, code)
+		return err
+	})
+	return codes, err
+}
+
+func (s *Service) confirmFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	factor, err := s.repo.GetFactorForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if factor.ConfirmedAt != nil {
+		return nil, entities.ErrMFAAlreadyEnrolled
+	}
+	now := time.Now()
+	step, ok := totp.Validate(factor.Secret, normalizeCode(code), now, totpSkew)
+	if !ok {
+		return nil, entities.ErrInvalidMFACode
+	}
+	if err := s.repo.ConfirmFactor(ctx, userID, step, now); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// Disable removes the user's authenticator and recovery codes. Roles that
+// require two-factor authentication cannot turn it off.
+func (s *Service) Disable(ctx context.Context, userID int, code string, meta entities.SessionMeta) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if s.IsRequired(user.Role) {
+		return entities.ErrMFARequired
+	}
+	return s.withCode(ctx, user, code, meta, func(ctx context.Context) error {
+		return s.repo.DeleteFactor(ctx, userID)
+	})
+}
+
+// Reset removes a user's authenticator without a code, for an admin helping
+// someone who lost both their device and recovery codes. Users whose role
+// requires two-factor authentication enroll again at their next sign-in.
+func (s *Service) Reset(ctx context.Context, userID int) error {
+	if _, err := s.users.GetByID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.repo.DeleteFactor(ctx, userID)
+	})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a
+// current code.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int, code string, meta entities.SessionMeta) ([]string, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	var codes []string
+	err = s.withCode(ctx, user, code, meta, func(ctx context.Context) error {
+		var err error
+		codes, err = s.replaceRecoveryCodes(ctx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// withCode runs fn in a transaction after checking a current code. Wrong
+// codes count against the same sign-in throttle as the challenge step, so a
+// stolen session cannot be used to guess the second factor.
+func (s *Service) withCode(
+	ctx context.Context,
+	user *entities.User,
+	code string,
+	meta entities.SessionMeta,
+	fn func(ctx context.Context) error,
+) error {
+	if err := s.guard.Check(ctx, user.Email, meta.IPAddress); err != nil {
+		return err
+	}
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.checkCode(ctx, user.ID, code); err != nil {
+			return err
+		}
+		return fn(ctx)
+	})
+	if errors.Is(err, entities.ErrInvalidMFACode) {
+		if err := s.guard.RecordFailure(ctx, user.Email, meta.IPAddress, &user.ID); err != nil {
+			return fmt.Errorf("failed to record sign-in failure: %w", err)
+		}
+	}
+	return err
+}
+
+func (s *Service) Status(ctx context.Context, userID int) (*entities.MFAStatus, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	status := &entities.MFAStatus{Required: s.IsRequired(user.Role)}
+
+	factor, err := s.repo.GetFactor(ctx, userID)
+	if errors.Is(err, entities.ErrMFANotEnrolled) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.ConfirmedAt == nil {
+		return status, nil
+	}
+	status.Enabled = true
+	status.ConfirmedAt = factor.ConfirmedAt
+	if status.RecoveryCodesRemaining, err = s.repo.CountRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// checkCode accepts a TOTP code that was not used before or an unused
+// recovery code. It must run inside a transaction.
+func (s *Service) checkCode(ctx context.Context, userID int, code string) error {
+	factor, err := s.repo.GetFactorForUpdate(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if factor.ConfirmedAt == nil {
+		return entities.ErrMFANotEnrolled
+	}
+
+	code = normalizeCode(code)
+	now := time.Now()
+	if len(code) == totp.Digits && isDigits(code) {
+		step, ok := totp.Validate(factor.Secret, code, now, totpSkew)
+		if !ok || step <= factor.LastUsedStep {
+			return entities.ErrInvalidMFACode
+		}
+		return s.repo.SetLastUsedStep(ctx, userID, step)
+	}
+	return s.repo.UseRecoveryCode(ctx, userID, hashToken(code), now)
+}
+
+func (s *Service) replaceRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashToken(normalizeCode(code))
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes, time.Now()); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpiredChallenges(ctx, time.Now())
+}
+
+// newRecoveryCode returns a code like "k7m2p-x9qrt". Ambiguous characters
+// are left out of the alphabet.
+func newRecoveryCode() (string, error) {
+	// Bytes at or above limit are skipped so every character is equally
+	// likely.
+	limit := 256 - 256%len(recoveryAlphabet)
+	code := make([]byte, 0, 10)
+	buf := make([]byte, 16)
+	for len(code) < cap(code) {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(code) < cap(code) {
+				code = append(code, recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+			}
+		}
+	}
+	return string(code[:5]) + "-" + string(code[5:]), nil
+}
+
+func newChallengeToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCode drops spaces and dashes users type or paste along with codes.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
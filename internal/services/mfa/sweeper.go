@@ -0,0 +1,54 @@
+package mfaservice
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/pkg/health"
+	"myproject/pkg/logger"
+)
+
+type Sweeper struct {
+	service   *Service
+	interval  time.Duration
+	logger    logger.Interface
+	heartbeat *health.Heartbeat
+}
+
+func NewSweeper(service *Service, interval time.Duration, logger logger.Interface) *Sweeper {
+	return &Sweeper{
+		service:   service,
+		interval:  interval,
+		logger:    logger,
+		heartbeat: health.NewHeartbeat(3 * interval),
+	}
+}
+
+// Run deletes expired sign-in challenges every interval until ctx is
+// cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.service.PurgeExpired(ctx)
+			s.heartbeat.Beat(err)
+			if err != nil {
+				s.logger.Error("mfa challenge sweeper failed", "error", err)
+				continue
+			}
+			if purged > 0 {
+				s.logger.Info("expired mfa challenges purged", "count", purged)
+			}
+		}
+	}
+}
+
+// Check fails when challenges have not been swept for three intervals.
+func (s *Sweeper) Check(ctx context.Context) error {
+	return s.heartbeat.Check(ctx)
+}
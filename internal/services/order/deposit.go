@@ -0,0 +1,79 @@
+package orderservice
+
+import (
+	"fmt"
+	"sort"
+
+	"myproject/internal/pkg/money"
+)
+
+// DepositBand requires at least MinPercent of the price as the deposit for
+// cars priced up to and including MaxPrice. A zero MaxPrice has no upper bound.
+type DepositBand struct {
+	MaxPrice   money.Money
+	MinPercent int64
+}
+
+// DepositPolicy picks the minimum deposit from the first band that covers the
+// car price. Prices above every band need no minimum deposit.
+type DepositPolicy struct {
+	bands []DepositBand
+}
+
+func NewDepositPolicy(bands []DepositBand) (*DepositPolicy, error) {
+	sorted := make([]DepositBand, 0, len(bands))
+	unbounded := 0
+	for _, band := range bands {
+		if band.MinPercent < 0 || band.MinPercent > 100 {
+			return nil, fmt.Errorf("deposit percentage must be between 0 and 100, got %d", band.MinPercent)
+		}
+		if band.MaxPrice.IsNegative() {
+			return nil, fmt.Errorf("deposit band price must not be negative: %s", band.MaxPrice)
+		}
+		if band.MaxPrice.IsZero() {
+			unbounded++
+		}
+		sorted = append(sorted, band)
+	}
+	if unbounded > 1 {
+		return nil, fmt.Errorf("only one deposit band may be unbounded")
+	}
+
+	var cmpErr error
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].MaxPrice, sorted[j].MaxPrice
+		if a.IsZero() || b.IsZero() {
+			return b.IsZero() && !a.IsZero()
+		}
+		cmp, err := a.Cmp(b)
+		if err != nil {
+			cmpErr = err
+		}
+		return cmp < 0
+	})
+	if cmpErr != nil {
+		return nil, cmpErr
+	}
+	return &DepositPolicy{bands: sorted}, nil
+}
+
+// MinimumDeposit returns the smallest deposit accepted for price, rounded up
+// to the minor unit.
+func (p *DepositPolicy) MinimumDeposit(price money.Money) (money.Money, error) {
+	if p == nil {
+		return money.Money{Currency: price.Currency}, nil
+	}
+	for _, band := range p.bands {
+		if !band.MaxPrice.IsZero() {
+			cmp, err := price.Cmp(band.MaxPrice)
+			if err != nil {
+				return money.Money{}, err
+			}
+			if cmp > 0 {
+				continue
+			}
+		}
+		return price.MulRat(band.MinPercent, 100, money.RoundUp)
+	}
+	return money.Money{Currency: price.Currency}, nil
+}
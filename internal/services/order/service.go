@@ -7,78 +7,134 @@ import (
 	"time"
 
 	"myproject/internal/entities"
+	"myproject/internal/pkg/identity"
+	"myproject/internal/pkg/money"
+	"myproject/internal/pkg/tracing"
 	orderrepo "myproject/internal/repositories/order"
-)
-
-var (
-	ErrOrderNotFound      = errors.New("order not found")
-	ErrInvalidOrderData   = errors.New("invalid order data")
-	ErrOrderAlreadyClosed = errors.New("order is already completed or cancelled")
-	ErrInvalidStatus      = errors.New("invalid order status")
+	"myproject/internal/repositories/txmanager"
 )
 
 type Service struct {
-	repo           orderrepo.Repository
-	carService     CarService
-	userService    UserService
-	paymentService PaymentService
+	repo       orderrepo.Repository
+	tx         txmanager.Manager
+	carService CarService
+	ledger     Ledger
+	users      Users
+	deposits   *DepositPolicy
+	audit      Auditor
 }
 
 type CarService interface {
+	LockCar(ctx context.Context, carID int) (*entities.Car, error)
 	CheckAvailability(ctx context.Context, carID int, startDate, endDate time.Time) (bool, error)
 	UpdateStatus(ctx context.Context, carID int, status string) error
 }
 
-type UserService interface {
-	CheckBalance(ctx context.Context, userID int, amount float64) (bool, error)
-	DeductBalance(ctx context.Context, userID int, amount float64) error
+type Users interface {
+	GetByID(ctx context.Context, id int) (*entities.User, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, action, entityType string, entityID int, before, after any) error
 }
 
-type PaymentService interface {
-	CreateTransaction(ctx context.Context, tx *entities.Transaction) error // Change the signature to take *entities.Transaction
+type Ledger interface {
+	RecordOrderPayment(ctx context.Context, order *entities.Order, amount money.Money) error
+	RecordOrderRefund(ctx context.Context, order *entities.Order) error
+	RecordOrderCompletion(ctx context.Context, order *entities.Order) error
 }
 
 func NewService(
 	repo orderrepo.Repository,
+	tx txmanager.Manager,
 	carService CarService,
-	userService UserService,
-	paymentService PaymentService,
+	ledger Ledger,
+	users Users,
+	deposits *DepositPolicy,
+	audit Auditor,
 ) *Service {
 	return &Service{
-		repo:           repo,
-		carService:     carService,
-		userService:    userService,
-		paymentService: paymentService,
+		repo:       repo,
+		tx:         tx,
+		carService: carService,
+		ledger:     ledger,
+		users:      users,
+		deposits:   deposits,
+		audit:      audit,
 	}
 }
 
+// CreateOrder places an order for the car at its current price. The deposit is
+// taken from the wallet right away; an order whose deposit covers the price is
+// paid immediately.
 func (s *Service) CreateOrder(ctx context.Context, order *entities.Order) (int, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.CreateOrder")
+	defer span.End()
+
 	if err := validateOrder(order); err != nil {
-		return 0, fmt.Errorf("%w: %v", ErrInvalidOrderData, err)
+		return 0, fmt.Errorf("%w: %v", entities.ErrInvalidOrderData, err)
 	}
 
-	available, err := s.carService.CheckAvailability(
-		ctx,
-		order.CarID,
-		order.CreatedAt,
-		order.UpdatedAt,
-	)
-	if err != nil {
-		return 0, fmt.Errorf("failed to check car availability: %w", err)
-	}
-	if !available {
-		return 0, errors.New("car is not available for selected dates")
+	var id int
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		car, err := s.carService.LockCar(ctx, order.CarID)
+		if err != nil {
+			return fmt.Errorf("failed to lock car: %w", err)
+		}
+		order.TotalPrice = car.Price
+
+		now := time.Now()
+		available, err := s.carService.CheckAvailability(ctx, order.CarID, now, now)
+		if err != nil {
+			return fmt.Errorf("failed to check car availability: %w", err)
+		}
+		if !available {
+			return entities.ErrCarNotAvailable
+		}
+
+		id, err = s.placeOrder(ctx, order, "order placed")
+		return err
+	})
+	return id, err
+}
+
+// CreateReservedOrder places an order for a car that is already held by a
+// reservation of the same user, so the availability check is skipped.
+func (s *Service) CreateReservedOrder(ctx context.Context, order *entities.Order) (int, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.CreateReservedOrder")
+	defer span.End()
+
+	if err := validateOrder(order); err != nil {
+		return 0, fmt.Errorf("%w: %v", entities.ErrInvalidOrderData, err)
 	}
 
-	hasBalance, err := s.userService.CheckBalance(ctx, order.UserID, order.TotalPrice)
-	if err != nil {
-		return 0, fmt.Errorf("failed to check user balance: %w", err)
+	var id int
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.carService.LockCar(ctx, order.CarID); err != nil {
+			return fmt.Errorf("failed to lock car: %w", err)
+		}
+
+		var err error
+		id, err = s.placeOrder(ctx, order, "converted from reservation")
+		return err
+	})
+	return id, err
+}
+
+// placeOrder must run inside a transaction that already holds the car row lock.
+func (s *Service) placeOrder(ctx context.Context, order *entities.Order, reason string) (int, error) {
+	if !order.TotalPrice.IsPositive() {
+		return 0, fmt.Errorf("%w: total price must be positive", entities.ErrInvalidOrderData)
+	}
+	if err := s.checkEmailVerified(ctx, order.UserID); err != nil {
+		return 0, err
 	}
-	if !hasBalance {
-		return 0, errors.New("insufficient funds")
+	if err := s.checkDeposit(order); err != nil {
+		return 0, err
 	}
 
 	order.Status = entities.OrderStatusPending
+	order.PaidAmount = order.Deposit
 	order.CreatedAt = time.Now()
 
 	id, err := s.repo.Create(ctx, order)
@@ -86,30 +142,131 @@ func (s *Service) CreateOrder(ctx context.Context, order *entities.Order) (int,
 		return 0, fmt.Errorf("failed to create order: %w", err)
 	}
 
-	if err := s.userService.DeductBalance(ctx, order.UserID, order.TotalPrice); err != nil {
-		return 0, fmt.Errorf("failed to deduct balance: %w", err)
+	order.ID = id
+
+	if err := s.recordStatusChange(ctx, id, "", order.Status, reason); err != nil {
+		return 0, err
+	}
+
+	if order.Deposit.IsPositive() {
+		if err := s.ledger.RecordOrderPayment(ctx, order, order.Deposit); err != nil {
+			return 0, fmt.Errorf("failed to record order payment: %w", err)
+		}
 	}
 
-	if err := s.carService.UpdateStatus(ctx, order.CarID, "reserved"); err != nil {
+	if err := s.carService.UpdateStatus(ctx, order.CarID, string(entities.CarStatusReserved)); err != nil {
 		return 0, fmt.Errorf("failed to update car status: %w", err)
 	}
 
-	transaction := &entities.Transaction{
-		UserID:      order.UserID,
-		Amount:      order.TotalPrice,
-		Type:        "order_payment",
-		Description: fmt.Sprintf("Payment for order #%d", id),
-		CreatedAt:   time.Now(),
+	if err := s.audit.Record(ctx, entities.AuditOrderCreate, entities.AuditEntityOrder, id, nil, order); err != nil {
+		return 0, err
+	}
+	if err := s.markPaidIfSettled(ctx, order); err != nil {
+		return 0, err
 	}
+	return id, nil
+}
 
-	if err := s.paymentService.CreateTransaction(ctx, transaction); err != nil {
-		return 0, fmt.Errorf("failed to create payment transaction: %w", err)
+// checkEmailVerified only lets customers with a confirmed email address order.
+func (s *Service) checkEmailVerified(ctx context.Context, userID int) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		return entities.ErrEmailNotVerified
 	}
+	return nil
+}
 
-	return id, nil
+// checkDeposit enforces the minimum deposit for the order's price band.
+func (s *Service) checkDeposit(order *entities.Order) error {
+	minimum, err := s.deposits.MinimumDeposit(order.TotalPrice)
+	if err != nil {
+		return err
+	}
+	if cmp, err := order.Deposit.Cmp(minimum); err != nil {
+		return err
+	} else if cmp < 0 {
+		return fmt.Errorf("%w: at least %s is required", entities.ErrDepositTooLow, minimum)
+	}
+	if cmp, err := order.Deposit.Cmp(order.TotalPrice); err != nil {
+		return err
+	} else if cmp > 0 {
+		return fmt.Errorf("%w: deposit %s is above the price %s", entities.ErrOverpayment, order.Deposit, order.TotalPrice)
+	}
+	return nil
+}
+
+// AddPayment takes amount from the customer's wallet towards the outstanding
+// balance of the order and moves the order to paid once nothing is due.
+func (s *Service) AddPayment(ctx context.Context, id int, amount money.Money) (*entities.Order, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.AddPayment", tracing.Attr("order.id", id))
+	defer span.End()
+
+	if id <= 0 {
+		return nil, entities.ErrInvalidOrderData
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: payment amount must be positive", entities.ErrInvalidOrderData)
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		order, err := s.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if order.Status == entities.OrderStatusCompleted || order.Status == entities.OrderStatusCancelled {
+			return entities.ErrOrderAlreadyClosed
+		}
+
+		if cmp, err := amount.Cmp(order.Outstanding); err != nil {
+			return err
+		} else if cmp > 0 {
+			return fmt.Errorf("%w: %s is due", entities.ErrOverpayment, order.Outstanding)
+		}
+
+		if err := s.ledger.RecordOrderPayment(ctx, order, amount); err != nil {
+			return fmt.Errorf("failed to record order payment: %w", err)
+		}
+		if err := s.repo.AddPaidAmount(ctx, id, amount); err != nil {
+			return fmt.Errorf("failed to update paid amount: %w", err)
+		}
+		paid := order.PaidAmount
+		if order.PaidAmount, err = order.PaidAmount.Add(amount); err != nil {
+			return err
+		}
+		err = s.audit.Record(ctx, entities.AuditOrderPayment, entities.AuditEntityOrder, id,
+			map[string]any{"paid_amount": paid},
+			map[string]any{"paid_amount": order.PaidAmount, "amount": amount})
+		if err != nil {
+			return err
+		}
+		return s.markPaidIfSettled(ctx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrder(ctx, id)
+}
+
+// markPaidIfSettled moves a pending or confirmed order to paid when its paid
+// amount covers the price.
+func (s *Service) markPaidIfSettled(ctx context.Context, order *entities.Order) error {
+	if order.Status != entities.OrderStatusPending && order.Status != entities.OrderStatusConfirmed {
+		return nil
+	}
+	cmp, err := order.PaidAmount.Cmp(order.TotalPrice)
+	if err != nil || cmp < 0 {
+		return err
+	}
+	return s.transition(ctx, order, entities.OrderStatusPaid, "paid in full")
 }
 
 func (s *Service) GetOrder(ctx context.Context, id int) (*entities.Order, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetOrder", tracing.Attr("order.id", id))
+	defer span.End()
+
 	if id <= 0 {
 		return nil, entities.ErrInvalidOrderData
 	}
@@ -126,8 +283,11 @@ func (s *Service) GetOrder(ctx context.Context, id int) (*entities.Order, error)
 }
 
 func (s *Service) GetOrdersByUserID(ctx context.Context, userID int) ([]entities.Order, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetOrdersByUserID", tracing.Attr("user.id", userID))
+	defer span.End()
+
 	if userID <= 0 {
-		return nil, ErrInvalidOrderData
+		return nil, entities.ErrInvalidOrderData
 	}
 
 	orders, err := s.repo.GetByUserID(ctx, userID)
@@ -138,74 +298,110 @@ func (s *Service) GetOrdersByUserID(ctx context.Context, userID int) ([]entities
 	return orders, nil
 }
 
-func (s *Service) UpdateOrderStatus(ctx context.Context, id int, status string) error {
+// UpdateOrderStatus moves the order to status if the state machine allows it,
+// running the transition's guards and side effects and recording it in the
+// status history, all in one transaction.
+func (s *Service) UpdateOrderStatus(ctx context.Context, id int, status, reason string) error {
+	ctx, span := tracing.Start(ctx, "orderservice.UpdateOrderStatus", tracing.Attr("order.id", id), tracing.Attr("order.status", status))
+	defer span.End()
+
 	if id <= 0 {
-		return ErrInvalidOrderData
+		return entities.ErrInvalidOrderData
 	}
-
 	if !isValidStatus(status) {
-		return ErrInvalidStatus
-	}
-
-	currentOrder, err := s.repo.GetByID(ctx, id)
-	if err != nil {
-		return fmt.Errorf("failed to get order: %w", err)
-	}
-
-	if currentOrder.Status == entities.OrderStatusCompleted ||
-		currentOrder.Status == entities.OrderStatusCancelled {
-		return ErrOrderAlreadyClosed
-	}
-
-	if err := s.repo.UpdateStatus(ctx, id, status); err != nil {
-		return fmt.Errorf("failed to update order status: %w", err)
+		return entities.ErrInvalidStatus
 	}
 
-	if status == entities.OrderStatusCompleted || status == entities.OrderStatusCancelled {
-		if err := s.carService.UpdateStatus(ctx, currentOrder.CarID, "available"); err != nil {
-			return fmt.Errorf("failed to update car status: %w", err)
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		order, err := s.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
 		}
-	}
+		return s.transition(ctx, order, status, reason)
+	})
+}
 
-	return nil
+// CancelOrder cancels the order, releases the car and refunds the customer
+// in a single transaction.
+func (s *Service) CancelOrder(ctx context.Context, id int, reason string) error {
+	return s.UpdateOrderStatus(ctx, id, entities.OrderStatusCancelled, reason)
 }
 
-func (s *Service) CancelOrder(ctx context.Context, id int) error {
+func (s *Service) GetOrderHistory(ctx context.Context, id int) ([]entities.OrderStatusChange, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.GetOrderHistory", tracing.Attr("order.id", id))
+	defer span.End()
+
 	if id <= 0 {
-		return ErrInvalidOrderData
+		return nil, entities.ErrInvalidOrderData
 	}
-
-	order, err := s.repo.GetByID(ctx, id)
+	history, err := s.repo.ListStatusHistory(ctx, id)
 	if err != nil {
-		return fmt.Errorf("failed to get order: %w", err)
+		return nil, fmt.Errorf("failed to get order history: %w", err)
 	}
+	return history, nil
+}
 
-	if order.Status == entities.OrderStatusCompleted ||
-		order.Status == entities.OrderStatusCancelled {
-		return ErrOrderAlreadyClosed
+// transition must run inside a transaction that holds the order row lock.
+func (s *Service) transition(ctx context.Context, order *entities.Order, to, reason string) error {
+	t, err := lookupTransition(order.Status, to)
+	if err != nil {
+		return err
 	}
-
-	if err := s.repo.UpdateStatus(ctx, id, entities.OrderStatusCancelled); err != nil {
-		return fmt.Errorf("failed to cancel order: %w", err)
+	for _, check := range t.guards {
+		if err := check(ctx, s, order); err != nil {
+			return err
+		}
 	}
 
-	if err := s.carService.UpdateStatus(ctx, order.CarID, "available"); err != nil {
-		return fmt.Errorf("failed to update car status: %w", err)
+	from := order.Status
+	if err := s.repo.UpdateStatus(ctx, order.ID, to); err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+	order.Status = to
+	if err := s.recordStatusChange(ctx, order.ID, from, to, reason); err != nil {
+		return err
+	}
+	err = s.audit.Record(ctx, entities.AuditOrderStatusChange, entities.AuditEntityOrder, order.ID,
+		map[string]any{"status": from},
+		map[string]any{"status": to, "reason": reason})
+	if err != nil {
+		return err
 	}
 
-	if err := s.userService.DeductBalance(ctx, order.UserID, -order.TotalPrice); err != nil {
-		return fmt.Errorf("failed to refund user balance: %w", err)
+	for _, apply := range t.effects {
+		if err := apply(ctx, s, order); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+func (s *Service) recordStatusChange(ctx context.Context, orderID int, from, to, reason string) error {
+	change := &entities.OrderStatusChange{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}
+	if caller, ok := identity.FromContext(ctx); ok {
+		change.ActorID = &caller.UserID
+	}
+	if err := s.repo.AddStatusChange(ctx, change); err != nil {
+		return fmt.Errorf("failed to record order status change: %w", err)
+	}
 	return nil
 }
 
-func (s *Service) ListAllOrders(ctx context.Context) ([]entities.Order, error) {
-	orders, err := s.repo.ListAll(ctx)
+func (s *Service) ListAllOrders(ctx context.Context, page entities.PageRequest) ([]entities.Order, entities.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "orderservice.ListAllOrders")
+	defer span.End()
+
+	orders, info, err := s.repo.ListAll(ctx, page)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list orders: %w", err)
+		return nil, entities.PageInfo{}, fmt.Errorf("failed to list orders: %w", err)
 	}
-	return orders, nil
+	return orders, info, nil
 }
 
 func validateOrder(o *entities.Order) error {
@@ -218,20 +414,8 @@ func validateOrder(o *entities.Order) error {
 	if o.CarID <= 0 {
 		return errors.New("invalid car ID")
 	}
-	if o.TotalPrice <= 0 {
-		return errors.New("total price must be positive")
+	if o.Deposit.IsNegative() {
+		return errors.New("deposit must not be negative")
 	}
 	return nil
 }
-
-func isValidStatus(status string) bool {
-	switch status {
-	case entities.OrderStatusPending,
-		entities.OrderStatusConfirmed,
-		entities.OrderStatusCompleted,
-		entities.OrderStatusCancelled:
-		return true
-	default:
-		return false
-	}
-}
@@ -0,0 +1,117 @@
+package orderservice
+
+import (
+	"context"
+	"fmt"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
+)
+
+// guard rejects a transition by returning an error; effect runs after the new
+// status is stored. Both run inside the transition's transaction with the
+// order row locked.
+type (
+	guard  func(ctx context.Context, s *Service, order *entities.Order) error
+	effect func(ctx context.Context, s *Service, order *entities.Order) error
+)
+
+type transition struct {
+	guards  []guard
+	effects []effect
+}
+
+// transitions lists every allowed status change. Completed and cancelled are
+// terminal. An order becomes paid once its paid amount covers the price; after
+// that it is either picked up at the showroom or shipped and delivered.
+var transitions = map[string]map[string]transition{
+	entities.OrderStatusPending: {
+		entities.OrderStatusConfirmed: {},
+		entities.OrderStatusPaid:      {guards: []guard{fullyPaid}},
+		entities.OrderStatusCancelled: {effects: []effect{releaseCar, refundOrder}},
+	},
+	entities.OrderStatusConfirmed: {
+		entities.OrderStatusPaid:      {guards: []guard{fullyPaid}},
+		entities.OrderStatusCancelled: {effects: []effect{releaseCar, refundOrder}},
+	},
+	entities.OrderStatusPaid: {
+		entities.OrderStatusShipped:   {},
+		entities.OrderStatusCompleted: {guards: []guard{fullyPaid}, effects: []effect{recognizeRevenue, markCarSold}},
+		entities.OrderStatusCancelled: {effects: []effect{releaseCar, refundOrder}},
+	},
+	entities.OrderStatusShipped: {
+		entities.OrderStatusDelivered: {},
+	},
+	entities.OrderStatusDelivered: {
+		entities.OrderStatusCompleted: {guards: []guard{fullyPaid}, effects: []effect{recognizeRevenue, markCarSold}},
+	},
+}
+
+func isValidStatus(status string) bool {
+	switch status {
+	case entities.OrderStatusCompleted, entities.OrderStatusCancelled:
+		return true
+	}
+	_, ok := transitions[status]
+	return ok
+}
+
+func lookupTransition(from, to string) (transition, error) {
+	t, ok := transitions[from][to]
+	if !ok {
+		if from == entities.OrderStatusCompleted || from == entities.OrderStatusCancelled {
+			return transition{}, entities.ErrOrderAlreadyClosed
+		}
+		return transition{}, fmt.Errorf("%w: %s -> %s", entities.ErrInvalidTransition, from, to)
+	}
+	return t, nil
+}
+
+func fullyPaid(ctx context.Context, s *Service, order *entities.Order) error {
+	cmp, err := order.PaidAmount.Cmp(order.TotalPrice)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return fmt.Errorf("%w: %s paid of %s", entities.ErrOrderNotFullyPaid, order.PaidAmount, order.TotalPrice)
+	}
+	return nil
+}
+
+func releaseCar(ctx context.Context, s *Service, order *entities.Order) error {
+	if _, err := s.carService.LockCar(ctx, order.CarID); err != nil {
+		return fmt.Errorf("failed to lock car: %w", err)
+	}
+	if err := s.carService.UpdateStatus(ctx, order.CarID, string(entities.CarStatusAvailable)); err != nil {
+		return fmt.Errorf("failed to update car status: %w", err)
+	}
+	return nil
+}
+
+func refundOrder(ctx context.Context, s *Service, order *entities.Order) error {
+	if !order.PaidAmount.IsPositive() {
+		return nil
+	}
+	if err := s.ledger.RecordOrderRefund(ctx, order); err != nil {
+		return fmt.Errorf("failed to record order refund: %w", err)
+	}
+	if err := s.repo.AddPaidAmount(ctx, order.ID, order.PaidAmount.Neg()); err != nil {
+		return fmt.Errorf("failed to reset paid amount: %w", err)
+	}
+	order.PaidAmount = money.Money{Currency: order.PaidAmount.Currency}
+	return nil
+}
+
+func recognizeRevenue(ctx context.Context, s *Service, order *entities.Order) error {
+	if err := s.ledger.RecordOrderCompletion(ctx, order); err != nil {
+		return fmt.Errorf("failed to record order completion: %w", err)
+	}
+	return nil
+}
+
+func markCarSold(ctx context.Context, s *Service, order *entities.Order) error {
+	if err := s.carService.UpdateStatus(ctx, order.CarID, string(entities.CarStatusSold)); err != nil {
+		return fmt.Errorf("failed to update car status: %w", err)
+	}
+	return nil
+}
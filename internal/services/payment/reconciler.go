@@ -0,0 +1,54 @@
+package paymentservice
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/pkg/health"
+	"myproject/pkg/logger"
+)
+
+type Reconciler struct {
+	service   *Service
+	interval  time.Duration
+	logger    logger.Interface
+	heartbeat *health.Heartbeat
+}
+
+func NewReconciler(service *Service, interval time.Duration, logger logger.Interface) *Reconciler {
+	return &Reconciler{
+		service:   service,
+		interval:  interval,
+		logger:    logger,
+		heartbeat: health.NewHeartbeat(3 * interval),
+	}
+}
+
+// Run settles payments left pending by provider timeouts every interval until
+// ctx is cancelled.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			settled, err := r.service.SettlePending(ctx)
+			r.heartbeat.Beat(err)
+			if err != nil {
+				r.logger.Error("payment reconciler failed", "error", err)
+			}
+			if settled > 0 {
+				r.logger.Info("pending payments settled", "count", settled)
+			}
+		}
+	}
+}
+
+// Check fails when the reconciler has stalled and pending payments are no
+// longer being settled.
+func (r *Reconciler) Check(ctx context.Context) error {
+	return r.heartbeat.Check(ctx)
+}
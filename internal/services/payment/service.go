@@ -2,40 +2,351 @@ package paymentservice
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"myproject/internal/entities"
+	"myproject/internal/pkg/gateway"
+	"myproject/internal/pkg/money"
 	paymentrepo "myproject/internal/repositories/payment"
+	"myproject/internal/repositories/txmanager"
+)
+
+const (
+	paymentMethodCard = "card"
+	webhookTolerance  = 5 * time.Minute
+	referencePrefix   = "payment:"
 )
 
 type PaymentService interface {
-	Deposit(ctx context.Context, userID int, amount float64) error
+	Deposit(ctx context.Context, userID int, amount money.Money, cardToken string) (*entities.Payment, error)
 	CreateTransaction(ctx context.Context, tx *entities.Transaction) error
 	GetTransactionsByUser(ctx context.Context, userID int) ([]entities.Transaction, error)
 }
 
+type Ledger interface {
+	RecordDeposit(ctx context.Context, userID int, amount money.Money, reference string) (int, error)
+	RecordDepositRefund(ctx context.Context, userID int, amount money.Money, reference string) (int, error)
+}
+
+type Auditor interface {
+	Record(ctx context.Context, action, entityType string, entityID int, before, after any) error
+}
+
 type Service struct {
-	repo paymentrepo.Repository
+	repo          paymentrepo.Repository
+	tx            txmanager.Manager
+	ledger        Ledger
+	audit         Auditor
+	gateway       gateway.PaymentGateway
+	webhookSecret string
+	chargeTimeout time.Duration
 }
 
-func NewService(repo paymentrepo.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(
+	repo paymentrepo.Repository,
+	tx txmanager.Manager,
+	ledger Ledger,
+	audit Auditor,
+	gw gateway.PaymentGateway,
+	webhookSecret string,
+	chargeTimeout time.Duration,
+) *Service {
+	return &Service{
+		repo:          repo,
+		tx:            tx,
+		ledger:        ledger,
+		audit:         audit,
+		gateway:       gw,
+		webhookSecret: webhookSecret,
+		chargeTimeout: chargeTimeout,
+	}
 }
 
-func (s *Service) Deposit(ctx context.Context, userID int, amount float64) error {
+// Deposit charges the card and credits the wallet once the charge succeeds.
+// A declined card returns the failed payment with entities.ErrPaymentDeclined;
+// a provider timeout returns the payment still pending, to be settled by a
+// webhook or the reconciler.
+func (s *Service) Deposit(ctx context.Context, userID int, amount money.Money, cardToken string) (*entities.Payment, error) {
 	if userID <= 0 {
-		return fmt.Errorf("invalid user ID: %d", userID)
+		return nil, fmt.Errorf("%w: invalid user ID: %d", entities.ErrInvalidInput, userID)
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: invalid deposit amount: %s", entities.ErrInvalidInput, amount)
+	}
+	if strings.TrimSpace(cardToken) == "" {
+		return nil, fmt.Errorf("%w: card token is required", entities.ErrInvalidInput)
+	}
+
+	payment := &entities.Payment{
+		UserID:        userID,
+		Amount:        amount,
+		PaymentMethod: paymentMethodCard,
+		Status:        entities.PaymentStatusPending,
+		Provider:      s.gateway.Name(),
+		CreatedAt:     time.Now(),
+	}
+	var id int
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if id, err = s.repo.Create(ctx, payment); err != nil {
+			return err
+		}
+		payment.ID = id
+		return s.audit.Record(ctx, entities.AuditPaymentDeposit, entities.AuditEntityPayment, id, nil, payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chargeCtx, cancel := context.WithTimeout(ctx, s.chargeTimeout)
+	defer cancel()
+
+	charge, err := s.gateway.CreateCharge(chargeCtx, gateway.ChargeRequest{
+		Amount:    amount,
+		Token:     cardToken,
+		Reference: paymentReference(id),
+	})
+	switch {
+	case errors.Is(err, gateway.ErrDeclined) && charge != nil:
+		payment, err = s.apply(ctx, id, charge)
+		if err != nil {
+			return nil, err
+		}
+		return payment, entities.ErrPaymentDeclined
+	case errors.Is(err, gateway.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return s.repo.GetPaymentByID(ctx, id)
+	case err != nil:
+		return nil, fmt.Errorf("%w: failed to create charge: %w", entities.ErrPaymentProvider, err)
+	}
+
+	if charge.Status == gateway.ChargeAuthorized {
+		captured, err := s.gateway.Capture(chargeCtx, charge.ID)
+		if errors.Is(err, gateway.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			return s.apply(ctx, id, charge)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to capture charge: %w", entities.ErrPaymentProvider, err)
+		}
+		charge = captured
+	}
+	return s.apply(ctx, id, charge)
+}
+
+func (s *Service) GetPayment(ctx context.Context, id int) (*entities.Payment, error) {
+	if id <= 0 {
+		return nil, entities.ErrPaymentNotFound
+	}
+	return s.repo.GetPaymentByID(ctx, id)
+}
+
+func (s *Service) GetPaymentsByUser(ctx context.Context, userID int) ([]entities.Payment, error) {
+	if userID <= 0 {
+		return nil, fmt.Errorf("invalid user ID")
+	}
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+// RefreshPayment asks the provider for the current state of a pending payment.
+func (s *Service) RefreshPayment(ctx context.Context, id int) (*entities.Payment, error) {
+	payment, err := s.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != entities.PaymentStatusPending {
+		return payment, nil
+	}
+
+	lookup := payment.ProviderID
+	if lookup == "" {
+		lookup = paymentReference(payment.ID)
+	}
+	charge, err := s.gateway.GetStatus(ctx, lookup)
+	if errors.Is(err, gateway.ErrChargeNotFound) {
+		return payment, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch charge status: %w", entities.ErrPaymentProvider, err)
+	}
+	return s.apply(ctx, id, charge)
+}
+
+// RefundPayment refunds a succeeded card payment and debits the wallet. It
+// fails with entities.ErrInsufficientFunds if the deposit was already spent.
+func (s *Service) RefundPayment(ctx context.Context, id int) (*entities.Payment, error) {
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		payment, err := s.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if payment.Status != entities.PaymentStatusSucceeded || payment.ProviderID == "" {
+			return entities.ErrPaymentStateConflict
+		}
+
+		if err := s.refundLedger(ctx, payment); err != nil {
+			return err
+		}
+		if err := s.refundCharge(ctx, payment); err != nil {
+			return err
+		}
+
+		refunded, err := s.repo.GetPaymentByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditPaymentRefund, entities.AuditEntityPayment, id, payment, refunded)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPaymentByID(ctx, id)
+}
+
+// HandleWebhook verifies a provider callback and applies the charge state it
+// carries. Redelivered events are harmless.
+func (s *Service) HandleWebhook(ctx context.Context, provider string, payload []byte, signature string) error {
+	if provider != s.gateway.Name() {
+		return gateway.ErrUnknownProvider
 	}
-	if amount <= 0 {
-		return fmt.Errorf("invalid deposit amount: %.2f", amount)
+	if err := gateway.VerifySignature(s.webhookSecret, payload, signature, webhookTolerance, time.Now()); err != nil {
+		return err
+	}
+
+	var event gateway.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("%w: %v", entities.ErrInvalidInput, err)
+	}
+	if event.Charge.ID == "" {
+		return fmt.Errorf("%w: missing charge id", entities.ErrInvalidInput)
+	}
+
+	id, ok := parseReference(event.Charge.Reference)
+	if !ok {
+		payment, err := s.repo.GetByProviderIDForUpdate(ctx, provider, event.Charge.ID)
+		if err != nil {
+			return err
+		}
+		id = payment.ID
 	}
-	return s.repo.Deposit(ctx, userID, amount)
+
+	_, err := s.apply(ctx, id, &event.Charge)
+	return err
+}
+
+// SettlePending refreshes payments that stayed pending for longer than the
+// charge timeout and returns how many changed state.
+func (s *Service) SettlePending(ctx context.Context) (int, error) {
+	pending, err := s.repo.ListPendingBefore(ctx, time.Now().Add(-s.chargeTimeout))
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	var errs []error
+	for _, payment := range pending {
+		updated, err := s.RefreshPayment(ctx, payment.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("payment %d: %w", payment.ID, err))
+			continue
+		}
+		if updated.Status != entities.PaymentStatusPending {
+			settled++
+		}
+	}
+	return settled, errors.Join(errs...)
+}
+
+// apply moves the payment to the state reported by the provider and books the
+// matching ledger entry in the same transaction.
+func (s *Service) apply(ctx context.Context, id int, charge *gateway.Charge) (*entities.Payment, error) {
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		payment, err := s.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if payment.ProviderID == "" {
+			if err := s.repo.SetProviderID(ctx, id, charge.ID); err != nil {
+				return err
+			}
+			payment.ProviderID = charge.ID
+		} else if payment.ProviderID != charge.ID {
+			return fmt.Errorf("%w: charge %s does not belong to payment %d", entities.ErrPaymentStateConflict, charge.ID, id)
+		}
+
+		if cmp, err := charge.Amount.Cmp(payment.Amount); err != nil || cmp != 0 {
+			return fmt.Errorf("%w: charge amount %s does not match payment amount %s", entities.ErrPaymentStateConflict, charge.Amount, payment.Amount)
+		}
+
+		switch {
+		case charge.Status == gateway.ChargeSucceeded && payment.Status == entities.PaymentStatusPending:
+			if err := s.repo.UpdateStatus(ctx, id, entities.PaymentStatusPending, entities.PaymentStatusSucceeded, ""); err != nil {
+				return err
+			}
+			entryID, err := s.ledger.RecordDeposit(ctx, payment.UserID, payment.Amount, paymentReference(id))
+			if err != nil {
+				return fmt.Errorf("failed to record deposit: %w", err)
+			}
+			return s.repo.SetTransactionID(ctx, id, entryID)
+
+		case charge.Status == gateway.ChargeFailed && payment.Status == entities.PaymentStatusPending:
+			reason := charge.FailureReason
+			if reason == "" {
+				reason = "declined"
+			}
+			return s.repo.UpdateStatus(ctx, id, entities.PaymentStatusPending, entities.PaymentStatusFailed, reason)
+
+		case charge.Status == gateway.ChargeRefunded && payment.Status == entities.PaymentStatusSucceeded:
+			return s.refundLedger(ctx, payment)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPaymentByID(ctx, id)
+}
+
+// refundCharge must run after refundLedger so a spent deposit never reaches
+// the provider; a retried transaction finds the charge already refunded.
+func (s *Service) refundCharge(ctx context.Context, payment *entities.Payment) error {
+	_, err := s.gateway.Refund(ctx, payment.ProviderID, payment.Amount)
+	if errors.Is(err, gateway.ErrInvalidState) {
+		charge, statusErr := s.gateway.GetStatus(ctx, payment.ProviderID)
+		if statusErr == nil && charge.Status == gateway.ChargeRefunded {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: failed to refund charge: %w", entities.ErrPaymentProvider, err)
+	}
+	return nil
+}
+
+func (s *Service) refundLedger(ctx context.Context, payment *entities.Payment) error {
+	if err := s.repo.UpdateStatus(ctx, payment.ID, entities.PaymentStatusSucceeded, entities.PaymentStatusRefunded, ""); err != nil {
+		return err
+	}
+	if _, err := s.ledger.RecordDepositRefund(ctx, payment.UserID, payment.Amount, paymentReference(payment.ID)); err != nil {
+		return fmt.Errorf("failed to record refund: %w", err)
+	}
+	return nil
 }
 
 func (s *Service) CreateTransaction(ctx context.Context, tx *entities.Transaction) error {
 	if tx == nil {
 		return fmt.Errorf("transaction cannot be nil")
 	}
-	return s.repo.CreateTransaction(ctx, tx)
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateTransaction(ctx, tx); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditPaymentTransaction, entities.AuditEntityTransaction, tx.ID, nil, tx)
+	})
 }
 
 func (s *Service) GetTransactionsByUser(ctx context.Context, userID int) ([]entities.Transaction, error) {
@@ -44,3 +355,16 @@ func (s *Service) GetTransactionsByUser(ctx context.Context, userID int) ([]enti
 	}
 	return s.repo.GetTransactionsByUserID(ctx, userID)
 }
+
+func paymentReference(id int) string {
+	return referencePrefix + strconv.Itoa(id)
+}
+
+func parseReference(reference string) (int, bool) {
+	raw, ok := strings.CutPrefix(reference, referencePrefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(raw)
+	return id, err == nil && id > 0
+}
@@ -0,0 +1,192 @@
+package reservationservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
+	reservationrepo "myproject/internal/repositories/reservation"
+	"myproject/internal/repositories/txmanager"
+)
+
+type CarService interface {
+	GetCar(ctx context.Context, id int) (*entities.Car, error)
+	LockCar(ctx context.Context, carID int) (*entities.Car, error)
+	UpdateStatus(ctx context.Context, carID int, status string) error
+}
+
+type OrderService interface {
+	CreateReservedOrder(ctx context.Context, order *entities.Order) (int, error)
+}
+
+type Service struct {
+	repo         reservationrepo.Repository
+	tx           txmanager.Manager
+	carService   CarService
+	orderService OrderService
+	holdDuration time.Duration
+}
+
+func NewService(
+	repo reservationrepo.Repository,
+	tx txmanager.Manager,
+	carService CarService,
+	orderService OrderService,
+	holdDuration time.Duration,
+) *Service {
+	return &Service{
+		repo:         repo,
+		tx:           tx,
+		carService:   carService,
+		orderService: orderService,
+		holdDuration: holdDuration,
+	}
+}
+
+func (s *Service) CreateReservation(ctx context.Context, userID, carID int) (*entities.Reservation, error) {
+	if userID <= 0 || carID <= 0 {
+		return nil, entities.ErrInvalidInput
+	}
+
+	var id int
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		car, err := s.carService.LockCar(ctx, carID)
+		if err != nil {
+			return fmt.Errorf("failed to lock car: %w", err)
+		}
+		if car.Status != entities.CarStatusAvailable {
+			return entities.ErrCarNotAvailable
+		}
+
+		now := time.Now()
+		id, err = s.repo.Create(ctx, &entities.Reservation{
+			UserID:    userID,
+			CarID:     carID,
+			Status:    entities.ReservationStatusActive,
+			CreatedAt: now,
+			ExpiresAt: now.Add(s.holdDuration),
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := s.carService.UpdateStatus(ctx, carID, string(entities.CarStatusReserved)); err != nil {
+			return fmt.Errorf("failed to reserve car: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) GetReservation(ctx context.Context, id int) (*entities.Reservation, error) {
+	if id <= 0 {
+		return nil, entities.ErrInvalidID
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) GetReservationsByUserID(ctx context.Context, userID int) ([]entities.Reservation, error) {
+	if userID <= 0 {
+		return nil, entities.ErrInvalidID
+	}
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+func (s *Service) ListAllReservations(ctx context.Context) ([]entities.Reservation, error) {
+	return s.repo.ListAll(ctx)
+}
+
+func (s *Service) CancelReservation(ctx context.Context, id int) error {
+	reservation, err := s.GetReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.release(ctx, reservation, entities.ReservationStatusCancelled)
+}
+
+// ConvertToOrder consumes an active reservation and places an order for the
+// held car at its current price.
+func (s *Service) ConvertToOrder(ctx context.Context, id int, deposit money.Money) (int, error) {
+	reservation, err := s.GetReservation(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if reservation.Status != entities.ReservationStatusActive || !reservation.ExpiresAt.After(time.Now()) {
+		return 0, entities.ErrReservationNotActive
+	}
+
+	var orderID int
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		car, err := s.carService.LockCar(ctx, reservation.CarID)
+		if err != nil {
+			return fmt.Errorf("failed to lock car: %w", err)
+		}
+
+		if err := s.repo.UpdateStatus(ctx, id, entities.ReservationStatusActive, entities.ReservationStatusConverted); err != nil {
+			return err
+		}
+
+		orderID, err = s.orderService.CreateReservedOrder(ctx, &entities.Order{
+			UserID:     reservation.UserID,
+			CarID:      reservation.CarID,
+			Deposit:    deposit,
+			TotalPrice: car.Price,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.repo.SetOrderID(ctx, id, orderID)
+	})
+	return orderID, err
+}
+
+// ExpireStale releases every active reservation whose hold window has passed
+// and returns how many were expired.
+func (s *Service) ExpireStale(ctx context.Context) (int, error) {
+	reservations, err := s.repo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range reservations {
+		err := s.release(ctx, &reservations[i], entities.ReservationStatusExpired)
+		if errors.Is(err, entities.ErrReservationNotActive) {
+			continue
+		}
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire reservation %d: %w", reservations[i].ID, err)
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// release ends the reservation and makes the car available again, unless
+// staff moved it out of reserved in the meantime, e.g. to maintenance.
+func (s *Service) release(ctx context.Context, reservation *entities.Reservation, status string) error {
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		car, err := s.carService.LockCar(ctx, reservation.CarID)
+		if err != nil {
+			return fmt.Errorf("failed to lock car: %w", err)
+		}
+		if err := s.repo.UpdateStatus(ctx, reservation.ID, entities.ReservationStatusActive, status); err != nil {
+			return err
+		}
+		if car.Status != entities.CarStatusReserved {
+			return nil
+		}
+		if err := s.carService.UpdateStatus(ctx, reservation.CarID, string(entities.CarStatusAvailable)); err != nil {
+			return fmt.Errorf("failed to release car: %w", err)
+		}
+		return nil
+	})
+}
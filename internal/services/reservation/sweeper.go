@@ -0,0 +1,54 @@
+package reservationservice
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/pkg/health"
+	"myproject/pkg/logger"
+)
+
+type Sweeper struct {
+	service   *Service
+	interval  time.Duration
+	logger    logger.Interface
+	heartbeat *health.Heartbeat
+}
+
+func NewSweeper(service *Service, interval time.Duration, logger logger.Interface) *Sweeper {
+	return &Sweeper{
+		service:   service,
+		interval:  interval,
+		logger:    logger,
+		heartbeat: health.NewHeartbeat(3 * interval),
+	}
+}
+
+// Run expires stale reservations every interval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.service.ExpireStale(ctx)
+			s.heartbeat.Beat(err)
+			if err != nil {
+				s.logger.Error("reservation sweeper failed", "error", err)
+				continue
+			}
+			if expired > 0 {
+				s.logger.Info("reservations expired", "count", expired)
+			}
+		}
+	}
+}
+
+// Check fails when stale reservations are no longer being expired, which
+// would leave cars held indefinitely.
+func (s *Sweeper) Check(ctx context.Context) error {
+	return s.heartbeat.Check(ctx)
+}
@@ -0,0 +1,224 @@
+package sessionservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/token"
+	sessionrepo "myproject/internal/repositories/session"
+	"myproject/internal/repositories/txmanager"
+)
+
+type Users interface {
+	GetByID(ctx context.Context, id int) (*entities.User, error)
+}
+
+type Service struct {
+	repo       sessionrepo.Repository
+	tx         txmanager.Manager
+	users      Users
+	tokens     token.Maker
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewService(
+	repo sessionrepo.Repository,
+	tx txmanager.Manager,
+	users Users,
+	tokens token.Maker,
+	accessTTL time.Duration,
+	refreshTTL time.Duration,
+) *Service {
+	return &Service{
+		repo:       repo,
+		tx:         tx,
+		users:      users,
+		tokens:     tokens,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Start opens a new session for an authenticated user.
+func (s *Service) Start(ctx context.Context, user *entities.User, meta entities.SessionMeta) (*entities.TokenPair, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	secret, hash, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &entities.Session{
+		ID:               id,
+		UserID:           user.ID,
+		RefreshTokenHash: hash,
+		UserAgent:        meta.UserAgent,
+		IPAddress:        meta.IPAddress,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(s.refreshTTL),
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return s.issue(user, session.ID, secret, session.ExpiresAt)
+}
+
+// Refresh exchanges a refresh token for a new token pair. Presenting a token
+// that was already rotated away means it leaked, so the whole session is
+// revoked and entities.ErrRefreshTokenReused returned. A secret the session
+// never issued only fails with entities.ErrInvalidRefreshToken: the session
+// ID is not secret, and guessing must not log its owner out.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*entities.TokenPair, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || id == "" || secret == "" {
+		return nil, entities.ErrInvalidRefreshToken
+	}
+
+	var (
+		pair   *entities.TokenPair
+		reused bool
+	)
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		reused = false
+		session, err := s.repo.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if session.RevokedAt != nil {
+			return entities.ErrSessionRevoked
+		}
+
+		now := time.Now()
+		hash := hashSecret(secret)
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(session.RefreshTokenHash)) != 1 {
+			rotated, err := s.repo.WasRotated(ctx, session.ID, hash)
+			if err != nil {
+				return err
+			}
+			if !rotated {
+				return entities.ErrInvalidRefreshToken
+			}
+			reused = true
+			return s.repo.Revoke(ctx, session.ID, entities.SessionRevokedTokenReuse, now)
+		}
+		if !now.Before(session.ExpiresAt) {
+			return entities.ErrSessionExpired
+		}
+
+		user, err := s.users.GetByID(ctx, session.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		nextSecret, nextHash, err := newRefreshSecret()
+		if err != nil {
+			return err
+		}
+		expiresAt := now.Add(s.refreshTTL)
+		if err := s.repo.Rotate(ctx, session.ID, nextHash, now, expiresAt); err != nil {
+			return err
+		}
+		pair, err = s.issue(user, session.ID, nextSecret, expiresAt)
+		return err
+	})
+	if errors.Is(err, entities.ErrSessionNotFound) {
+		return nil, entities.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, entities.ErrRefreshTokenReused
+	}
+	return pair, nil
+}
+
+// Logout revokes a single session of the user.
+func (s *Service) Logout(ctx context.Context, userID int, sessionID string) error {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return entities.ErrSessionNotFound
+	}
+	return s.repo.Revoke(ctx, sessionID, entities.SessionRevokedLogout, time.Now())
+}
+
+// LogoutAll revokes every session of the user and returns how many there were.
+func (s *Service) LogoutAll(ctx context.Context, userID int) (int64, error) {
+	return s.repo.RevokeAllForUser(ctx, userID, "", entities.SessionRevokedLogoutAll, time.Now())
+}
+
+// RevokeOthers revokes every session of the user except keepID.
+func (s *Service) RevokeOthers(ctx context.Context, userID int, keepID, reason string) error {
+	_, err := s.repo.RevokeAllForUser(ctx, userID, keepID, reason, time.Now())
+	return err
+}
+
+// IsActive reports whether access tokens of the session are still honored.
+func (s *Service) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if errors.Is(err, entities.ErrSessionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return session.RevokedAt == nil && time.Now().Before(session.ExpiresAt), nil
+}
+
+func (s *Service) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx, time.Now())
+}
+
+func (s *Service) issue(user *entities.User, sessionID, secret string, refreshExpiresAt time.Time) (*entities.TokenPair, error) {
+	accessToken, payload, err := s.tokens.CreateToken(user.ID, user.Role, sessionID, s.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	return &entities.TokenPair{
+		SessionID:        sessionID,
+		AccessToken:      accessToken,
+		AccessExpiresAt:  payload.ExpiresAt,
+		RefreshToken:     sessionID + "." + secret,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// newRefreshSecret returns the secret part of a refresh token and its hash.
+// Refresh tokens have the form "<session id>.<secret>".
+func newRefreshSecret() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(buf)
+	return secret, hashSecret(secret), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
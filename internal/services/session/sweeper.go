@@ -0,0 +1,53 @@
+package sessionservice
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/pkg/health"
+	"myproject/pkg/logger"
+)
+
+type Sweeper struct {
+	service   *Service
+	interval  time.Duration
+	logger    logger.Interface
+	heartbeat *health.Heartbeat
+}
+
+func NewSweeper(service *Service, interval time.Duration, logger logger.Interface) *Sweeper {
+	return &Sweeper{
+		service:   service,
+		interval:  interval,
+		logger:    logger,
+		heartbeat: health.NewHeartbeat(3 * interval),
+	}
+}
+
+// Run deletes expired sessions every interval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.service.PurgeExpired(ctx)
+			s.heartbeat.Beat(err)
+			if err != nil {
+				s.logger.Error("session sweeper failed", "error", err)
+				continue
+			}
+			if purged > 0 {
+				s.logger.Info("expired sessions purged", "count", purged)
+			}
+		}
+	}
+}
+
+// Check fails when the sweeper has not purged sessions for three intervals.
+func (s *Sweeper) Check(ctx context.Context) error {
+	return s.heartbeat.Check(ctx)
+}
@@ -0,0 +1,73 @@
+package statsservice
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"myproject/internal/pkg/metrics"
+	statsrepo "myproject/internal/repositories/stats"
+)
+
+// RegisterMetrics exposes business figures read from the database at scrape
+// time. They describe the whole dealership, so every instance reports the
+// same values and survives restarts without resetting.
+func RegisterMetrics(registry *metrics.Registry, repo statsrepo.Repository) {
+	registry.NewCollector("dealership_cars", "Cars in stock, by status.", metrics.TypeGauge, []string{"status"},
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			counts, err := repo.CarsByStatus(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return countSamples(counts), nil
+		})
+
+	registry.NewCollector("dealership_order_transitions_total",
+		"Order status changes, by new status; pending counts orders placed.", metrics.TypeCounter, []string{"status"},
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			counts, err := repo.OrderTransitions(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return countSamples(counts), nil
+		})
+
+	registry.NewCollector("dealership_deposits", "Card deposits, by current payment status.", metrics.TypeGauge, []string{"status"},
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			totals, err := repo.Deposits(ctx)
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]metrics.Sample, 0, len(totals))
+			for _, t := range totals {
+				samples = append(samples, metrics.Sample{Values: []string{t.Status}, Value: float64(t.Count)})
+			}
+			return samples, nil
+		})
+
+	registry.NewCollector("dealership_deposits_amount", "Card deposit volume in major currency units, by current payment status.",
+		metrics.TypeGauge, []string{"status", "currency"},
+		func(ctx context.Context) ([]metrics.Sample, error) {
+			totals, err := repo.Deposits(ctx)
+			if err != nil {
+				return nil, err
+			}
+			samples := make([]metrics.Sample, 0, len(totals))
+			for _, t := range totals {
+				amount, err := strconv.ParseFloat(t.Amount.Decimal(), 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid deposit amount %q: %w", t.Amount.Decimal(), err)
+				}
+				samples = append(samples, metrics.Sample{Values: []string{t.Status, t.Amount.Currency}, Value: amount})
+			}
+			return samples, nil
+		})
+}
+
+func countSamples(counts map[string]int64) []metrics.Sample {
+	samples := make([]metrics.Sample, 0, len(counts))
+	for key, n := range counts {
+		samples = append(samples, metrics.Sample{Values: []string{key}, Value: float64(n)})
+	}
+	return samples
+}
@@ -0,0 +1,85 @@
+package testdriveservice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OpeningHours describes when the showroom accepts test drives. Opens and
+// Closes are offsets from local midnight.
+type OpeningHours struct {
+	Location *time.Location
+	Opens    time.Duration
+	Closes   time.Duration
+	Days     map[time.Weekday]bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func ParseOpeningHours(timezone, opensAt, closesAt string, days []string) (OpeningHours, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return OpeningHours{}, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+
+	opens, err := parseClock(opensAt)
+	if err != nil {
+		return OpeningHours{}, err
+	}
+	closes, err := parseClock(closesAt)
+	if err != nil {
+		return OpeningHours{}, err
+	}
+	if closes <= opens {
+		return OpeningHours{}, fmt.Errorf("showroom closes at %s before it opens at %s", closesAt, opensAt)
+	}
+
+	hours := OpeningHours{Location: location, Opens: opens, Closes: closes, Days: make(map[time.Weekday]bool)}
+	for _, day := range days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return OpeningHours{}, fmt.Errorf("invalid working day %q", day)
+		}
+		hours.Days[weekday] = true
+	}
+	if len(hours.Days) == 0 {
+		for _, weekday := range weekdays {
+			hours.Days[weekday] = true
+		}
+	}
+	return hours, nil
+}
+
+// Contains reports whether [start, end) falls within a single working day.
+func (h OpeningHours) Contains(start, end time.Time) bool {
+	start, end = start.In(h.Location), end.In(h.Location)
+	if !h.Days[start.Weekday()] {
+		return false
+	}
+	opens, closes := h.window(start)
+	return !start.Before(opens) && !end.After(closes)
+}
+
+// window returns the opening and closing time on the day of t.
+func (h OpeningHours) window(t time.Time) (time.Time, time.Time) {
+	t = t.In(h.Location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, h.Location)
+	return midnight.Add(h.Opens), midnight.Add(h.Closes)
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", value, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,222 @@
+package testdriveservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"myproject/internal/entities"
+	testdriverepo "myproject/internal/repositories/testdrive"
+)
+
+type CarService interface {
+	GetCar(ctx context.Context, id int) (*entities.Car, error)
+}
+
+type UserService interface {
+	GetByID(ctx context.Context, id int) (*entities.User, error)
+}
+
+type Service struct {
+	repo        testdriverepo.Repository
+	carService  CarService
+	userService UserService
+	hours       OpeningHours
+	duration    time.Duration
+}
+
+func NewService(
+	repo testdriverepo.Repository,
+	carService CarService,
+	userService UserService,
+	hours OpeningHours,
+	duration time.Duration,
+) *Service {
+	return &Service{
+		repo:        repo,
+		carService:  carService,
+		userService: userService,
+		hours:       hours,
+		duration:    duration,
+	}
+}
+
+func (s *Service) ScheduleTestDrive(ctx context.Context, testDrive *entities.TestDrive) (*entities.TestDrive, error) {
+	if testDrive == nil || testDrive.UserID <= 0 || testDrive.CarID <= 0 {
+		return nil, entities.ErrInvalidInput
+	}
+
+	car, err := s.carService.GetCar(ctx, testDrive.CarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get car: %w", err)
+	}
+	if car.Status == entities.CarStatusSold {
+		return nil, entities.ErrCarNotAvailable
+	}
+
+	if testDrive.SalespersonID != nil {
+		if err := s.checkSalesperson(ctx, *testDrive.SalespersonID); err != nil {
+			return nil, err
+		}
+	}
+
+	testDrive.EndsAt = testDrive.Date.Add(s.duration)
+	if err := s.checkSlot(ctx, testDrive, 0); err != nil {
+		return nil, err
+	}
+
+	testDrive.Status = entities.TestDriveStatusScheduled
+	id, err := s.repo.Create(ctx, testDrive)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) GetTestDrive(ctx context.Context, id int) (*entities.TestDrive, error) {
+	if id <= 0 {
+		return nil, entities.ErrInvalidID
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) GetTestDrivesByUserID(ctx context.Context, userID int) ([]entities.TestDrive, error) {
+	if userID <= 0 {
+		return nil, entities.ErrInvalidID
+	}
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+func (s *Service) ListAllTestDrives(ctx context.Context) ([]entities.TestDrive, error) {
+	return s.repo.ListAll(ctx)
+}
+
+func (s *Service) RescheduleTestDrive(ctx context.Context, id int, startsAt time.Time) (*entities.TestDrive, error) {
+	testDrive, err := s.GetTestDrive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if testDrive.Status != entities.TestDriveStatusScheduled {
+		return nil, entities.ErrTestDriveNotScheduled
+	}
+
+	testDrive.Date = startsAt
+	testDrive.EndsAt = startsAt.Add(s.duration)
+	if err := s.checkSlot(ctx, testDrive, testDrive.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Reschedule(ctx, id, testDrive.Date, testDrive.EndsAt); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// UpdateTestDriveStatus closes a scheduled test drive as cancelled, completed or no-show.
+func (s *Service) UpdateTestDriveStatus(ctx context.Context, id int, status string) error {
+	if !isFinalStatus(status) {
+		return entities.ErrInvalidTestDriveStatus
+	}
+
+	testDrive, err := s.GetTestDrive(ctx, id)
+	if err != nil {
+		return err
+	}
+	if testDrive.Status != entities.TestDriveStatusScheduled {
+		return entities.ErrTestDriveNotScheduled
+	}
+
+	return s.repo.UpdateStatus(ctx, id, status)
+}
+
+// AvailableSlots lists the free slots for a car on date, a YYYY-MM-DD day in
+// the showroom's time zone.
+func (s *Service) AvailableSlots(ctx context.Context, carID int, date string) ([]entities.TestDriveSlot, error) {
+	if carID <= 0 {
+		return nil, entities.ErrInvalidID
+	}
+	day, err := time.ParseInLocation(time.DateOnly, date, s.hours.Location)
+	if err != nil {
+		return nil, entities.ErrInvalidTestDriveDate
+	}
+
+	slots := []entities.TestDriveSlot{}
+	if !s.hours.Days[day.In(s.hours.Location).Weekday()] {
+		return slots, nil
+	}
+
+	opens, closes := s.hours.window(day)
+	booked, err := s.repo.ListScheduledByCar(ctx, carID, opens, closes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for start := opens; !start.Add(s.duration).After(closes); start = start.Add(s.duration) {
+		end := start.Add(s.duration)
+		if start.Before(now) || overlapsAny(booked, start, end) {
+			continue
+		}
+		slots = append(slots, entities.TestDriveSlot{StartsAt: start, EndsAt: end})
+	}
+	return slots, nil
+}
+
+func (s *Service) checkSlot(ctx context.Context, testDrive *entities.TestDrive, excludeID int) error {
+	if testDrive.Date.Before(time.Now()) {
+		return entities.ErrTestDriveInPast
+	}
+	if !s.hours.Contains(testDrive.Date, testDrive.EndsAt) {
+		return entities.ErrTestDriveOutsideHours
+	}
+
+	busy, err := s.repo.HasCarConflict(ctx, testDrive.CarID, testDrive.Date, testDrive.EndsAt, excludeID)
+	if err != nil {
+		return fmt.Errorf("failed to check car schedule: %w", err)
+	}
+	if busy {
+		return entities.ErrTestDriveConflict
+	}
+
+	if testDrive.SalespersonID != nil {
+		busy, err := s.repo.HasSalespersonConflict(ctx, *testDrive.SalespersonID, testDrive.Date, testDrive.EndsAt, excludeID)
+		if err != nil {
+			return fmt.Errorf("failed to check salesperson schedule: %w", err)
+		}
+		if busy {
+			return entities.ErrTestDriveConflict
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkSalesperson(ctx context.Context, salespersonID int) error {
+	salesperson, err := s.userService.GetByID(ctx, salespersonID)
+	if err != nil {
+		return fmt.Errorf("failed to get salesperson: %w", err)
+	}
+	if salesperson.Role != entities.RoleManager && salesperson.Role != entities.RoleAdmin {
+		return entities.ErrInvalidSalesperson
+	}
+	return nil
+}
+
+func overlapsAny(testDrives []entities.TestDrive, start, end time.Time) bool {
+	for _, td := range testDrives {
+		if td.Date.Before(end) && td.EndsAt.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFinalStatus(status string) bool {
+	switch status {
+	case entities.TestDriveStatusCancelled,
+		entities.TestDriveStatusCompleted,
+		entities.TestDriveStatusNoShow:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,234 @@
+package userservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/email"
+	"myproject/internal/pkg/tracing"
+)
+
+const minPasswordLength = 8
+
+// AccountOptions configures the links mailed to users. AppURL is the base
+// URL of the web app that serves the verification and reset pages.
+type AccountOptions struct {
+	AppURL          string
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+}
+
+type Mailer interface {
+	Send(ctx context.Context, to, template string, data any) error
+}
+
+type accountLinkData struct {
+	Name      string
+	Link      string
+	ExpiresAt time.Time
+}
+
+type passwordChangedData struct {
+	Name      string
+	ChangedAt time.Time
+	ResetLink string
+}
+
+// ResendVerification mails a new verification link to a user whose address
+// is not confirmed yet. Links sent before stop working.
+func (s *Service) ResendVerification(ctx context.Context, userID int) error {
+	ctx, span := tracing.Start(ctx, "userservice.ResendVerification", tracing.Attr("user.id", userID))
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.EmailVerifiedAt != nil {
+		return entities.ErrEmailAlreadyVerified
+	}
+
+	var token string
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		token, err = s.issueAccountToken(ctx, user.ID, entities.TokenPurposeEmailVerification, s.account.VerificationTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.sendVerification(ctx, user, token)
+}
+
+// VerifyEmail redeems a verification token and marks the address confirmed.
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := tracing.Start(ctx, "userservice.VerifyEmail")
+	defer span.End()
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		t, err := s.tokens.Consume(ctx, entities.TokenPurposeEmailVerification, hashAccountToken(token), now)
+		if err != nil {
+			return err
+		}
+		return s.repo.MarkEmailVerified(ctx, t.UserID, now)
+	})
+}
+
+// RequestPasswordReset mails a single-use reset link. An unknown address is
+// not an error, so the endpoint does not reveal which emails are registered.
+func (s *Service) RequestPasswordReset(ctx context.Context, address string) error {
+	ctx, span := tracing.Start(ctx, "userservice.RequestPasswordReset")
+	defer span.End()
+
+	user, err := s.repo.GetByEmail(ctx, strings.TrimSpace(address))
+	if errors.Is(err, entities.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	var token string
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		token, err = s.issueAccountToken(ctx, user.ID, entities.TokenPurposePasswordReset, s.account.ResetTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	data := accountLinkData{
+		Name:      user.Name,
+		Link:      s.accountLink("/reset-password", token),
+		ExpiresAt: time.Now().Add(s.account.ResetTTL).UTC(),
+	}
+	if err := s.mailer.Send(ctx, user.Email, email.TemplatePasswordReset, data); err != nil {
+		return fmt.Errorf("%w: %v", entities.ErrEmailNotSent, err)
+	}
+	return nil
+}
+
+// ResetPassword redeems a reset token and sets a new password. Every session
+// of the user is revoked. Following the link also proves the user owns the
+// address, so it counts as verified from then on.
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "userservice.ResetPassword")
+	defer span.End()
+
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	hashedPassword, err := generateHash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	var userID int
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		t, err := s.tokens.Consume(ctx, entities.TokenPurposePasswordReset, hashAccountToken(token), now)
+		if err != nil {
+			return err
+		}
+		userID = t.UserID
+		if err := s.tokens.Invalidate(ctx, userID, entities.TokenPurposePasswordReset, now); err != nil {
+			return err
+		}
+		if err := s.repo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+		if err := s.repo.MarkEmailVerified(ctx, userID, now); err != nil && !errors.Is(err, entities.ErrEmailAlreadyVerified) {
+			return fmt.Errorf("failed to mark email verified: %w", err)
+		}
+		return s.audit.Record(ctx, entities.AuditUserPasswordReset, entities.AuditEntityUser, userID, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessions.RevokeOthers(ctx, userID, "", entities.SessionRevokedPasswordReset); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return s.notifyPasswordChanged(ctx, userID)
+}
+
+func (s *Service) sendVerification(ctx context.Context, user *entities.User, token string) error {
+	data := accountLinkData{
+		Name:      user.Name,
+		Link:      s.accountLink("/verify-email", token),
+		ExpiresAt: time.Now().Add(s.account.VerificationTTL).UTC(),
+	}
+	if err := s.mailer.Send(ctx, user.Email, email.TemplateVerifyEmail, data); err != nil {
+		return fmt.Errorf("%w: %v", entities.ErrEmailNotSent, err)
+	}
+	return nil
+}
+
+func (s *Service) notifyPasswordChanged(ctx context.Context, userID int) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", entities.ErrEmailNotSent, err)
+	}
+	data := passwordChangedData{
+		Name:      user.Name,
+		ChangedAt: time.Now().UTC(),
+		ResetLink: strings.TrimRight(s.account.AppURL, "/") + "/forgot-password",
+	}
+	if err := s.mailer.Send(ctx, user.Email, email.TemplatePasswordChanged, data); err != nil {
+		return fmt.Errorf("%w: %v", entities.ErrEmailNotSent, err)
+	}
+	return nil
+}
+
+// issueAccountToken invalidates the user's earlier tokens for purpose and
+// stores a new one, returning the raw token for the email link.
+func (s *Service) issueAccountToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	if err := s.tokens.Invalidate(ctx, userID, purpose, now); err != nil {
+		return "", err
+	}
+	err := s.tokens.Create(ctx, &entities.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashAccountToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *Service) accountLink(path, token string) string {
+	return strings.TrimRight(s.account.AppURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func hashAccountToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return entities.ErrWeakPassword
+	}
+	return nil
+}
@@ -5,7 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"myproject/internal/entities"
+	"myproject/internal/pkg/identity"
+	"myproject/internal/pkg/money"
+	"myproject/internal/pkg/tracing"
+	accounttokenrepo "myproject/internal/repositories/accounttoken"
+	"myproject/internal/repositories/txmanager"
 	userrepo "myproject/internal/repositories/user"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -16,65 +22,235 @@ type UseCase interface {
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
 	Update(ctx context.Context, user *entities.User) error
 	Delete(ctx context.Context, id int) error
-	List(ctx context.Context, limit, offset int) ([]*entities.User, error)
+	List(ctx context.Context, page entities.PageRequest) ([]*entities.User, entities.PageInfo, error)
 	ChangePassword(ctx context.Context, userID int, oldPassword, newPassword string) error
-	Authenticate(ctx context.Context, email, password string) (string, *entities.User, error)
+	Authenticate(ctx context.Context, email, password string, meta entities.SessionMeta) (*entities.SignIn, error)
 	Count(ctx context.Context) (int, error)
-	CheckBalance(ctx context.Context, userID int, amount float64) (bool, error)
-	DeductBalance(ctx context.Context, userID int, amount float64) error
+	UnlockUser(ctx context.Context, id int) error
+	ListLockoutEvents(ctx context.Context, id int) ([]entities.LockoutEvent, error)
+	ResendVerification(ctx context.Context, userID int) error
+	VerifyEmail(ctx context.Context, token string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	CheckBalance(ctx context.Context, userID int, amount money.Money) (bool, error)
+}
+
+var _ UseCase = (*Service)(nil)
+
+type Sessions interface {
+	Start(ctx context.Context, user *entities.User, meta entities.SessionMeta) (*entities.TokenPair, error)
+	RevokeOthers(ctx context.Context, userID int, keepID, reason string) error
+}
+
+// LoginGuard throttles repeated failed sign-ins.
+type LoginGuard interface {
+	Reserve(ctx context.Context, email, ip string, userID *int) error
+	Release(ctx context.Context, email, ip string) error
+	RecordSuccess(ctx context.Context, email string) error
+	Unlock(ctx context.Context, userID int, email string) error
+	ListEvents(ctx context.Context, userID int) ([]entities.LockoutEvent, error)
+}
+
+// Auditor records administrative changes to users.
+type Auditor interface {
+	Record(ctx context.Context, action, entityType string, entityID int, before, after any) error
+}
+
+// MFA decides whether a sign-in needs a second factor.
+type MFA interface {
+	Challenge(ctx context.Context, user *entities.User, meta entities.SessionMeta) (*entities.MFAChallengeToken, error)
 }
 
 type Service struct {
-	repo userrepo.Repository
+	repo     userrepo.Repository
+	tx       txmanager.Manager
+	sessions Sessions
+	guard    LoginGuard
+	mfa      MFA
+	tokens   accounttokenrepo.Repository
+	mailer   Mailer
+	audit    Auditor
+	account  AccountOptions
 }
 
-func NewUserService(repo userrepo.Repository) *Service {
-	return &Service{repo: repo}
+func NewUserService(
+	repo userrepo.Repository,
+	tx txmanager.Manager,
+	sessions Sessions,
+	guard LoginGuard,
+	mfa MFA,
+	tokens accounttokenrepo.Repository,
+	mailer Mailer,
+	audit Auditor,
+	account AccountOptions,
+) *Service {
+	return &Service{
+		repo:     repo,
+		tx:       tx,
+		sessions: sessions,
+		guard:    guard,
+		mfa:      mfa,
+		tokens:   tokens,
+		mailer:   mailer,
+		audit:    audit,
+		account:  account,
+	}
 }
 
+const bcryptCost = 14
+
 func generateHash(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
 	return string(bytes), err
 }
 
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// compareDummyHash spends as long as checking a real password, so a sign-in
+// with an unknown email takes as long as one with a wrong password.
+func compareDummyHash(password string) {
+	dummyHashOnce.Do(func() {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcryptCost)
+		dummyHash = string(hash)
+	})
+	checkPasswordHash(password, dummyHash)
+}
+
 func checkPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
 
+// Create registers a customer and mails them a link to confirm their address.
+// The user is created even if the mail fails; the error then wraps
+// entities.ErrEmailNotSent and the link can be requested again.
 func (s *Service) Create(ctx context.Context, user *entities.User) error {
+	ctx, span := tracing.Start(ctx, "userservice.Create")
+	defer span.End()
+
+	if err := validatePassword(user.Password); err != nil {
+		return err
+	}
 	hashedPassword, err := generateHash(user.Password)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 	user.PasswordHash = hashedPassword
-	user.Balance = 0
-	user.Role = "customer"
+	user.Balance = money.Zero()
+	user.Role = entities.RoleCustomer
+	user.EmailVerifiedAt = nil
 
-	return s.repo.Create(ctx, user)
+	var token string
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, user); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, entities.AuditUserCreate, entities.AuditEntityUser, user.ID, nil, user); err != nil {
+			return err
+		}
+		var err error
+		token, err = s.issueAccountToken(ctx, user.ID, entities.TokenPurposeEmailVerification, s.account.VerificationTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return s.sendVerification(ctx, user, token)
 }
 
 func (s *Service) GetByID(ctx context.Context, id int) (*entities.User, error) {
+	ctx, span := tracing.Start(ctx, "userservice.GetByID", tracing.Attr("user.id", id))
+	defer span.End()
+
 	return s.repo.GetByID(ctx, id)
 }
 
 func (s *Service) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	ctx, span := tracing.Start(ctx, "userservice.GetByEmail")
+	defer span.End()
+
 	return s.repo.GetByEmail(ctx, email)
 }
 
+// Update changes the user's profile. Changing the role signs the user out
+// everywhere. A new email is unverified until the
+// user opens the link mailed to it; if that mail fails the error wraps
+// entities.ErrEmailNotSent after the change is saved.
 func (s *Service) Update(ctx context.Context, user *entities.User) error {
-	return s.repo.Update(ctx, user)
+	ctx, span := tracing.Start(ctx, "userservice.Update", tracing.Attr("user.id", user.ID))
+	defer span.End()
+
+	var updated *entities.User
+	var token string
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.GetByID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		// Balance is owned by the ledger; an empty role keeps the current one.
+		user.Balance = existing.Balance
+		if user.Role == "" {
+			user.Role = existing.Role
+		}
+		if err := s.repo.Update(ctx, user); err != nil {
+			return err
+		}
+
+		updated, err = s.repo.GetByID(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		// Sessions carry the role they were opened with; a new role, staff
+		// ones in particular, has to go through sign-in and its second factor.
+		if updated.Role != existing.Role {
+			if err := s.sessions.RevokeOthers(ctx, user.ID, "", entities.SessionRevokedRoleChange); err != nil {
+				return fmt.Errorf("failed to revoke sessions: %w", err)
+			}
+		}
+		if updated.Email != existing.Email {
+			if token, err = s.issueAccountToken(ctx, user.ID, entities.TokenPurposeEmailVerification, s.account.VerificationTTL); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(ctx, entities.AuditUserUpdate, entities.AuditEntityUser, user.ID, existing, updated)
+	})
+	if err != nil || token == "" {
+		return err
+	}
+	return s.sendVerification(ctx, updated, token)
 }
 
 func (s *Service) Delete(ctx context.Context, id int) error {
-	return s.repo.Delete(ctx, id)
+	ctx, span := tracing.Start(ctx, "userservice.Delete", tracing.Attr("user.id", id))
+	defer span.End()
+
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditUserDelete, entities.AuditEntityUser, id, existing, nil)
+	})
 }
 
-func (s *Service) List(ctx context.Context, limit, offset int) ([]*entities.User, error) {
-	return s.repo.List(ctx, limit, offset)
+func (s *Service) List(ctx context.Context, page entities.PageRequest) ([]*entities.User, entities.PageInfo, error) {
+	ctx, span := tracing.Start(ctx, "userservice.List")
+	defer span.End()
+
+	return s.repo.List(ctx, page)
 }
 
 func (s *Service) ChangePassword(ctx context.Context, userID int, oldPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "userservice.ChangePassword", tracing.Attr("user.id", userID))
+	defer span.End()
+
 	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
@@ -83,52 +259,122 @@ func (s *Service) ChangePassword(ctx context.Context, userID int, oldPassword, n
 	if !checkPasswordHash(oldPassword, user.PasswordHash) {
 		return errors.New("incorrect old password")
 	}
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
 
 	hashedPassword, err := generateHash(newPassword)
 	if err != nil {
 		return fmt.Errorf("failed to hash new password: %w", err)
 	}
 
-	user.PasswordHash = hashedPassword
-	return s.repo.Update(ctx, user)
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditUserPasswordChange, entities.AuditEntityUser, userID, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Other devices have to log in again with the new password.
+	caller, _ := identity.FromContext(ctx)
+	if err := s.sessions.RevokeOthers(ctx, userID, caller.SessionID, entities.SessionRevokedPasswordChange); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return s.notifyPasswordChanged(ctx, userID)
 }
 
-func (s *Service) Authenticate(ctx context.Context, email, password string) (string, *entities.User, error) {
+// Authenticate checks the credentials and opens a new session, or returns a
+// two-factor challenge instead when the user has or needs an authenticator.
+// An unknown email and a wrong password fail the same way and take the same
+// time; both count towards the lockout of the account and of the client IP.
+// The attempt is counted before the password is compared and handed back when
+// it matches, so parallel guesses cannot slip past the throttle.
+func (s *Service) Authenticate(ctx context.Context, email, password string, meta entities.SessionMeta) (*entities.SignIn, error) {
+	ctx, span := tracing.Start(ctx, "userservice.Authenticate")
+	defer span.End()
+
 	user, err := s.repo.GetByEmail(ctx, email)
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to get user by email: %w", err)
+	if err != nil && !errors.Is(err, entities.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	var userID *int
+	if user != nil {
+		userID = &user.ID
+	}
+	if err := s.guard.Reserve(ctx, email, meta.IPAddress, userID); err != nil {
+		return nil, err
 	}
 
+	if user == nil {
+		compareDummyHash(password)
+		return nil, entities.ErrInvalidCredentials
+	}
 	if !checkPasswordHash(password, user.PasswordHash) {
-		return "", nil, errors.New("incorrect password")
+		return nil, entities.ErrInvalidCredentials
+	}
+	if err := s.guard.Release(ctx, email, meta.IPAddress); err != nil {
+		return nil, fmt.Errorf("failed to release sign-in attempt: %w", err)
 	}
 
-	token := "generated_token"
-	return token, user, nil
+	// Failures are only cleared once the second factor is through too, so a
+	// leaked password does not allow unlimited guessing of codes.
+	challenge, err := s.mfa.Challenge(ctx, user, meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start mfa challenge: %w", err)
+	}
+	if challenge != nil {
+		return &entities.SignIn{Challenge: challenge}, nil
+	}
+
+	if err := s.guard.RecordSuccess(ctx, email); err != nil {
+		return nil, fmt.Errorf("failed to reset sign-in failures: %w", err)
+	}
+	tokens, err := s.sessions.Start(ctx, user, meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	return &entities.SignIn{Tokens: tokens, User: user}, nil
+}
+
+// UnlockUser lifts a sign-in lockout of the user's account.
+func (s *Service) UnlockUser(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "userservice.UnlockUser", tracing.Attr("user.id", id))
+	defer span.End()
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.guard.Unlock(ctx, user.ID, user.Email); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, entities.AuditUserUnlock, entities.AuditEntityUser, user.ID, nil, nil)
+	})
+}
+
+func (s *Service) ListLockoutEvents(ctx context.Context, id int) ([]entities.LockoutEvent, error) {
+	return s.guard.ListEvents(ctx, id)
 }
 
 func (s *Service) Count(ctx context.Context) (int, error) {
 	return s.repo.Count(ctx)
 }
 
-func (s *Service) CheckBalance(ctx context.Context, userID int, amount float64) (bool, error) {
+func (s *Service) CheckBalance(ctx context.Context, userID int, amount money.Money) (bool, error) {
+	ctx, span := tracing.Start(ctx, "userservice.CheckBalance", tracing.Attr("user.id", userID))
+	defer span.End()
+
 	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
 		return false, fmt.Errorf("failed to get user: %w", err)
 	}
-	return user.Balance >= amount, nil
-}
-
-func (s *Service) DeductBalance(ctx context.Context, userID int, amount float64) error {
-	user, err := s.repo.GetByID(ctx, userID)
+	cmp, err := user.Balance.Cmp(amount)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+		return false, err
 	}
-
-	if user.Balance < amount {
-		return errors.New("insufficient funds")
-	}
-
-	newBalance := user.Balance - amount
-	return s.repo.UpdateBalance(ctx, userID, newBalance)
+	return cmp >= 0, nil
 }
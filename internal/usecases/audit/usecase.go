@@ -0,0 +1,12 @@
+package auditcase
+
+import (
+	"context"
+
+	"myproject/internal/entities"
+)
+
+type UseCase interface {
+	List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEvent, error)
+	Verify(ctx context.Context) (*entities.AuditVerification, error)
+}
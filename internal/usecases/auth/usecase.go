@@ -0,0 +1,13 @@
+package authcase
+
+import (
+	"context"
+
+	"myproject/internal/entities"
+)
+
+type UseCase interface {
+	Refresh(ctx context.Context, refreshToken string) (*entities.TokenPair, error)
+	Logout(ctx context.Context, userID int, sessionID string) error
+	LogoutAll(ctx context.Context, userID int) (int64, error)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"myproject/internal/entities"
+	"myproject/internal/pkg/vin"
 	carrepo "myproject/internal/repositories/car"
 )
 
@@ -12,8 +13,11 @@ type CarUseCase interface {
 	GetCar(ctx context.Context, id int) (*entities.Car, error)
 	UpdateCar(ctx context.Context, id int, input entities.CarUpdate) (*entities.Car, error)
 	DeleteCar(ctx context.Context, id int) error
-	ListCars(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, int, error)
+	ListCars(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, entities.PageInfo, error)
+	SuggestCars(ctx context.Context, prefix string, limit int) ([]entities.CarSuggestion, error)
+	CarFacets(ctx context.Context, filter entities.CarFilter) (entities.FacetSummary, error)
 	ChangeCarStatus(ctx context.Context, id int, status entities.CarStatus) (*entities.Car, error)
+	DecodeVIN(ctx context.Context, number string) (vin.Info, error)
 }
 
 type carUseCase struct {
@@ -83,12 +87,36 @@ func (uc *carUseCase) DeleteCar(ctx context.Context, id int) error {
 	return nil
 }
 
-func (uc *carUseCase) ListCars(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, int, error) {
-	cars, err := uc.repo.List(ctx, filter)
+func (uc *carUseCase) ListCars(ctx context.Context, filter entities.CarFilter) ([]*entities.Car, entities.PageInfo, error) {
+	cars, page, err := uc.repo.List(ctx, filter)
 	if err != nil {
-		return nil, 0, fmt.Errorf("list cars: %w", err)
+		return nil, entities.PageInfo{}, fmt.Errorf("list cars: %w", err)
 	}
-	return cars, len(cars), nil
+	return cars, page, nil
+}
+
+func (uc *carUseCase) CarFacets(ctx context.Context, filter entities.CarFilter) (entities.FacetSummary, error) {
+	facets, err := uc.repo.Facets(ctx, filter)
+	if err != nil {
+		return entities.FacetSummary{}, fmt.Errorf("car facets: %w", err)
+	}
+	return facets, nil
+}
+
+func (uc *carUseCase) SuggestCars(ctx context.Context, prefix string, limit int) ([]entities.CarSuggestion, error) {
+	suggestions, err := uc.repo.Suggest(ctx, prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("suggest cars: %w", err)
+	}
+	return suggestions, nil
+}
+
+func (uc *carUseCase) DecodeVIN(ctx context.Context, number string) (vin.Info, error) {
+	info, err := vin.Decode(number)
+	if err != nil {
+		return vin.Info{}, fmt.Errorf("%w: %v", entities.ErrInvalidVIN, err)
+	}
+	return info, nil
 }
 
 func (uc *carUseCase) ChangeCarStatus(ctx context.Context, id int, status entities.CarStatus) (*entities.Car, error) {
@@ -0,0 +1,13 @@
+package ledgercase
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type UseCase interface {
+	GetStatement(ctx context.Context, userID int, from, to time.Time) (*entities.Statement, error)
+	Reconcile(ctx context.Context) ([]entities.BalanceDrift, error)
+}
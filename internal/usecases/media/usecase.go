@@ -0,0 +1,15 @@
+package mediacase
+
+import (
+	"context"
+
+	"myproject/internal/entities"
+)
+
+type UseCase interface {
+	UploadMedia(ctx context.Context, carID int, upload entities.MediaUpload) (*entities.CarMedia, error)
+	ListMedia(ctx context.Context, carID int) ([]*entities.CarMedia, error)
+	SetPrimaryMedia(ctx context.Context, carID, mediaID int) (*entities.CarMedia, error)
+	ReorderMedia(ctx context.Context, carID int, ids []int) ([]*entities.CarMedia, error)
+	DeleteMedia(ctx context.Context, carID, mediaID int) error
+}
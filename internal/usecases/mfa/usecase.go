@@ -0,0 +1,19 @@
+package mfacase
+
+import (
+	"context"
+
+	"myproject/internal/entities"
+)
+
+type UseCase interface {
+	VerifyChallenge(ctx context.Context, challengeToken, code string, meta entities.SessionMeta) (*entities.SignIn, error)
+	BeginChallengeEnrollment(ctx context.Context, challengeToken string) (*entities.MFAEnrollment, error)
+	ConfirmChallengeEnrollment(ctx context.Context, challengeToken, code string, meta entities.SessionMeta) (*entities.SignIn, error)
+	Status(ctx context.Context, userID int) (*entities.MFAStatus, error)
+	BeginEnrollment(ctx context.Context, userID int) (*entities.MFAEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID int, code string) ([]string, error)
+	Disable(ctx context.Context, userID int, code string, meta entities.SessionMeta) error
+	Reset(ctx context.Context, userID int) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string, meta entities.SessionMeta) ([]string, error)
+}
@@ -0,0 +1,17 @@
+package reservationcase
+
+import (
+	"context"
+
+	"myproject/internal/entities"
+	"myproject/internal/pkg/money"
+)
+
+type UseCase interface {
+	CreateReservation(ctx context.Context, userID, carID int) (*entities.Reservation, error)
+	GetReservation(ctx context.Context, id int) (*entities.Reservation, error)
+	GetReservationsByUserID(ctx context.Context, userID int) ([]entities.Reservation, error)
+	ListAllReservations(ctx context.Context) ([]entities.Reservation, error)
+	CancelReservation(ctx context.Context, id int) error
+	ConvertToOrder(ctx context.Context, id int, deposit money.Money) (int, error)
+}
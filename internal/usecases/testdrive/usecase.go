@@ -0,0 +1,18 @@
+package testdrivecase
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/entities"
+)
+
+type UseCase interface {
+	ScheduleTestDrive(ctx context.Context, testDrive *entities.TestDrive) (*entities.TestDrive, error)
+	GetTestDrive(ctx context.Context, id int) (*entities.TestDrive, error)
+	GetTestDrivesByUserID(ctx context.Context, userID int) ([]entities.TestDrive, error)
+	ListAllTestDrives(ctx context.Context) ([]entities.TestDrive, error)
+	RescheduleTestDrive(ctx context.Context, id int, startsAt time.Time) (*entities.TestDrive, error)
+	UpdateTestDriveStatus(ctx context.Context, id int, status string) error
+	AvailableSlots(ctx context.Context, carID int, date string) ([]entities.TestDriveSlot, error)
+}
@@ -0,0 +1,4 @@
+UPDATE users SET role = 'customer' WHERE role IS NULL OR role = 'user';
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'customer';
+ALTER TABLE users ALTER COLUMN role SET NOT NULL;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('customer', 'manager', 'admin'));
@@ -0,0 +1,14 @@
+CREATE TABLE reservations (
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    car_id int not null references cars(id) on delete restrict,
+    order_id int references orders(id) on delete set null,
+    status varchar(20) not null default 'active',
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp,
+    expires_at timestamp not null
+);
+
+CREATE UNIQUE INDEX idx_reservations_active_car ON reservations(car_id) WHERE status = 'active';
+CREATE INDEX idx_reservations_user_id ON reservations(user_id);
+CREATE INDEX idx_reservations_expires_at ON reservations(expires_at) WHERE status = 'active';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_test_drives_user_id;
+DROP INDEX IF EXISTS idx_test_drives_car_date;
+
+ALTER TABLE test_drives DROP CONSTRAINT IF EXISTS test_drives_salesperson_no_overlap;
+ALTER TABLE test_drives DROP CONSTRAINT IF EXISTS test_drives_car_no_overlap;
+ALTER TABLE test_drives DROP CONSTRAINT IF EXISTS test_drives_time_check;
+
+ALTER TABLE test_drives DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE test_drives DROP COLUMN IF EXISTS salesperson_id;
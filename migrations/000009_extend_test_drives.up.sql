@@ -0,0 +1,17 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE test_drives ADD COLUMN salesperson_id int references users(id) on delete set null;
+ALTER TABLE test_drives ADD COLUMN ends_at timestamp;
+UPDATE test_drives SET ends_at = date + interval '30 minutes' WHERE ends_at IS NULL;
+ALTER TABLE test_drives ALTER COLUMN ends_at SET NOT NULL;
+
+ALTER TABLE test_drives ADD CONSTRAINT test_drives_time_check CHECK (ends_at > date);
+ALTER TABLE test_drives ADD CONSTRAINT test_drives_car_no_overlap
+    EXCLUDE USING gist (car_id WITH =, tsrange(date, ends_at) WITH &&)
+    WHERE (status = 'scheduled');
+ALTER TABLE test_drives ADD CONSTRAINT test_drives_salesperson_no_overlap
+    EXCLUDE USING gist (salesperson_id WITH =, tsrange(date, ends_at) WITH &&)
+    WHERE (status = 'scheduled' AND salesperson_id IS NOT NULL);
+
+CREATE INDEX idx_test_drives_car_date ON test_drives(car_id, date);
+CREATE INDEX idx_test_drives_user_id ON test_drives(user_id);
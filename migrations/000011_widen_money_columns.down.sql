@@ -0,0 +1,11 @@
+alter table transactions alter column amount type decimal(10, 2);
+
+alter table orders
+    alter column deposit type decimal(10, 2),
+    alter column total_price type decimal(12, 2);
+
+alter table cars alter column price type decimal(12, 2);
+
+alter table users
+    alter column balance drop not null,
+    alter column balance type decimal(10, 2);
@@ -0,0 +1,13 @@
+update users set balance = 0 where balance is null;
+
+alter table users
+    alter column balance type numeric(18, 2),
+    alter column balance set not null;
+
+alter table cars alter column price type numeric(18, 2);
+
+alter table orders
+    alter column deposit type numeric(18, 2),
+    alter column total_price type numeric(18, 2);
+
+alter table transactions alter column amount type numeric(18, 2);
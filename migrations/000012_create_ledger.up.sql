@@ -0,0 +1,95 @@
+CREATE TABLE ledger_accounts (
+    id serial primary key,
+    code varchar(64) unique not null,
+    type varchar(20) not null check (type in ('asset', 'liability', 'equity', 'revenue')),
+    user_id int unique references users(id) on delete restrict,
+    name varchar(100) not null,
+    balance numeric(18, 2) not null default 0,
+    created_at timestamp default current_timestamp
+);
+
+CREATE TABLE journal_entries (
+    id serial primary key,
+    type varchar(32) not null,
+    reference varchar(64) not null default '',
+    description text not null default '',
+    created_at timestamp default current_timestamp
+);
+
+CREATE TABLE ledger_postings (
+    id serial primary key,
+    entry_id int not null references journal_entries(id) on delete restrict,
+    account_id int not null references ledger_accounts(id) on delete restrict,
+    amount numeric(18, 2) not null check (amount <> 0)
+);
+
+CREATE INDEX idx_journal_entries_created_at ON journal_entries(created_at);
+CREATE INDEX idx_journal_entries_reference ON journal_entries(reference);
+CREATE INDEX idx_ledger_postings_entry_id ON ledger_postings(entry_id);
+CREATE INDEX idx_ledger_postings_account_id ON ledger_postings(account_id);
+
+-- Every entry must balance once its transaction commits.
+CREATE FUNCTION ledger_check_entry_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT coalesce(sum(amount), 0) FROM ledger_postings WHERE entry_id = NEW.entry_id) <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_entry_balanced();
+
+-- Posted entries are immutable; corrections are new entries.
+CREATE FUNCTION ledger_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER journal_entries_immutable
+    BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+
+CREATE TRIGGER ledger_postings_immutable
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+
+INSERT INTO ledger_accounts (code, type, name) VALUES
+    ('cash', 'asset', 'Cash and payment clearing'),
+    ('deposits_held', 'liability', 'Customer funds held against orders'),
+    ('dealership_revenue', 'revenue', 'Dealership revenue'),
+    ('refunds', 'liability', 'Refunds clearing'),
+    ('opening_balances', 'equity', 'Opening balances');
+
+-- Carry existing balances into the ledger as opening entries.
+INSERT INTO ledger_accounts (code, type, user_id, name)
+SELECT 'wallet:' || id, 'liability', id, 'Customer wallet #' || id FROM users WHERE balance <> 0;
+
+DO $$
+DECLARE
+    wallet record;
+    entry int;
+    opening int;
+BEGIN
+    SELECT id INTO opening FROM ledger_accounts WHERE code = 'opening_balances';
+    FOR wallet IN
+        SELECT a.id AS account_id, u.id AS user_id, u.balance
+        FROM ledger_accounts a JOIN users u ON u.id = a.user_id
+    LOOP
+        INSERT INTO journal_entries (type, reference, description)
+        VALUES ('opening_balance', 'user:' || wallet.user_id, 'Opening balance')
+        RETURNING id INTO entry;
+
+        INSERT INTO ledger_postings (entry_id, account_id, amount) VALUES
+            (entry, opening, wallet.balance),
+            (entry, wallet.account_id, -wallet.balance);
+
+        UPDATE ledger_accounts SET balance = balance + wallet.balance WHERE id = opening;
+        UPDATE ledger_accounts SET balance = -wallet.balance WHERE id = wallet.account_id;
+    END LOOP;
+END;
+$$;
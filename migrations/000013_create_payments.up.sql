@@ -0,0 +1,18 @@
+CREATE TABLE payments (
+    id serial primary key,
+    user_id int not null references users(id) on delete restrict,
+    amount numeric(18, 2) not null check (amount > 0),
+    payment_method varchar(20) not null default 'card',
+    status varchar(20) not null default 'pending'
+        check (status in ('pending', 'succeeded', 'failed', 'refunded')),
+    provider varchar(32) not null,
+    provider_id varchar(128),
+    failure_reason text not null default '',
+    transaction_id int references journal_entries(id),
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp
+);
+
+CREATE UNIQUE INDEX idx_payments_provider_charge ON payments(provider, provider_id) WHERE provider_id IS NOT NULL;
+CREATE INDEX idx_payments_user_id ON payments(user_id);
+CREATE INDEX idx_payments_pending ON payments(created_at) WHERE status = 'pending';
@@ -0,0 +1,16 @@
+CREATE TABLE idempotency_keys (
+    user_id int not null references users(id) on delete cascade,
+    key varchar(255) not null,
+    fingerprint char(64) not null,
+    status varchar(20) not null default 'processing'
+        check (status in ('processing', 'completed')),
+    response_status int,
+    response_body bytea,
+    content_type varchar(255) not null default '',
+    created_at timestamp not null default current_timestamp,
+    updated_at timestamp not null default current_timestamp,
+    expires_at timestamp not null,
+    primary key (user_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
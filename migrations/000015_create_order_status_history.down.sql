@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS order_status_history;
+
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_status_check,
+    ALTER COLUMN status DROP NOT NULL,
+    ALTER COLUMN status SET DEFAULT 'reserved';
@@ -0,0 +1,23 @@
+UPDATE orders SET status = 'pending' WHERE status IS NULL OR status = 'reserved';
+
+ALTER TABLE orders
+    ALTER COLUMN status SET DEFAULT 'pending',
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT orders_status_check
+        check (status in ('pending', 'confirmed', 'paid', 'shipped', 'delivered', 'completed', 'cancelled'));
+
+CREATE TABLE order_status_history (
+    id serial primary key,
+    order_id int not null references orders(id) on delete cascade,
+    from_status varchar(20),
+    to_status varchar(20) not null,
+    actor_id int references users(id) on delete set null,
+    reason text not null default '',
+    created_at timestamp not null default current_timestamp
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, created_at);
+
+INSERT INTO order_status_history (order_id, from_status, to_status, reason, created_at)
+SELECT id, null, status, 'recorded when history tracking was introduced', coalesce(updated_at, created_at, current_timestamp)
+FROM orders;
@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_paid_amount_check;
+ALTER TABLE orders DROP COLUMN IF EXISTS paid_amount;
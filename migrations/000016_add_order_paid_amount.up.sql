@@ -0,0 +1,8 @@
+ALTER TABLE orders ADD COLUMN paid_amount numeric(18, 2) not null default 0;
+
+-- Orders placed before partial payments existed were charged in full; cancelled
+-- ones were refunded.
+UPDATE orders SET paid_amount = total_price WHERE status <> 'cancelled';
+
+ALTER TABLE orders ADD CONSTRAINT orders_paid_amount_check
+    check (paid_amount >= 0 and paid_amount <= total_price);
@@ -0,0 +1,16 @@
+CREATE TABLE sessions (
+    id char(32) primary key,
+    user_id int not null references users(id) on delete cascade,
+    refresh_token_hash char(64) not null,
+    generation int not null default 1,
+    user_agent text not null default '',
+    ip_address varchar(45) not null default '',
+    created_at timestamp not null default current_timestamp,
+    last_used_at timestamp not null default current_timestamp,
+    expires_at timestamp not null,
+    revoked_at timestamp,
+    revoke_reason varchar(32) not null default ''
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_sessions_expires_at ON sessions(expires_at);
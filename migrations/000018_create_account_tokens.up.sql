@@ -0,0 +1,17 @@
+alter table users add column email_verified_at timestamp;
+
+-- Accounts that existed before verification was introduced keep ordering.
+update users set email_verified_at = coalesce(created_at, current_timestamp);
+
+create table account_tokens (
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    purpose varchar(32) not null check (purpose in ('email_verification', 'password_reset')),
+    token_hash char(64) not null unique,
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null default current_timestamp
+);
+
+create index idx_account_tokens_user_purpose on account_tokens(user_id, purpose) where used_at is null;
+create index idx_account_tokens_expires_at on account_tokens(expires_at);
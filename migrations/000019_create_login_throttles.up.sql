@@ -0,0 +1,27 @@
+create table login_throttles (
+    scope varchar(16) not null check (scope in ('account', 'ip')),
+    subject varchar(255) not null,
+    failures int not null default 0,
+    lockouts int not null default 0,
+    first_failure_at timestamp not null,
+    last_failure_at timestamp not null,
+    locked_until timestamp,
+    primary key (scope, subject)
+);
+
+create index idx_login_throttles_last_failure_at on login_throttles(last_failure_at);
+
+create table lockout_events (
+    id serial primary key,
+    event varchar(16) not null check (event in ('locked', 'unlocked')),
+    scope varchar(16) not null check (scope in ('account', 'ip')),
+    subject varchar(255) not null,
+    user_id int references users(id) on delete set null,
+    ip_address varchar(45) not null default '',
+    failures int not null default 0,
+    locked_until timestamp,
+    actor_id int references users(id) on delete set null,
+    created_at timestamp not null default current_timestamp
+);
+
+create index idx_lockout_events_user_id on lockout_events(user_id, created_at);
@@ -0,0 +1,3 @@
+drop table if exists mfa_challenges;
+drop table if exists mfa_recovery_codes;
+drop table if exists mfa_factors;
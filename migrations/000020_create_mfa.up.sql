@@ -0,0 +1,30 @@
+create table mfa_factors (
+    user_id int primary key references users(id) on delete cascade,
+    secret varchar(64) not null,
+    confirmed_at timestamp,
+    last_used_step bigint not null default 0,
+    created_at timestamp not null default current_timestamp
+);
+
+create table mfa_recovery_codes (
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    code_hash char(64) not null,
+    used_at timestamp,
+    created_at timestamp not null default current_timestamp,
+    unique (user_id, code_hash)
+);
+
+create table mfa_challenges (
+    token_hash char(64) primary key,
+    user_id int not null references users(id) on delete cascade,
+    purpose varchar(16) not null check (purpose in ('verify', 'enroll')),
+    attempts int not null default 0,
+    user_agent text not null default '',
+    ip_address varchar(45) not null default '',
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp not null default current_timestamp
+);
+
+create index idx_mfa_challenges_expires_at on mfa_challenges(expires_at);
@@ -0,0 +1,3 @@
+drop table if exists audit_chain_head;
+drop table if exists audit_events;
+drop function if exists audit_reject_change();
@@ -0,0 +1,43 @@
+create table audit_events (
+    id bigserial primary key,
+    actor_id int,
+    actor_role varchar(32) not null default '',
+    action varchar(64) not null,
+    entity_type varchar(32) not null,
+    entity_id int not null,
+    changes json not null default '{}',
+    request_id varchar(128) not null default '',
+    ip_address varchar(45) not null default '',
+    created_at timestamp not null,
+    prev_hash char(64) not null unique,
+    hash char(64) not null unique
+);
+
+create index idx_audit_events_entity on audit_events(entity_type, entity_id, id);
+create index idx_audit_events_actor_id on audit_events(actor_id, id);
+create index idx_audit_events_created_at on audit_events(created_at);
+
+-- The newest hash of the chain. Appending locks this row, so entries are
+-- chained one at a time.
+create table audit_chain_head (
+    id int primary key check (id = 1),
+    last_hash char(64) not null
+);
+
+insert into audit_chain_head (id, last_hash) values (1, repeat('0', 64));
+
+-- Audit entries are never changed or removed; actor_id deliberately has no
+-- foreign key so deleting a user keeps the trail intact.
+create function audit_reject_change() returns trigger as $$
+begin
+    raise exception '% is append-only', TG_TABLE_NAME;
+end;
+$$ language plpgsql;
+
+create trigger audit_events_immutable
+    before update or delete on audit_events
+    for each row execute function audit_reject_change();
+
+create trigger audit_events_no_truncate
+    before truncate on audit_events
+    for each statement execute function audit_reject_change();
@@ -0,0 +1,18 @@
+drop index if exists idx_orders_status_id;
+drop index if exists idx_orders_created_at_id;
+drop index if exists idx_users_created_at_id;
+drop index if exists idx_users_name_id;
+drop index if exists idx_cars_brand_id;
+drop index if exists idx_cars_mileage_id;
+drop index if exists idx_cars_year_id;
+drop index if exists idx_cars_price_id;
+drop index if exists idx_cars_created_at_id;
+
+alter table orders
+    alter column updated_at drop not null,
+    alter column created_at drop not null;
+alter table users alter column created_at drop not null;
+alter table cars
+    alter column updated_at drop not null,
+    alter column created_at drop not null,
+    alter column mileage drop not null;
@@ -0,0 +1,29 @@
+-- Keyset pagination compares sort keys with = and <, which never match NULL.
+update cars set mileage = 0 where mileage is null;
+update cars set created_at = current_timestamp where created_at is null;
+update cars set updated_at = created_at where updated_at is null;
+alter table cars
+    alter column mileage set not null,
+    alter column created_at set not null,
+    alter column updated_at set not null;
+
+update users set created_at = current_timestamp where created_at is null;
+alter table users alter column created_at set not null;
+
+update orders set created_at = current_timestamp where created_at is null;
+update orders set updated_at = created_at where updated_at is null;
+alter table orders
+    alter column created_at set not null,
+    alter column updated_at set not null;
+
+create index idx_cars_created_at_id on cars (created_at, id);
+create index idx_cars_price_id on cars (price, id);
+create index idx_cars_year_id on cars (year, id);
+create index idx_cars_mileage_id on cars (mileage, id);
+create index idx_cars_brand_id on cars (brand, id);
+
+create index idx_users_name_id on users (name, id);
+create index idx_users_created_at_id on users (created_at, id);
+
+create index idx_orders_created_at_id on orders (created_at, id);
+create index idx_orders_status_id on orders (status, id);
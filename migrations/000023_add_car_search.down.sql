@@ -0,0 +1,12 @@
+drop index if exists idx_cars_model_trgm;
+drop index if exists idx_cars_brand_trgm;
+drop index if exists idx_cars_search_text_trgm;
+drop index if exists idx_cars_search_vector;
+
+alter table cars
+    drop column if exists search_vector,
+    drop column if exists search_text,
+    drop column if exists description;
+
+drop function if exists car_search_vector(text, text, int, text, text);
+drop function if exists car_search_text(text, text, int, text, text);
@@ -0,0 +1,34 @@
+create extension if not exists pg_trgm;
+
+alter table cars add column description text not null default '';
+
+-- carrepo passes the new column values to these on every insert and update,
+-- so the search columns never lag behind the row.
+create function car_search_text(brand text, model text, year int, color text, description text)
+    returns text
+    language sql immutable
+as $$
+    select lower(concat_ws(' ', brand, model, year::text, color, description))
+$$;
+
+create function car_search_vector(brand text, model text, year int, color text, description text)
+    returns tsvector
+    language sql immutable
+as $$
+    select setweight(to_tsvector('simple'::regconfig, concat_ws(' ', brand, model)), 'A')
+        || setweight(to_tsvector('simple'::regconfig, concat_ws(' ', year::text, color)), 'B')
+        || setweight(to_tsvector('simple'::regconfig, coalesce(description, '')), 'C')
+$$;
+
+alter table cars
+    add column search_text text not null default '',
+    add column search_vector tsvector not null default ''::tsvector;
+
+update cars set
+    search_text = car_search_text(brand, model, year, color, description),
+    search_vector = car_search_vector(brand, model, year, color, description);
+
+create index idx_cars_search_vector on cars using gin (search_vector);
+create index idx_cars_search_text_trgm on cars using gin (search_text gin_trgm_ops);
+create index idx_cars_brand_trgm on cars using gin (lower(brand) gin_trgm_ops);
+create index idx_cars_model_trgm on cars using gin (lower(model) gin_trgm_ops);
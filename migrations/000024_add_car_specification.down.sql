@@ -0,0 +1,17 @@
+drop index if exists idx_cars_features;
+drop index if exists idx_cars_fuel_type;
+drop index if exists idx_cars_body_type;
+drop index if exists idx_cars_vin;
+
+alter table cars
+    drop column if exists features,
+    drop column if exists condition,
+    drop column if exists seats,
+    drop column if exists doors,
+    drop column if exists power_hp,
+    drop column if exists engine_volume,
+    drop column if exists drivetrain,
+    drop column if exists transmission,
+    drop column if exists fuel_type,
+    drop column if exists body_type,
+    drop column if exists vin;
@@ -0,0 +1,34 @@
+-- Empty strings and zeros stand for an unknown value so the columns can be
+-- filtered, faceted and scanned without NULL handling. vin stays nullable:
+-- it is unique when present.
+alter table cars
+    add column vin varchar(17),
+    add column body_type varchar(20) not null default '',
+    add column fuel_type varchar(20) not null default '',
+    add column transmission varchar(20) not null default '',
+    add column drivetrain varchar(10) not null default '',
+    add column engine_volume numeric(3, 1) not null default 0,
+    add column power_hp int not null default 0,
+    add column doors smallint not null default 0,
+    add column seats smallint not null default 0,
+    add column condition varchar(10) not null default 'used',
+    add column features jsonb not null default '{}';
+
+alter table cars
+    add constraint chk_cars_body_type check (body_type in
+        ('', 'sedan', 'hatchback', 'wagon', 'coupe', 'convertible', 'suv', 'crossover', 'minivan', 'van', 'pickup')),
+    add constraint chk_cars_fuel_type check (fuel_type in
+        ('', 'petrol', 'diesel', 'hybrid', 'plugin_hybrid', 'electric', 'lpg')),
+    add constraint chk_cars_transmission check (transmission in ('', 'manual', 'automatic', 'cvt', 'dct')),
+    add constraint chk_cars_drivetrain check (drivetrain in ('', 'fwd', 'rwd', 'awd', '4wd')),
+    add constraint chk_cars_condition check (condition in ('new', 'used')),
+    add constraint chk_cars_engine_volume check (engine_volume >= 0),
+    add constraint chk_cars_power_hp check (power_hp >= 0),
+    add constraint chk_cars_doors check (doors between 0 and 9),
+    add constraint chk_cars_seats check (seats between 0 and 99),
+    add constraint chk_cars_features check (jsonb_typeof(features) = 'object');
+
+create unique index idx_cars_vin on cars (vin) where vin is not null;
+create index idx_cars_body_type on cars (body_type);
+create index idx_cars_fuel_type on cars (fuel_type);
+create index idx_cars_features on cars using gin (features);
@@ -0,0 +1,19 @@
+create table car_media (
+    id serial primary key,
+    car_id int not null references cars (id) on delete cascade,
+    kind varchar(16) not null check (kind in ('photo', 'document')),
+    storage_key varchar(512) not null unique,
+    thumbnail_keys jsonb not null default '{}',
+    filename varchar(255) not null,
+    content_type varchar(100) not null,
+    size_bytes bigint not null check (size_bytes > 0),
+    width int not null default 0,
+    height int not null default 0,
+    position int not null default 0,
+    is_primary boolean not null default false,
+    created_at timestamp not null default current_timestamp,
+    check (not is_primary or kind = 'photo')
+);
+
+create index idx_car_media_car_id_position on car_media (car_id, position, id);
+create unique index idx_car_media_primary on car_media (car_id) where is_primary;
@@ -0,0 +1,3 @@
+drop trigger if exists car_media_queue_blobs on car_media;
+drop function if exists car_media_queue_blobs();
+drop table if exists media_orphans;
@@ -0,0 +1,27 @@
+-- Blobs waiting to be deleted from media storage. Rows are queued when media
+-- is removed, including through a car being deleted, and before an upload
+-- stores its blobs; the upload dequeues them when it commits.
+create table media_orphans (
+    storage_key varchar(512) primary key,
+    delete_after timestamp not null
+);
+
+create index idx_media_orphans_delete_after on media_orphans (delete_after);
+
+create function car_media_queue_blobs() returns trigger as $$
+begin
+    insert into media_orphans (storage_key, delete_after)
+    select key, now() at time zone 'utc'
+    from (
+        select OLD.storage_key as key
+        union all
+        select value from jsonb_each_text(OLD.thumbnail_keys)
+    ) keys
+    on conflict (storage_key) do update set delete_after = excluded.delete_after;
+    return OLD;
+end;
+$$ language plpgsql;
+
+create trigger car_media_queue_blobs
+    after delete on car_media
+    for each row execute function car_media_queue_blobs();
@@ -0,0 +1,7 @@
+-- Hashes of refresh tokens a session has rotated away from. Presenting one
+-- of them again means the token leaked.
+CREATE TABLE session_rotated_tokens (
+    session_id char(32) not null references sessions(id) on delete cascade,
+    token_hash char(64) not null,
+    primary key (session_id, token_hash)
+);
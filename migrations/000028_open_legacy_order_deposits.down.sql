@@ -0,0 +1,31 @@
+-- Postings are append-only, so the order opening entries are reversed rather
+-- than deleted.
+DO $$
+DECLARE
+    opened record;
+    entry int;
+BEGIN
+    FOR opened IN
+        SELECT j.id, j.reference
+        FROM journal_entries j
+        WHERE j.type = 'opening_balance' AND j.reference LIKE 'order:%'
+          AND j.description = 'Opening balance'
+          AND NOT EXISTS (
+              SELECT 1 FROM journal_entries r
+              WHERE r.reference = j.reference AND r.description = 'Opening balance reversed' AND r.id > j.id
+          )
+        ORDER BY j.id
+    LOOP
+        INSERT INTO journal_entries (type, reference, description)
+        VALUES ('opening_balance', opened.reference, 'Opening balance reversed')
+        RETURNING id INTO entry;
+
+        INSERT INTO ledger_postings (entry_id, account_id, amount)
+        SELECT entry, p.account_id, -p.amount FROM ledger_postings p WHERE p.entry_id = opened.id;
+
+        UPDATE ledger_accounts a SET balance = a.balance - p.amount
+        FROM ledger_postings p
+        WHERE p.entry_id = opened.id AND a.id = p.account_id;
+    END LOOP;
+END;
+$$;
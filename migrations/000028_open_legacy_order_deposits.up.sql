@@ -0,0 +1,50 @@
+-- Orders paid before the ledger existed never moved money into deposits_held.
+-- Open what they paid there, so refunds and completions taking it out again
+-- leave the account balanced: the paid amount for orders still open, and
+-- whatever later entries already took out for orders closed since then.
+-- Orders paid through the ledger have their own payment entries.
+DO $$
+DECLARE
+    ord record;
+    entry int;
+    opening int;
+    held int;
+BEGIN
+    SELECT id INTO opening FROM ledger_accounts WHERE code = 'opening_balances';
+    SELECT id INTO held FROM ledger_accounts WHERE code = 'deposits_held';
+    FOR ord IN
+        SELECT o.id, CASE WHEN o.status IN ('cancelled', 'completed')
+            THEN coalesce(taken.amount, 0) ELSE o.paid_amount END AS amount
+        FROM orders o
+        LEFT JOIN LATERAL (
+            SELECT sum(p.amount) AS amount
+            FROM journal_entries j JOIN ledger_postings p ON p.entry_id = j.id
+            WHERE j.reference = 'order:' || o.id AND p.account_id = held AND p.amount > 0
+        ) taken ON true
+        WHERE NOT EXISTS (
+            SELECT 1 FROM journal_entries j
+            WHERE j.reference = 'order:' || o.id AND j.type = 'order_payment'
+        )
+        AND (
+            SELECT count(*) FILTER (WHERE j.description = 'Opening balance')
+                - count(*) FILTER (WHERE j.description = 'Opening balance reversed')
+            FROM journal_entries j
+            WHERE j.reference = 'order:' || o.id AND j.type = 'opening_balance'
+        ) = 0
+        ORDER BY o.id
+    LOOP
+        CONTINUE WHEN ord.amount <= 0;
+
+        INSERT INTO journal_entries (type, reference, description)
+        VALUES ('opening_balance', 'order:' || ord.id, 'Opening balance')
+        RETURNING id INTO entry;
+
+        INSERT INTO ledger_postings (entry_id, account_id, amount) VALUES
+            (entry, opening, ord.amount),
+            (entry, held, -ord.amount);
+
+        UPDATE ledger_accounts SET balance = balance + ord.amount WHERE id = opening;
+        UPDATE ledger_accounts SET balance = balance - ord.amount WHERE id = held;
+    END LOOP;
+END;
+$$;
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the key/value pairs in addition
+// to those already stored; loggers bound to it with WithContext add them to
+// every entry.
+func NewContext(ctx context.Context, args ...interface{}) context.Context {
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	record := slog.NewRecord(time.Time{}, 0, "", 0)
+	record.Add(args...)
+
+	merged := make([]slog.Attr, len(attrs), len(attrs)+record.NumAttrs())
+	copy(merged, attrs)
+	record.Attrs(func(a slog.Attr) bool {
+		merged = append(merged, a)
+		return true
+	})
+	return context.WithValue(ctx, contextKey{}, merged)
+}
+
+// FromContext returns the default logger bound to ctx, for code such as
+// repositories that has no logger of its own.
+func FromContext(ctx context.Context) Interface {
+	l := slog.Default()
+	if _, ok := l.Handler().(contextHandler); !ok {
+		l = slog.New(contextHandler{l.Handler()})
+	}
+	return &Logger{logger: l, ctx: ctx}
+}
+
+// contextHandler adds the attributes stored by NewContext to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -1,9 +1,16 @@
 package logger
 
+import "context"
+
 type Interface interface {
 	Debug(msg string, args ...interface{})
 	Info(msg string, args ...interface{})
 	Warn(msg string, args ...interface{})
 	Error(msg string, args ...interface{})
 	Fatal(msg string, args ...interface{})
+	// With returns a logger that adds the key/value pairs to every entry.
+	With(args ...interface{}) Interface
+	// WithContext returns a logger that adds the attributes stored in ctx by
+	// NewContext, such as the request ID.
+	WithContext(ctx context.Context) Interface
 }
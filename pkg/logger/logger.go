@@ -1,45 +1,146 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"runtime"
+	"strings"
+	"time"
 )
 
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	levelFatal = slog.LevelError + 4
+)
+
+// Logger writes structured entries through log/slog. Args are key/value
+// pairs as in slog, e.g. logger.Error("create car failed", "error", err).
 type Logger struct {
-	*log.Logger
-	logLevel string
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+// New returns a logger writing to stdout at level ("debug", "info", "warn"
+// or "error") in format ("json" or "text"). It also becomes the slog default,
+// which FromContext falls back to.
+func New(level, format string) (*Logger, error) {
+	return NewWithWriter(os.Stdout, level, format)
 }
 
-func New(level string) *Logger {
-	l := &Logger{
-		Logger:   log.New(os.Stdout, "", log.LstdFlags|log.Lshortfile),
-		logLevel: level,
+func NewWithWriter(w io.Writer, level, format string) (*Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
 	}
-	return l
+
+	opts := &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       lvl,
+		ReplaceAttr: replaceAttr,
+	}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(w, opts)
+	case FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	l := slog.New(contextHandler{handler})
+	slog.SetDefault(l)
+	return &Logger{logger: l}, nil
 }
 
-func (l *Logger) Debug(msg string, args ...interface{}) {
-	if l.logLevel == "debug" {
-		l.Printf("[DEBUG] "+msg, args...)
+// ParseLevel accepts the slog level names in any case, "warning" and
+// offsets such as "info+2".
+func ParseLevel(level string) (slog.Level, error) {
+	if strings.EqualFold(level, "warning") {
+		return slog.LevelWarn, nil
+	}
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", level)
 	}
+	return lvl, nil
 }
 
-func (l *Logger) Info(msg string, args ...interface{}) {
-	if l.logLevel == "debug" || l.logLevel == "info" {
-		l.Printf("[INFO] "+msg, args...)
+func replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey {
+		if lvl, ok := a.Value.Any().(slog.Level); ok && lvl == levelFatal {
+			a.Value = slog.StringValue("FATAL")
+		}
+	}
+	if a.Key == slog.SourceKey {
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			a.Value = slog.StringValue(fmt.Sprintf("%s:%d", shortFile(src.File), src.Line))
+		}
 	}
+	return a
 }
 
-func (l *Logger) Warn(msg string, args ...interface{}) {
-	if l.logLevel == "debug" || l.logLevel == "info" || l.logLevel == "warn" {
-		l.Printf("[WARN] "+msg, args...)
+func shortFile(path string) string {
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		if j := strings.LastIndexByte(path[:i], '/'); j >= 0 {
+			return path[j+1:]
+		}
 	}
+	return path
+}
+
+func (l *Logger) Debug(msg string, args ...interface{}) {
+	l.log(slog.LevelDebug, msg, args)
+}
+
+func (l *Logger) Info(msg string, args ...interface{}) {
+	l.log(slog.LevelInfo, msg, args)
+}
+
+func (l *Logger) Warn(msg string, args ...interface{}) {
+	l.log(slog.LevelWarn, msg, args)
 }
 
 func (l *Logger) Error(msg string, args ...interface{}) {
-	l.Printf("[ERROR] "+msg, args...)
+	l.log(slog.LevelError, msg, args)
 }
 
 func (l *Logger) Fatal(msg string, args ...interface{}) {
-	l.Fatalf("[FATAL] "+msg, args...)
+	l.log(levelFatal, msg, args)
+	os.Exit(1)
+}
+
+func (l *Logger) With(args ...interface{}) Interface {
+	return &Logger{logger: l.logger.With(args...), ctx: l.ctx}
+}
+
+func (l *Logger) WithContext(ctx context.Context) Interface {
+	return &Logger{logger: l.logger, ctx: ctx}
+}
+
+// Slog exposes the underlying logger for libraries that take a *slog.Logger.
+func (l *Logger) Slog() *slog.Logger {
+	return l.logger
+}
+
+// log reports the caller of Debug, Info and so on as the source.
+func (l *Logger) log(level slog.Level, msg string, args []interface{}) {
+	ctx := l.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = l.logger.Handler().Handle(ctx, r)
 }